@@ -2,15 +2,17 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"flag"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/QwaQ-dev/servicesSubscription/internal/config"
 	"github.com/QwaQ-dev/servicesSubscription/internal/handlers"
-	postgres "github.com/QwaQ-dev/servicesSubscription/internal/repository"
+	"github.com/QwaQ-dev/servicesSubscription/internal/repository"
 	"github.com/QwaQ-dev/servicesSubscription/internal/routes"
 	"github.com/QwaQ-dev/servicesSubscription/internal/services"
 	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
@@ -23,23 +25,65 @@ const (
 )
 
 func main() {
-	app := fiber.New(fiber.Config{
-		BodyLimit: 1024 * 1024 * 1024,
-	})
+	storage := flag.String("storage", "", "subscriptions storage: postgres or memory, overrides the config")
+	flag.Parse()
 
 	cfg := config.MustLoad()
+	if *storage != "" {
+		cfg.Storage = *storage
+	}
+
 	log := setupLogger(cfg.Env)
 
-	log.Info("Starting subscriptions backend", slog.String("env", cfg.Env))
-	db, err := postgres.InitDatabase(cfg.Database, log)
-	if err != nil {
-		log.Error("Error with connecting to database", sl.Err(err))
+	// requestsCtx is the parent of every request context, cancelling it
+	// aborts the queries of requests that outlive the graceful shutdown.
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	app := fiber.New(fiber.Config{
+		BodyLimit:    1024 * 1024 * 1024,
+		ErrorHandler: handlers.ErrorHandler(log),
+	})
+	app.Use(handlers.RequestContext(requestsCtx))
+
+	log.Info("Starting subscriptions backend", slog.String("env", cfg.Env), slog.String("storage", cfg.Storage))
+
+	var (
+		db               *sql.DB
+		subscriptionRepo repository.SubscriptionRepository
+	)
+
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		var err error
+		db, err = repository.InitDatabase(cfg.Database, log)
+		if err != nil {
+			log.Error("Error with connecting to database", sl.Err(err))
+			os.Exit(1)
+		}
+
+		subscriptionRepo = repository.NewSubsriptionRepo(db, cfg.Database.QueryTimeout, log)
+	case config.StorageMemory:
+		log.Warn("Using in-memory storage, subscriptions are lost on restart")
+		subscriptionRepo = repository.NewMemorySubscriptionRepo(log)
+	default:
+		log.Error("Unknown storage", slog.String("storage", cfg.Storage))
 		os.Exit(1)
 	}
 
-	subscriptionRepo := postgres.NewSubsriptionRepo(db, log)
-	subscriptionService := services.NewSubsriptionService(subscriptionRepo, log)
-	subscriptionHandler := handlers.NewSubsriptionHandler(subscriptionService, log)
+	var notifier services.Notifier
+	if cfg.Alerts.WebhookURL != "" {
+		log.Info("Budget alerts go to webhook", slog.String("url", cfg.Alerts.WebhookURL))
+		notifier = services.NewWebhookNotifier(cfg.Alerts.WebhookURL, &http.Client{Timeout: cfg.Alerts.WebhookTimeout})
+	}
+
+	subscriptionService := services.NewSubsriptionService(subscriptionRepo, notifier, log)
+	subscriptionHandler := handlers.NewSubsriptionHandler(subscriptionService)
+
+	// The jobs stop together with the in-flight requests.
+	go subscriptionService.RunPurgeJob(requestsCtx, cfg.Purge.Interval, cfg.Purge.Retention)
+	go subscriptionService.RunLifecycleJob(requestsCtx, cfg.Lifecycle.Interval)
+	go subscriptionService.RunAlertJob(requestsCtx)
 
 	routes.InitRoutes(app, log, subscriptionHandler)
 
@@ -57,11 +101,9 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	db.Close()
-
 	log.Info("Shutting down application...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := app.ShutdownWithContext(ctx); err != nil {
@@ -70,6 +112,12 @@ func main() {
 		log.Info("Fiber server gracefully stopped.")
 	}
 
+	cancelRequests()
+
+	if db != nil {
+		db.Close()
+	}
+
 	log.Info("Application exited.")
 }
 
@@ -15,39 +15,140 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscription/": {
+        "/audit": {
             "get": {
-                "description": "List of all subscriptions",
+                "description": "Paginated changes of all subscriptions, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Audit"
+                ],
+                "summary": "Query the audit trail",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, 500 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change, see the X-Actor header",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "create, update, delete, restore or purge",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "subscription_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, inclusive",
+                        "name": "to",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get All subscriptions",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.AuditPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/budgets/": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Budgets"
+                ],
+                "summary": "Get budgets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user UUID",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "budgets",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "array",
                                 "items": {
-                                    "$ref": "#/definitions/structures.Subscription"
+                                    "$ref": "#/definitions/structures.Budget"
                                 }
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Creating new subscription",
+                "description": "Caps the monthly spend of the user on every subscription, on a category or on a catalog service.\nCreating or updating a subscription that pushes a month over a budget raises an alert",
                 "consumes": [
                     "application/json"
                 ],
@@ -55,17 +156,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Budgets"
                 ],
-                "summary": "Create Subscription",
+                "summary": "Create budget",
                 "parameters": [
                     {
-                        "description": "Subscription data",
-                        "name": "subscription",
+                        "description": "Budget data",
+                        "name": "budget",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/structures.Subscription"
+                            "$ref": "#/definitions/structures.Budget"
                         }
                     }
                 ],
@@ -78,34 +179,114 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid subscription format",
+                        "description": "Invalid budget format",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "User already has a budget with this scope",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     },
                     "500": {
-                        "description": "Error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     }
                 }
             }
         },
-        "/subscription/{id}": {
+        "/budgets/report": {
             "get": {
-                "description": "returns subscription by ID",
+                "description": "For every budget and every month from from to to, the share of the user in the covered\nsubscriptions counted like /summ/ in the currency of the budget, what remains and whether it is over",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Budgets"
                 ],
-                "summary": "Get one subscription by ID",
+                "summary": "Compare spend with budgets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user UUID, budgets of every user by default",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "first month in MM-YYYY, the current month by default",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last month in MM-YYYY, from by default, at most 120 months after it",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "amortized",
+                            "charges"
+                        ],
+                        "type": "string",
+                        "default": "amortized",
+                        "description": "amortized spreads each charge over its billing period, charges counts it in the month it is charged",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.BudgetReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/budgets/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Budgets"
+                ],
+                "summary": "Get one budget by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "subscription ID",
+                        "description": "budget ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -113,27 +294,35 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Budget",
                         "schema": {
-                            "$ref": "#/definitions/structures.Subscription"
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/structures.Budget"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update subscription by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -141,64 +330,81 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Budgets"
                 ],
-                "summary": "Update subscription",
+                "summary": "Update budget",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "subscription ID",
+                        "description": "budget ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "subscription data",
-                        "name": "subscription",
+                        "description": "Budget data",
+                        "name": "budget",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/structures.Subscription"
+                            "$ref": "#/definitions/structures.Budget"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message",
+                        "description": "updated Budget",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "string"
+                                "$ref": "#/definitions/structures.Budget"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "User already has a budget with this scope",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete subscription by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Budgets"
                 ],
-                "summary": "Delete subscription",
+                "summary": "Delete budget",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "subscription ID",
+                        "description": "budget ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -217,21 +423,159 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     }
                 }
             }
         },
-        "/summ/": {
+        "/forecast": {
+            "get": {
+                "description": "Projects the spend of every month from the current one on with the total of each month and what\nevery subscription adds to it. Months are counted like /summ/monthly: billing periods, scheduled\nprices, pauses, end dates and shares apply the same way",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sum"
+                ],
+                "summary": "Forecast spend of the coming months",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 12,
+                        "description": "number of months, the current one included, at most 120",
+                        "name": "months",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user UUID, only the share of the user in the subscriptions they pay for or share",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "currency of the amounts, RUB by default",
+                        "name": "target_currency",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "amortized",
+                            "charges"
+                        ],
+                        "type": "string",
+                        "default": "amortized",
+                        "description": "amortized spreads each charge over its billing period, charges counts it in the month it is charged",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Forecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/rates": {
             "get": {
-                "description": "Returns the sum of all subscriptions filtered by date, user and service",
+                "description": "Returns stored exchange rates ordered by month and currency pair",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Exchange rates"
+                ],
+                "summary": "Get exchange rates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 code on either side of the pair",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "first month in MM-YYYY",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last month in MM-YYYY",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "rates",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/structures.ExchangeRate"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Stores the rate of one unit of from in to, valid from month until the next rate of the pair.\nA rate already stored for the same month and pair is replaced",
                 "consumes": [
                     "application/json"
                 ],
@@ -239,83 +583,3162 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Sum"
+                    "Exchange rates"
                 ],
-                "summary": "Get summ of subscriptions prices",
+                "summary": "Store exchange rates",
                 "parameters": [
                     {
-                        "description": "Filters",
-                        "name": "counting",
+                        "description": "Exchange rates",
+                        "name": "rates",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/structures.Counting"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structures.ExchangeRate"
+                            }
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "total",
+                        "description": "stored",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "number",
-                                "format": "float64"
+                                "type": "integer"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/structures.ErrorResponse"
+                            "$ref": "#/definitions/structures.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "structures.Counting": {
-            "type": "object",
-            "properties": {
-                "end_date": {
-                    "type": "string"
-                },
+        },
+        "/rates/csv": {
+            "post": {
+                "description": "Stores the rates of a CSV document with a month,from,to,rate header like PUT /rates.\nThe document is either the request body or the file field of a multipart form",
+                "consumes": [
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Exchange rates"
+                ],
+                "summary": "Upload exchange rates as CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV document",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "stored",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/renewals": {
+            "get": {
+                "description": "Lists the charges of all subscriptions from today until within from now, ordered by date and subscription, a page at a time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Charges"
+                ],
+                "summary": "Get upcoming renewals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "30d",
+                        "description": "days or weeks like 30d or 2w, at most 366d",
+                        "name": "within",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user UUID, subscriptions shared with the user included",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, 500 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.RenewalPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/": {
+            "get": {
+                "description": "Lists all services ordered by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Get the service catalog",
+                "responses": {
+                    "200": {
+                        "description": "services",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/structures.Service"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscriptions created with the name or an alias of the service are linked to it and take its name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Add a service to the catalog",
+                "parameters": [
+                    {
+                        "description": "Service data",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.Service"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message + id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid service format",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Name or alias is taken",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/lookup": {
+            "get": {
+                "description": "Fuzzy search over names and aliases: case, spacing, ё and Cyrillic or Latin spelling\ndo not matter, small typos are tolerated. Matches are ordered by score from 0 to 1",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Find services by name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "service name as typed by the user",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of matches, 5 by default, 50 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "matches",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/structures.ServiceMatch"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/match": {
+            "post": {
+                "description": "Links the subscriptions not linked yet whose service_name is a name or alias in the catalog,\nfor instance after adding aliases, and reports the names still unmatched",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Link subscriptions to the catalog",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.ServiceMatchReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/unmatched": {
+            "get": {
+                "description": "Lists the service_name of subscriptions not linked to the catalog with the number of\nsubscriptions, most used first, and the closest catalog entry as suggestion",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Get service names missing from the catalog",
+                "responses": {
+                    "200": {
+                        "description": "unmatched",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/structures.UnmatchedName"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Get one service by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/structures.Service"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Overwrites the service and its aliases. Subscriptions of the service take its new name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Update service",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Service data",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.Service"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated Service",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/structures.Service"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Name or alias is taken",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a service no subscription refers to. Soft-deleted subscriptions count until they are purged",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Delete service",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Service has subscriptions",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/": {
+            "get": {
+                "description": "Paginated list of subscriptions. Use next_cursor from the response as cursor for the next page",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get All subscriptions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, 500 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of subscriptions to skip, cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-id",
+                        "description": "id, price, start_date or service_name, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "service name, a name in the catalog matches all its aliases",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "catalog service ID",
+                        "name": "service_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "subscription category, case-insensitive",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tag the subscription has, case-insensitive",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "month in MM-YYYY the subscription is active at",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimal price, decimal like 99.90",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "maximal price, decimal like 499.00",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "trial, active, paused, cancelled or expired",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include soft-deleted subscriptions",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.SubscriptionPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creating new subscription",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Create Subscription",
+                "parameters": [
+                    {
+                        "description": "Subscription data",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message + id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid subscription format",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}": {
+            "get": {
+                "description": "returns subscription by ID with its version as ETag",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get one subscription by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "return the subscription even if it is soft-deleted",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update subscription by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Update subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "subscription data",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Soft-deletes subscription by ID. It can be restored until it is purged after the retention period",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Delete subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Updates only the fields present in the patch. Accepts JSON Merge Patch (RFC 7386,\napplication/merge-patch+json or application/json), where null clears end_date,\nand JSON Patch (RFC 6902, application/json-patch+json) on top-level fields",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Partially update subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "merge patch object or array of JSON Patch operations",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated subscription",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/structures.Subscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "JSON Patch test operation failed",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/cancel": {
+            "post": {
+                "description": "Cancels a trial, active or paused subscription. at=period_end (default) sets end_date to the last\nmonth of the billing period already paid for, at=immediately to the current month.\nCancelled and expired subscriptions and ones that have not started yet give 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lifecycle"
+                ],
+                "summary": "Cancel subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "when the subscription ends",
+                        "name": "cancel",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/structures.CancelRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Invalid transition",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/charges": {
+            "get": {
+                "description": "Lists past and future charge dates with the price in effect in the month of each charge.\nCharges start on the first day of start_date and repeat every billing period",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Charges"
+                ],
+                "summary": "Get charges of a subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "first month in MM-YYYY, start_date by default",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last month in MM-YYYY, end_date or 12 months from now by default, at most 120 months after from",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "charges",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/structures.Charge"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/history": {
+            "get": {
+                "description": "Returns every change of the subscription, oldest first, with the snapshots before and after it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Get subscription history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structures.AuditEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/members": {
+            "put": {
+                "description": "Replaces the users sharing the cost with the owner. A member pays a fixed amount of every charge\nor weight parts (1 by default) of what the amounts leave, the owner takes 1 part unless listed.\nAn empty list makes the subscription personal again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Share a subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "members, amounts in the currency of the subscription",
+                        "name": "members",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structures.MemberJSON"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/pause": {
+            "post": {
+                "description": "Stops billing an active subscription from the month in from on, the current month by default.\nPaused months are not counted in sums. Other statuses give 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lifecycle"
+                ],
+                "summary": "Pause subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "first paused month",
+                        "name": "pause",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/structures.PauseRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Invalid transition",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/prices": {
+            "post": {
+                "description": "Changes the subscription price from effective_from on, earlier months keep the old price.\nA change already scheduled for the same month is replaced",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Schedule a price change",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new price in the currency of the subscription and the month it takes effect",
+                        "name": "change",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.PriceChangeJSON"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/prices/{month}": {
+            "delete": {
+                "description": "Removes the price change scheduled for month, the price before it is charged on",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Delete a scheduled price change",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "effective_from of the change in MM-YYYY",
+                        "name": "month",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No subscription or no change for month",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/restore": {
+            "post": {
+                "description": "Restores a soft-deleted subscription by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Restore subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the deleted subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Subscription is not deleted",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/resume": {
+            "post": {
+                "description": "Bills a paused subscription again from the month in from on, the current month by default.\nOther statuses give 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lifecycle"
+                ],
+                "summary": "Resume subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "first billed month",
+                        "name": "resume",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/structures.PauseRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the subscription must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Invalid transition",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/summ/": {
+            "get": {
+                "description": "Returns the sum of subscription prices for every month each subscription was active\nwithin the period, filtered by user and service. Subscriptions without end_date run to the end of the period.\nWith user_id only the share of the user in the subscriptions it owns or is a member of is counted.\nPrices are converted to target_currency (RUB by default) with the rate of each month, rates lists the rates used.\nAmounts are decimal strings; each prorated or converted subscription-month is rounded half away from zero\nto minor units of target_currency before summing, rounding describes the rule",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sum"
+                ],
+                "summary": "Get summ of subscriptions prices",
+                "parameters": [
+                    {
+                        "description": "Filters",
+                        "name": "counting",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.Counting"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "amortized",
+                            "charges"
+                        ],
+                        "type": "string",
+                        "default": "amortized",
+                        "description": "amortized spreads each charge over its billing period, charges counts it in the month it is charged",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.SpendTotal"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/summ/grouped": {
+            "get": {
+                "description": "Returns totals per service_name, user_id or category for the period, sorted by amount.\ngroup_by is required, top limits the number of groups (0 means all). Amounts are converted to target_currency like /summ/",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sum"
+                ],
+                "summary": "Get subscriptions prices grouped by service, user or category",
+                "parameters": [
+                    {
+                        "description": "Filters",
+                        "name": "counting",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.Counting"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "amortized",
+                            "charges"
+                        ],
+                        "type": "string",
+                        "default": "amortized",
+                        "description": "amortized spreads each charge over its billing period, charges counts it in the month it is charged",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.GroupSpendReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/summ/monthly": {
+            "get": {
+                "description": "Returns one bucket per month of the period with the total price and the number\nof active subscriptions, filtered and converted to target_currency like /summ/",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sum"
+                ],
+                "summary": "Get monthly breakdown of subscriptions prices",
+                "parameters": [
+                    {
+                        "description": "Filters",
+                        "name": "counting",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.Counting"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "amortized",
+                            "charges"
+                        ],
+                        "type": "string",
+                        "default": "amortized",
+                        "description": "amortized spreads each charge over its billing period, charges counts it in the month it is charged",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.MonthlySpendReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/summ/settlement": {
+            "get": {
+                "description": "Returns what the members of shared subscriptions owe their owners for the period, netted between\nevery two users and ordered by amount. Shares are counted and converted to target_currency like /summ/,\nuser_id lists only the debts of and to the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sum"
+                ],
+                "summary": "Get who owes whom for shared subscriptions",
+                "parameters": [
+                    {
+                        "description": "Filters",
+                        "name": "counting",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.Counting"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "amortized",
+                            "charges"
+                        ],
+                        "type": "string",
+                        "default": "amortized",
+                        "description": "amortized spreads each charge over its billing period, charges counts it in the month it is charged",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Settlement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/": {
+            "get": {
+                "description": "Paginated list of users, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get all users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, 500 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of users to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.UserPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a user subscriptions can refer to. The id is generated unless given",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Create user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message + id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user format",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "id or email is taken",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get one user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/structures.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Overwrites the name and email of the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structures.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated User",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/structures.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "email is taken",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a user without subscriptions. Soft-deleted subscriptions count until they are purged",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "User has subscriptions",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/subscriptions": {
+            "get": {
+                "description": "Paginated list of the subscriptions of the user, filtered and sorted like /subscription/",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get subscriptions of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, 500 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of subscriptions to skip, cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-id",
+                        "description": "id, price, start_date or service_name, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "service name, a name in the catalog matches all its aliases",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "catalog service ID",
+                        "name": "service_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "subscription category, case-insensitive",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tag the subscription has, case-insensitive",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "month in MM-YYYY the subscription is active at",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "trial, active, paused, cancelled or expired",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include soft-deleted subscriptions",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.SubscriptionPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/summary": {
+            "get": {
+                "description": "Number of subscriptions active or in trial this month, their amortized spend this month\nconverted to target_currency like /summ/monthly, and the next charge within a year",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get summary of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "currency of monthly_spend, RUB by default",
+                        "name": "target_currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structures.UserSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structures.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "structures.AppliedRate": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "inverted": {
+                    "type": "boolean"
+                },
+                "month": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 92.5
+                },
+                "rate_month": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after": {
+                    "$ref": "#/definitions/structures.Subscription"
+                },
+                "before": {
+                    "$ref": "#/definitions/structures.Subscription"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structures.AuditPage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.AuditEntry"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structures.Budget": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "1500.00"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "entertainment"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "service_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "structures.BudgetMonth": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "type": "string"
+                },
+                "over": {
+                    "type": "boolean"
+                },
+                "remaining": {
+                    "type": "string",
+                    "example": "300.00"
+                },
+                "spent": {
+                    "type": "string",
+                    "example": "1200.00"
+                }
+            }
+        },
+        "structures.BudgetReport": {
+            "type": "object",
+            "properties": {
+                "budgets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.BudgetUsage"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "rounding": {
+                    "$ref": "#/definitions/structures.Rounding"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.BudgetUsage": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "$ref": "#/definitions/structures.Budget"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.BudgetMonth"
+                    }
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.AppliedRate"
+                    }
+                }
+            }
+        },
+        "structures.CancelRequest": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string",
+                    "enum": [
+                        "period_end",
+                        "immediately"
+                    ]
+                }
+            }
+        },
+        "structures.Charge": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "299.99"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2025-07-01"
+                }
+            }
+        },
+        "structures.Counting": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "string"
+                },
+                "include_deleted": {
+                    "description": "IncludeDeleted counts soft-deleted subscriptions too.",
+                    "type": "boolean"
+                },
+                "service_id": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "tag": {
+                    "type": "string"
+                },
+                "target_currency": {
+                    "description": "TargetCurrency is the currency sums are converted to, DefaultCurrency if empty.",
+                    "type": "string"
+                },
+                "top": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.Debt": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "266.67"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.ExchangeRate": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "month": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 92.5
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.Forecast": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.ForecastMonth"
+                    }
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.AppliedRate"
+                    }
+                },
+                "rounding": {
+                    "$ref": "#/definitions/structures.Rounding"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "string",
+                    "example": "17994.00"
+                }
+            }
+        },
+        "structures.ForecastContribution": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "399.00"
+                },
                 "service_name": {
                     "type": "string"
                 },
-                "start_date": {
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.ForecastMonth": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.ForecastContribution"
+                    }
+                },
+                "total": {
+                    "type": "string",
+                    "example": "1499.50"
+                }
+            }
+        },
+        "structures.GroupSpend": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "string",
+                    "example": "1499.50"
+                }
+            }
+        },
+        "structures.GroupSpendReport": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.GroupSpend"
+                    }
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.AppliedRate"
+                    }
+                },
+                "rounding": {
+                    "$ref": "#/definitions/structures.Rounding"
+                }
+            }
+        },
+        "structures.Member": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "100.00"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structures.MemberJSON": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "100.00"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structures.MonthlySpend": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "string",
+                    "example": "1499.50"
+                }
+            }
+        },
+        "structures.MonthlySpendReport": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.MonthlySpend"
+                    }
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.AppliedRate"
+                    }
+                },
+                "rounding": {
+                    "$ref": "#/definitions/structures.Rounding"
+                }
+            }
+        },
+        "structures.Pause": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.PauseRequest": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.PriceChange": {
+            "type": "object",
+            "properties": {
+                "effective_from": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "349.00"
+                }
+            }
+        },
+        "structures.PriceChangeJSON": {
+            "type": "object",
+            "properties": {
+                "effective_from": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "349.00"
+                }
+            }
+        },
+        "structures.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.Renewal": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "299.99"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2025-07-01"
+                },
+                "service_name": {
                     "type": "string"
                 },
+                "subscription_id": {
+                    "type": "integer"
+                },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
-        "structures.ErrorResponse": {
+        "structures.RenewalPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "renewals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.Renewal"
+                    }
+                }
+            }
+        },
+        "structures.Rounding": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.Service": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "description": "Aliases are other names of the service, matched like Name by ServiceKey.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Яндекс Плюс"
+                    ]
+                },
+                "category": {
+                    "type": "string",
+                    "example": "video"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "default_price": {
+                    "type": "string",
+                    "example": "399.00"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "website": {
+                    "type": "string",
+                    "example": "https://plus.yandex.ru"
+                }
+            }
+        },
+        "structures.ServiceMatch": {
+            "type": "object",
+            "properties": {
+                "score": {
+                    "type": "number"
+                },
+                "service": {
+                    "$ref": "#/definitions/structures.Service"
+                }
+            }
+        },
+        "structures.ServiceMatchReport": {
+            "type": "object",
+            "properties": {
+                "matched": {
+                    "type": "integer"
+                },
+                "unmatched": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.UnmatchedName"
+                    }
+                }
+            }
+        },
+        "structures.Settlement": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "debts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.Debt"
+                    }
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.AppliedRate"
+                    }
+                },
+                "rounding": {
+                    "$ref": "#/definitions/structures.Rounding"
+                },
+                "start_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.SpendTotal": {
             "type": "object",
             "properties": {
-                "error": {
+                "currency": {
+                    "type": "string"
+                },
+                "mode": {
                     "type": "string"
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.AppliedRate"
+                    }
+                },
+                "rounding": {
+                    "$ref": "#/definitions/structures.Rounding"
+                },
+                "total": {
+                    "type": "string",
+                    "example": "1499.50"
                 }
             }
         },
         "structures.Subscription": {
             "type": "object",
             "properties": {
+                "billing_months": {
+                    "type": "integer"
+                },
+                "billing_period": {
+                    "description": "BillingPeriod is how often Price is charged, monthly if empty.\nBillingMonths is the length of a custom period.",
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly",
+                        "custom"
+                    ]
+                },
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "category": {
+                    "description": "Category is the category of the catalog service unless set. Tags are\nlower case and sorted.",
+                    "type": "string",
+                    "example": "entertainment"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
                 "end_date": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "members": {
+                    "description": "Members share the cost with the owner UserID, see Shares. They change\nthrough the members endpoint only.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.Member"
+                    }
+                },
+                "pauses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.Pause"
+                    }
+                },
                 "price": {
+                    "type": "string",
+                    "example": "299.99"
+                },
+                "prices": {
+                    "description": "Prices are the scheduled price changes ordered by month, Price is\ncharged until the first of them takes effect.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.PriceChange"
+                    }
+                },
+                "service_id": {
+                    "description": "ServiceID refers to the service catalog, a name found there sets it.",
                     "type": "integer"
                 },
                 "service_name": {
@@ -324,6 +3747,139 @@ const docTemplate = `{
                 "start_date": {
                     "type": "string"
                 },
+                "status": {
+                    "description": "Status, Pauses and CancelledAt change through the lifecycle\nendpoints only, see StatusIn.",
+                    "type": "string",
+                    "enum": [
+                        "trial",
+                        "active",
+                        "paused",
+                        "cancelled",
+                        "expired"
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "work",
+                        "cloud"
+                    ]
+                },
+                "trial_end_date": {
+                    "description": "TrialEndDate is the last month of the trial, TrialPrice is charged\nevery trial month instead of Price.",
+                    "type": "string"
+                },
+                "trial_price": {
+                    "type": "string",
+                    "example": "0.00"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structures.SubscriptionPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.Subscription"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structures.UnmatchedName": {
+            "type": "object",
+            "properties": {
+                "service_name": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "integer"
+                },
+                "suggestion": {
+                    "$ref": "#/definitions/structures.ServiceMatch"
+                }
+            }
+        },
+        "structures.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "structures.UserPage": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.User"
+                    }
+                }
+            }
+        },
+        "structures.UserSummary": {
+            "type": "object",
+            "properties": {
+                "active_subscriptions": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "month": {
+                    "type": "string"
+                },
+                "monthly_spend": {
+                    "type": "string",
+                    "example": "1499.50"
+                },
+                "next_renewal": {
+                    "description": "NextRenewal is the first charge from today on, within a year.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/structures.Renewal"
+                        }
+                    ]
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structures.AppliedRate"
+                    }
+                },
+                "rounding": {
+                    "$ref": "#/definitions/structures.Rounding"
+                },
                 "user_id": {
                     "type": "string"
                 }
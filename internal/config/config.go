@@ -3,18 +3,29 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env      string `yaml:"env" env-default:"dev" env-required:"true"`
-	Server   `yaml:"server"`
-	Database `yaml:"database"`
+	Env       string `yaml:"env" env-default:"dev" env-required:"true"`
+	Storage   string `yaml:"storage" env:"STORAGE" env-default:"postgres"`
+	Server    `yaml:"server"`
+	Database  `yaml:"database"`
+	Purge     `yaml:"purge"`
+	Lifecycle `yaml:"lifecycle"`
+	Alerts    `yaml:"alerts"`
 }
 
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
 type Server struct {
-	Port string `yaml:"port" env-default:":8080"`
+	Port            string        `yaml:"port" env-default:":8080"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"2s"`
 }
 
 type Database struct {
@@ -24,6 +35,28 @@ type Database struct {
 	DBpassword string `yaml:"db_password"`
 	SSLMode    string `yaml:"sslmode"`
 	DBusername string `yaml:"db_username"`
+
+	// QueryTimeout bounds every query made by the repository.
+	QueryTimeout time.Duration `yaml:"query_timeout" env-default:"5s"`
+}
+
+// Purge configures the removal of soft-deleted subscriptions.
+type Purge struct {
+	// Retention is how long a deleted subscription can still be restored.
+	Retention time.Duration `yaml:"retention" env-default:"720h"`
+	Interval  time.Duration `yaml:"interval" env-default:"1h"`
+}
+
+// Lifecycle configures the job ending trials and expiring subscriptions.
+type Lifecycle struct {
+	Interval time.Duration `yaml:"interval" env-default:"1h"`
+}
+
+// Alerts configures where budget alerts go: posted as JSON to WebhookURL
+// if it is set, otherwise written to the log.
+type Alerts struct {
+	WebhookURL     string        `yaml:"webhook_url" env:"ALERTS_WEBHOOK_URL"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" env-default:"5s"`
 }
 
 func MustLoad() *Config {
@@ -41,5 +74,23 @@ func MustLoad() *Config {
 		log.Fatalf("Cannot read .yml: %s", err.Error())
 	}
 
+	// The jobs tick every interval, time.NewTicker panics unless it is
+	// positive, and a timeout of zero fails every call it bounds.
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"database.query_timeout", cfg.Database.QueryTimeout},
+		{"purge.retention", cfg.Purge.Retention},
+		{"purge.interval", cfg.Purge.Interval},
+		{"lifecycle.interval", cfg.Lifecycle.Interval},
+		{"alerts.webhook_timeout", cfg.Alerts.WebhookTimeout},
+	}
+	for _, duration := range durations {
+		if duration.value <= 0 {
+			log.Fatalf("%s must be positive, got %s", duration.name, duration.value)
+		}
+	}
+
 	return &cfg
 }
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateBudget godoc
+// @Summary Create budget
+// @Description Caps the monthly spend of the user on every subscription, on a category or on a catalog service.
+// @Description Creating or updating a subscription that pushes a month over a budget raises an alert
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param budget body structures.Budget true "Budget data"
+// @Success 200 {object} map[string]interface{} "message + id"
+// @Failure 400 {object} structures.Problem "Invalid budget format"
+// @Failure 409 {object} structures.Problem "User already has a budget with this scope"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /budgets/ [post]
+func (h *SubscriptionHandler) CreateBudget(c *fiber.Ctx) error {
+	var budget structures.Budget
+	if err := c.BodyParser(&budget); err != nil {
+		return badRequest("Invalid budget format", err)
+	}
+
+	id, err := h.subscriptionService.CreateBudget(c.UserContext(), &budget)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Budget created successfully",
+		"id":      id,
+	})
+}
+
+// GetBudgets godoc
+// @Summary Get budgets
+// @Tags Budgets
+// @Produce json
+// @Param user_id query string false "user UUID"
+// @Success 200 {object} map[string][]structures.Budget "budgets"
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /budgets/ [get]
+func (h *SubscriptionHandler) GetBudgets(c *fiber.Ctx) error {
+	var filter structures.BudgetFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	budgets, err := h.subscriptionService.GetBudgets(c.UserContext(), &filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"budgets": budgets,
+	})
+}
+
+// GetOneBudget godoc
+// @Summary Get one budget by ID
+// @Tags Budgets
+// @Produce json
+// @Param id path int true "budget ID"
+// @Success 200 {object} map[string]structures.Budget "Budget"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 500 {object} structures.Problem
+// @Router /budgets/{id} [get]
+func (h *SubscriptionHandler) GetOneBudget(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	budget, err := h.subscriptionService.GetBudgetById(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"Budget": budget,
+	})
+}
+
+// UpdateBudget godoc
+// @Summary Update budget
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param id path int true "budget ID"
+// @Param budget body structures.Budget true "Budget data"
+// @Success 200 {object} map[string]structures.Budget "updated Budget"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "User already has a budget with this scope"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /budgets/{id} [put]
+func (h *SubscriptionHandler) UpdateBudget(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	var budget structures.Budget
+	if err := c.BodyParser(&budget); err != nil {
+		return badRequest("Invalid budget format", err)
+	}
+
+	if err := h.subscriptionService.UpdateBudget(c.UserContext(), &budget, id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"Budget": budget,
+	})
+}
+
+// DeleteBudget godoc
+// @Summary Delete budget
+// @Tags Budgets
+// @Produce json
+// @Param id path int true "budget ID"
+// @Success 200 {object} map[string]string "message"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 500 {object} structures.Problem
+// @Router /budgets/{id} [delete]
+func (h *SubscriptionHandler) DeleteBudget(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.subscriptionService.DeleteBudget(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Budget has been deleted",
+	})
+}
+
+// GetBudgetReport godoc
+// @Summary Compare spend with budgets
+// @Description For every budget and every month from from to to, the share of the user in the covered
+// @Description subscriptions counted like /summ/ in the currency of the budget, what remains and whether it is over
+// @Tags Budgets
+// @Produce json
+// @Param user_id query string false "user UUID, budgets of every user by default"
+// @Param from query string false "first month in MM-YYYY, the current month by default"
+// @Param to query string false "last month in MM-YYYY, from by default, at most 120 months after it"
+// @Param mode query string false "amortized spreads each charge over its billing period, charges counts it in the month it is charged" Enums(amortized, charges) default(amortized)
+// @Success 200 {object} structures.BudgetReport
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /budgets/report [get]
+func (h *SubscriptionHandler) GetBudgetReport(c *fiber.Ctx) error {
+	var filter structures.BudgetReportFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	report, err := h.subscriptionService.GetBudgetReport(c.UserContext(), &filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateService godoc
+// @Summary Add a service to the catalog
+// @Description Subscriptions created with the name or an alias of the service are linked to it and take its name
+// @Tags Services
+// @Accept json
+// @Produce json
+// @Param service body structures.Service true "Service data"
+// @Success 200 {object} map[string]interface{} "message + id"
+// @Failure 400 {object} structures.Problem "Invalid service format"
+// @Failure 409 {object} structures.Problem "Name or alias is taken"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /services/ [post]
+func (h *SubscriptionHandler) CreateService(c *fiber.Ctx) error {
+	var service structures.Service
+	if err := c.BodyParser(&service); err != nil {
+		return badRequest("Invalid service format", err)
+	}
+
+	id, err := h.subscriptionService.CreateService(c.UserContext(), &service)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Service created successfully",
+		"id":      id,
+	})
+}
+
+// GetServices godoc
+// @Summary Get the service catalog
+// @Description Lists all services ordered by name
+// @Tags Services
+// @Produce json
+// @Success 200 {object} map[string][]structures.Service "services"
+// @Failure 500 {object} structures.Problem
+// @Router /services/ [get]
+func (h *SubscriptionHandler) GetServices(c *fiber.Ctx) error {
+	services, err := h.subscriptionService.GetServices(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"services": services,
+	})
+}
+
+// GetOneService godoc
+// @Summary Get one service by ID
+// @Tags Services
+// @Produce json
+// @Param id path int true "service ID"
+// @Success 200 {object} map[string]structures.Service "Service"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 500 {object} structures.Problem
+// @Router /services/{id} [get]
+func (h *SubscriptionHandler) GetOneService(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	service, err := h.subscriptionService.GetServiceById(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"Service": service,
+	})
+}
+
+// UpdateService godoc
+// @Summary Update service
+// @Description Overwrites the service and its aliases. Subscriptions of the service take its new name
+// @Tags Services
+// @Accept json
+// @Produce json
+// @Param id path int true "service ID"
+// @Param service body structures.Service true "Service data"
+// @Success 200 {object} map[string]structures.Service "updated Service"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "Name or alias is taken"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /services/{id} [put]
+func (h *SubscriptionHandler) UpdateService(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	var service structures.Service
+	if err := c.BodyParser(&service); err != nil {
+		return badRequest("Invalid service format", err)
+	}
+
+	if err := h.subscriptionService.UpdateService(c.UserContext(), &service, id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"Service": service,
+	})
+}
+
+// DeleteService godoc
+// @Summary Delete service
+// @Description Deletes a service no subscription refers to. Soft-deleted subscriptions count until they are purged
+// @Tags Services
+// @Produce json
+// @Param id path int true "service ID"
+// @Success 200 {object} map[string]string "message"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "Service has subscriptions"
+// @Failure 500 {object} structures.Problem
+// @Router /services/{id} [delete]
+func (h *SubscriptionHandler) DeleteService(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.subscriptionService.DeleteService(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Service has been deleted",
+	})
+}
+
+// LookupServices godoc
+// @Summary Find services by name
+// @Description Fuzzy search over names and aliases: case, spacing, ё and Cyrillic or Latin spelling
+// @Description do not matter, small typos are tolerated. Matches are ordered by score from 0 to 1
+// @Tags Services
+// @Produce json
+// @Param q query string true "service name as typed by the user"
+// @Param limit query int false "number of matches, 5 by default, 50 at most"
+// @Success 200 {object} map[string][]structures.ServiceMatch "matches"
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /services/lookup [get]
+func (h *SubscriptionHandler) LookupServices(c *fiber.Ctx) error {
+	var lookup structures.ServiceLookup
+	if err := c.QueryParser(&lookup); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	matches, err := h.subscriptionService.LookupServices(c.UserContext(), &lookup)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"matches": matches,
+	})
+}
+
+// GetUnmatchedNames godoc
+// @Summary Get service names missing from the catalog
+// @Description Lists the service_name of subscriptions not linked to the catalog with the number of
+// @Description subscriptions, most used first, and the closest catalog entry as suggestion
+// @Tags Services
+// @Produce json
+// @Success 200 {object} map[string][]structures.UnmatchedName "unmatched"
+// @Failure 500 {object} structures.Problem
+// @Router /services/unmatched [get]
+func (h *SubscriptionHandler) GetUnmatchedNames(c *fiber.Ctx) error {
+	names, err := h.subscriptionService.GetUnmatchedNames(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"unmatched": names,
+	})
+}
+
+// MatchServices godoc
+// @Summary Link subscriptions to the catalog
+// @Description Links the subscriptions not linked yet whose service_name is a name or alias in the catalog,
+// @Description for instance after adding aliases, and reports the names still unmatched
+// @Tags Services
+// @Produce json
+// @Success 200 {object} structures.ServiceMatchReport
+// @Failure 500 {object} structures.Problem
+// @Router /services/match [post]
+func (h *SubscriptionHandler) MatchServices(c *fiber.Ctx) error {
+	report, err := h.subscriptionService.MatchServices(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
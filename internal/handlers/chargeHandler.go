@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCharges godoc
+// @Summary Get charges of a subscription
+// @Description Lists past and future charge dates with the price in effect in the month of each charge.
+// @Description Charges start on the first day of start_date and repeat every billing period
+// @Tags Charges
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Param from query string false "first month in MM-YYYY, start_date by default"
+// @Param to query string false "last month in MM-YYYY, end_date or 12 months from now by default, at most 120 months after from"
+// @Success 200 {object} map[string][]structures.Charge "charges"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id}/charges [get]
+func (h *SubscriptionHandler) GetCharges(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	var filter structures.ChargeFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	charges, err := h.subscriptionService.GetCharges(c.UserContext(), id, &filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"charges": charges,
+	})
+}
+
+// GetRenewals godoc
+// @Summary Get upcoming renewals
+// @Description Lists the charges of all subscriptions from today until within from now, ordered by date and subscription, a page at a time
+// @Tags Charges
+// @Produce json
+// @Param within query string false "days or weeks like 30d or 2w, at most 366d" default(30d)
+// @Param user_id query string false "user UUID, subscriptions shared with the user included"
+// @Param limit query int false "page size, 50 by default, 500 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} structures.RenewalPage
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /renewals [get]
+func (h *SubscriptionHandler) GetRenewals(c *fiber.Ctx) error {
+	var filter structures.RenewalFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	page, err := h.subscriptionService.GetRenewals(c.UserContext(), &filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(page)
+}
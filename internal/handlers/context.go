@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// HeaderActor names who makes the request, it is recorded in the audit trail.
+// The API has no authentication, so the header is whatever the client says:
+// the audit trail tells who claimed to make a change, not who made it.
+const HeaderActor = "X-Actor"
+
+const (
+	anonymousActor = "anonymous"
+
+	// maxActorLength bounds the actor stored with every audit entry, in runes.
+	maxActorLength = 64
+)
+
+// sanitizeActor makes the X-Actor header safe to store and to log: control
+// characters are dropped, spaces trimmed and the rest cut to maxActorLength.
+func sanitizeActor(header string) string {
+	actor := strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return -1
+		}
+		return r
+	}, header))
+
+	if runes := []rune(actor); len(runes) > maxActorLength {
+		actor = strings.TrimSpace(string(runes[:maxActorLength]))
+	}
+
+	if actor == "" {
+		return anonymousActor
+	}
+	return actor
+}
+
+// RequestContext gives every request a context derived from base, so that
+// cancelling base aborts the queries of requests still running. Queries of
+// a client that went away are bounded by the database timeouts. The context
+// carries the actor and the request ID, which is generated unless the client
+// sends X-Request-ID and is echoed in the response.
+func RequestContext(base context.Context) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancel(base)
+		defer cancel()
+
+		// Header values point into a buffer fasthttp reuses, and these
+		// outlive the request in the audit trail.
+		requestID := utils.CopyString(c.Get(fiber.HeaderXRequestID))
+		if requestID == "" {
+			requestID = utils.UUIDv4()
+		}
+		c.Set(fiber.HeaderXRequestID, requestID)
+
+		actor := sanitizeActor(utils.CopyString(c.Get(HeaderActor)))
+
+		c.SetUserContext(structures.WithRequestInfo(ctx, structures.RequestInfo{
+			Actor:     actor,
+			RequestID: requestID,
+		}))
+		return c.Next()
+	}
+}
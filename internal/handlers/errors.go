@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+const problemContentType = "application/problem+json"
+
+// ErrorHandler renders every error returned by a handler as RFC 7807 problem details.
+// Domain errors from structures are mapped to their HTTP status, *fiber.Error keeps
+// its own code and anything else becomes an opaque 500.
+//
+// It is the only place a failed request is logged: handlers return their errors
+// without logging them. Client errors are logged at Warn, server errors at Error.
+func ErrorHandler(log *slog.Logger) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		problem := problemFromError(err)
+		problem.Instance = c.OriginalURL()
+
+		attrs := []any{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", problem.Status),
+			sl.Err(err),
+		}
+		if problem.Status >= fiber.StatusInternalServerError {
+			log.Error("Request failed", attrs...)
+		} else {
+			log.Warn("Request rejected", attrs...)
+		}
+
+		return c.Status(problem.Status).JSON(problem, problemContentType)
+	}
+}
+
+// badRequest is a 400 with message as detail. The cause is not shown to the
+// client, it only ends up in the log of ErrorHandler.
+func badRequest(message string, cause error) error {
+	return fmt.Errorf("%w: %w", fiber.NewError(fiber.StatusBadRequest, message), cause)
+}
+
+// problemFromError checks field errors first: a body that parses but holds a
+// malformed amount is a 422 even though badRequest wrapped it.
+func problemFromError(err error) structures.Problem {
+	var validationErr *structures.ValidationError
+	if errors.As(err, &validationErr) {
+		problem := newProblem(fiber.StatusUnprocessableEntity, "Request contains invalid fields")
+		problem.Errors = validationErr.Fields
+		return problem
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return newProblem(fiberErr.Code, fiberErr.Message)
+	}
+
+	switch {
+	case errors.Is(err, structures.ErrValidation):
+		return newProblem(fiber.StatusUnprocessableEntity, "Request contains invalid values")
+	case errors.Is(err, structures.ErrNotFound):
+		return newProblem(fiber.StatusNotFound, "Resource not found")
+	case errors.Is(err, structures.ErrPreconditionFailed):
+		return newProblem(fiber.StatusPreconditionFailed, "Resource was modified, fetch it again")
+	case errors.Is(err, structures.ErrConflict):
+		return newProblem(fiber.StatusConflict, "Resource conflicts with its current state")
+	case errors.Is(err, structures.ErrUnavailable):
+		return newProblem(fiber.StatusServiceUnavailable, "Storage is temporarily unavailable")
+	}
+
+	return newProblem(fiber.StatusInternalServerError, "")
+}
+
+func newProblem(status int, detail string) structures.Problem {
+	return structures.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
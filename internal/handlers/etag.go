@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func formatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// etagList splits an If-Match / If-None-Match header value into entity tags.
+func etagList(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// ifMatchVersions returns the versions accepted by the If-Match header.
+// An absent header or "*" accepts any version and gives an empty list.
+// Weak tags never match, as If-Match requires strong comparison.
+func ifMatchVersions(c *fiber.Ctx) ([]int, error) {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return nil, nil
+	}
+
+	versions := []int{}
+	for _, tag := range etagList(header) {
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+
+		version, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err != nil || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid If-Match header")
+		}
+		versions = append(versions, version)
+	}
+
+	if len(versions) == 0 {
+		// Only weak tags: nothing can match, version 0 is never stored.
+		versions = append(versions, 0)
+	}
+
+	return versions, nil
+}
+
+// noneMatch reports whether the If-None-Match header lets a GET of a resource
+// with etag through, using weak comparison.
+func noneMatch(c *fiber.Ctx, etag string) bool {
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	if header == "" {
+		return true
+	}
+
+	for _, tag := range etagList(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetRates godoc
+// @Summary Get exchange rates
+// @Description Returns stored exchange rates ordered by month and currency pair
+// @Tags Exchange rates
+// @Produce json
+// @Param currency query string false "ISO 4217 code on either side of the pair"
+// @Param from query string false "first month in MM-YYYY"
+// @Param to query string false "last month in MM-YYYY"
+// @Success 200 {object} map[string][]structures.ExchangeRate "rates"
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /rates [get]
+func (h *SubscriptionHandler) GetRates(c *fiber.Ctx) error {
+	var filter structures.ExchangeRateFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	rates, err := h.subscriptionService.GetRates(c.UserContext(), &filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"rates": rates,
+	})
+}
+
+// SetRates godoc
+// @Summary Store exchange rates
+// @Description Stores the rate of one unit of from in to, valid from month until the next rate of the pair.
+// @Description A rate already stored for the same month and pair is replaced
+// @Tags Exchange rates
+// @Accept json
+// @Produce json
+// @Param rates body []structures.ExchangeRate true "Exchange rates"
+// @Success 200 {object} map[string]int "stored"
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /rates [put]
+func (h *SubscriptionHandler) SetRates(c *fiber.Ctx) error {
+	var rates []structures.ExchangeRate
+	if err := c.BodyParser(&rates); err != nil {
+		return badRequest("Invalid exchange rates format", err)
+	}
+
+	if err := h.subscriptionService.SetRates(c.UserContext(), rates); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"stored": len(rates),
+	})
+}
+
+// ImportRatesCSV godoc
+// @Summary Upload exchange rates as CSV
+// @Description Stores the rates of a CSV document with a month,from,to,rate header like PUT /rates.
+// @Description The document is either the request body or the file field of a multipart form
+// @Tags Exchange rates
+// @Accept text/csv
+// @Accept mpfd
+// @Produce json
+// @Param file formData file false "CSV document"
+// @Success 200 {object} map[string]int "stored"
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /rates/csv [post]
+func (h *SubscriptionHandler) ImportRatesCSV(c *fiber.Ctx) error {
+	var document io.Reader = bytes.NewReader(c.Body())
+
+	if file, err := c.FormFile("file"); err == nil {
+		opened, err := file.Open()
+		if err != nil {
+			return badRequest("Invalid file", err)
+		}
+		defer opened.Close()
+
+		document = opened
+	}
+
+	stored, err := h.subscriptionService.ImportRatesCSV(c.UserContext(), document)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"stored": stored,
+	})
+}
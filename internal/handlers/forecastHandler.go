@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetForecast godoc
+// @Summary Forecast spend of the coming months
+// @Description Projects the spend of every month from the current one on with the total of each month and what
+// @Description every subscription adds to it. Months are counted like /summ/monthly: billing periods, scheduled
+// @Description prices, pauses, end dates and shares apply the same way
+// @Tags Sum
+// @Produce json
+// @Param months query int false "number of months, the current one included, at most 120" default(12)
+// @Param user_id query string false "user UUID, only the share of the user in the subscriptions they pay for or share"
+// @Param target_currency query string false "currency of the amounts, RUB by default"
+// @Param mode query string false "amortized spreads each charge over its billing period, charges counts it in the month it is charged" Enums(amortized, charges) default(amortized)
+// @Success 200 {object} structures.Forecast
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /forecast [get]
+func (h *SubscriptionHandler) GetForecast(c *fiber.Ctx) error {
+	var filter structures.ForecastFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	forecast, err := h.subscriptionService.Forecast(c.UserContext(), &filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(forecast)
+}
@@ -0,0 +1,399 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/handlers"
+	"github.com/QwaQ-dev/servicesSubscription/internal/repository"
+	"github.com/QwaQ-dev/servicesSubscription/internal/routes"
+	"github.com/QwaQ-dev/servicesSubscription/internal/services"
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/gofiber/fiber/v2"
+)
+
+const testUserID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+// newTestApp wires the routes like cmd/main.go over the memory storage.
+func newTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	app := fiber.New(fiber.Config{ErrorHandler: handlers.ErrorHandler(log)})
+	app.Use(handlers.RequestContext(context.Background()))
+
+	service := services.NewSubsriptionService(repository.NewMemorySubscriptionRepo(log), nil, log)
+	routes.InitRoutes(app, log, handlers.NewSubsriptionHandler(service))
+
+	return app
+}
+
+type request struct {
+	method      string
+	url         string
+	body        string
+	contentType string
+	headers     map[string]string
+}
+
+type response struct {
+	status  int
+	headers map[string]string
+	body    []byte
+}
+
+func send(t *testing.T, app *fiber.App, r request) response {
+	t.Helper()
+
+	req := httptest.NewRequest(r.method, r.url, strings.NewReader(r.body))
+	contentType := r.contentType
+	if contentType == "" && r.body != "" {
+		contentType = fiber.MIMEApplicationJSON
+	}
+	if contentType != "" {
+		req.Header.Set(fiber.HeaderContentType, contentType)
+	}
+	for name, value := range r.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", r.method, r.url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: %v", r.method, r.url, err)
+	}
+
+	headers := make(map[string]string)
+	for _, name := range []string{fiber.HeaderETag, fiber.HeaderContentType, fiber.HeaderXRequestID} {
+		headers[name] = resp.Header.Get(name)
+	}
+
+	return response{status: resp.StatusCode, headers: headers, body: body}
+}
+
+// mustSend sends r and fails unless it gets status.
+func mustSend(t *testing.T, app *fiber.App, r request, status int) response {
+	t.Helper()
+
+	resp := send(t, app, r)
+	if resp.status != status {
+		t.Fatalf("%s %s = %d %s, want %d", r.method, r.url, resp.status, resp.body, status)
+	}
+
+	return resp
+}
+
+// newSubscription creates a user and a subscription of it with ID 1.
+func newSubscription(t *testing.T, app *fiber.App) {
+	t.Helper()
+
+	mustSend(t, app, request{method: "POST", url: "/api/v1/users/", body: `{"id": "` + testUserID + `", "name": "Test"}`}, fiber.StatusOK)
+	mustSend(t, app, request{
+		method: "POST",
+		url:    "/api/v1/subscription/",
+		body:   `{"service_name": "Netflix", "price": "599.00", "user_id": "` + testUserID + `", "start_date": "01-2025"}`,
+	}, fiber.StatusOK)
+}
+
+func TestConditionalRequests(t *testing.T) {
+	update := `{"service_name": "Netflix", "price": "649.00", "user_id": "` + testUserID + `", "start_date": "01-2025"}`
+	patch := `{"price": "699.00"}`
+
+	tests := []struct {
+		name     string
+		request  request
+		status   int
+		wantETag string
+	}{
+		{
+			name:     "get returns the version",
+			request:  request{method: "GET", url: "/api/v1/subscription/1"},
+			status:   fiber.StatusOK,
+			wantETag: `"1"`,
+		},
+		{
+			name:    "get of the current version is not modified",
+			request: request{method: "GET", url: "/api/v1/subscription/1", headers: map[string]string{"If-None-Match": `W/"1"`}},
+			status:  fiber.StatusNotModified,
+		},
+		{
+			name:     "get of an older version",
+			request:  request{method: "GET", url: "/api/v1/subscription/1", headers: map[string]string{"If-None-Match": `"0"`}},
+			status:   fiber.StatusOK,
+			wantETag: `"1"`,
+		},
+		{
+			name:    "put of a stale version",
+			request: request{method: "PUT", url: "/api/v1/subscription/1", body: update, headers: map[string]string{"If-Match": `"2"`}},
+			status:  fiber.StatusPreconditionFailed,
+		},
+		{
+			name:    "put with a weak tag",
+			request: request{method: "PUT", url: "/api/v1/subscription/1", body: update, headers: map[string]string{"If-Match": `W/"1"`}},
+			status:  fiber.StatusPreconditionFailed,
+		},
+		{
+			name:    "put with a malformed tag",
+			request: request{method: "PUT", url: "/api/v1/subscription/1", body: update, headers: map[string]string{"If-Match": `1`}},
+			status:  fiber.StatusBadRequest,
+		},
+		{
+			name:    "put of one of the versions",
+			request: request{method: "PUT", url: "/api/v1/subscription/1", body: update, headers: map[string]string{"If-Match": `"5", "1"`}},
+			status:  fiber.StatusOK,
+		},
+		{
+			name: "patch of the replaced version",
+			request: request{
+				method: "PATCH", url: "/api/v1/subscription/1", body: patch,
+				contentType: services.MergePatchContentType, headers: map[string]string{"If-Match": `"1"`},
+			},
+			status: fiber.StatusPreconditionFailed,
+		},
+		{
+			name: "patch of the current version",
+			request: request{
+				method: "PATCH", url: "/api/v1/subscription/1", body: patch,
+				contentType: services.MergePatchContentType, headers: map[string]string{"If-Match": `"2"`},
+			},
+			status:   fiber.StatusOK,
+			wantETag: `"3"`,
+		},
+		{
+			name:    "delete of a stale version",
+			request: request{method: "DELETE", url: "/api/v1/subscription/1", headers: map[string]string{"If-Match": `"2"`}},
+			status:  fiber.StatusPreconditionFailed,
+		},
+		{
+			name:    "delete of any version",
+			request: request{method: "DELETE", url: "/api/v1/subscription/1", headers: map[string]string{"If-Match": `*`}},
+			status:  fiber.StatusOK,
+		},
+	}
+
+	// The requests change the same subscription one after another.
+	app := newTestApp(t)
+	newSubscription(t, app)
+
+	for _, tt := range tests {
+		resp := send(t, app, tt.request)
+		if resp.status != tt.status {
+			t.Fatalf("%s: got %d %s, want %d", tt.name, resp.status, resp.body, tt.status)
+		}
+		if tt.wantETag != "" && resp.headers[fiber.HeaderETag] != tt.wantETag {
+			t.Errorf("%s: got ETag %s, want %s", tt.name, resp.headers[fiber.HeaderETag], tt.wantETag)
+		}
+	}
+}
+
+func TestProblemDetails(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    request
+		status     int
+		wantFields []string
+	}{
+		{
+			name:    "unknown subscription",
+			request: request{method: "GET", url: "/api/v1/subscription/99"},
+			status:  fiber.StatusNotFound,
+		},
+		{
+			name:    "malformed id",
+			request: request{method: "GET", url: "/api/v1/subscription/abc"},
+			status:  fiber.StatusBadRequest,
+		},
+		{
+			name:    "malformed body",
+			request: request{method: "POST", url: "/api/v1/subscription/", body: `{"price": `},
+			status:  fiber.StatusBadRequest,
+		},
+		{
+			name: "invalid fields",
+			request: request{
+				method: "POST", url: "/api/v1/subscription/",
+				body: `{"service_name": "Netflix", "price": "599.00", "user_id": "nobody", "start_date": "2025-01"}`,
+			},
+			status:     fiber.StatusUnprocessableEntity,
+			wantFields: []string{"user_id", "start_date"},
+		},
+		{
+			name: "malformed amount",
+			request: request{
+				method: "POST", url: "/api/v1/subscription/",
+				body: `{"service_name": "Netflix", "price": "5.999", "user_id": "` + testUserID + `", "start_date": "01-2025"}`,
+			},
+			status:     fiber.StatusUnprocessableEntity,
+			wantFields: []string{"price"},
+		},
+		{
+			name:    "unsupported patch",
+			request: request{method: "PATCH", url: "/api/v1/subscription/1", body: `{}`, contentType: fiber.MIMETextPlain},
+			status:  fiber.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "immutable field in a patch",
+			request:    request{method: "PATCH", url: "/api/v1/subscription/1", body: `{"deleted_at": "2025-06-01T00:00:00Z"}`, contentType: services.MergePatchContentType},
+			status:     fiber.StatusUnprocessableEntity,
+			wantFields: []string{"deleted_at"},
+		},
+		{
+			name:    "failed test of a json patch",
+			request: request{method: "PATCH", url: "/api/v1/subscription/1", body: `[{"op": "test", "path": "/price", "value": "1.00"}]`, contentType: services.JSONPatchContentType},
+			status:  fiber.StatusConflict,
+		},
+		{
+			name:    "user with subscriptions",
+			request: request{method: "DELETE", url: "/api/v1/users/" + testUserID},
+			status:  fiber.StatusConflict,
+		},
+	}
+
+	app := newTestApp(t)
+	newSubscription(t, app)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := send(t, app, tt.request)
+			if resp.status != tt.status {
+				t.Fatalf("got %d %s, want %d", resp.status, resp.body, tt.status)
+			}
+
+			if got := resp.headers[fiber.HeaderContentType]; got != "application/problem+json" {
+				t.Errorf("got Content-Type %q, want application/problem+json", got)
+			}
+
+			var problem structures.Problem
+			if err := json.Unmarshal(resp.body, &problem); err != nil {
+				t.Fatalf("decode problem %s: %v", resp.body, err)
+			}
+			if problem.Status != tt.status || problem.Title == "" || problem.Instance != tt.request.url {
+				t.Errorf("got problem %+v for %s", problem, tt.request.url)
+			}
+
+			var fields []string
+			for _, field := range problem.Errors {
+				fields = append(fields, field.Field)
+			}
+			for _, field := range tt.wantFields {
+				if !slices.Contains(fields, field) {
+					t.Errorf("got fields %v, want %s among them", fields, field)
+				}
+			}
+		})
+	}
+}
+
+func TestImportRatesCSV(t *testing.T) {
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	file, err := writer.CreateFormFile("file", "rates.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(file, "month,from,to,rate\n03-2025,EUR,RUB,98.5\n")
+	writer.Close()
+
+	tests := []struct {
+		name       string
+		request    request
+		status     int
+		wantStored int
+		wantFields []string
+	}{
+		{
+			name: "body",
+			request: request{
+				method: "POST", url: "/api/v1/rates/csv", contentType: "text/csv",
+				body: "Month, From, To, Rate\n01-2025,USD,RUB,90\n02-2025,USD,RUB,91.25\n",
+			},
+			status:     fiber.StatusOK,
+			wantStored: 2,
+		},
+		{
+			name:       "multipart file",
+			request:    request{method: "POST", url: "/api/v1/rates/csv", contentType: writer.FormDataContentType(), body: form.String()},
+			status:     fiber.StatusOK,
+			wantStored: 1,
+		},
+		{
+			name:       "empty",
+			request:    request{method: "POST", url: "/api/v1/rates/csv", contentType: "text/csv"},
+			status:     fiber.StatusUnprocessableEntity,
+			wantFields: []string{"csv"},
+		},
+		{
+			name: "missing column",
+			request: request{
+				method: "POST", url: "/api/v1/rates/csv", contentType: "text/csv",
+				body: "month,from,rate\n01-2025,USD,90\n",
+			},
+			status:     fiber.StatusUnprocessableEntity,
+			wantFields: []string{"csv"},
+		},
+		{
+			name: "invalid rate",
+			request: request{
+				method: "POST", url: "/api/v1/rates/csv", contentType: "text/csv",
+				body: "month,from,to,rate\n01-2025,USD,RUB,90\n02-2025,USD,RUB,-1\n",
+			},
+			status:     fiber.StatusUnprocessableEntity,
+			wantFields: []string{"csv line 3"},
+		},
+	}
+
+	app := newTestApp(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := send(t, app, tt.request)
+			if resp.status != tt.status {
+				t.Fatalf("got %d %s, want %d", resp.status, resp.body, tt.status)
+			}
+
+			var body struct {
+				Stored int                     `json:"stored"`
+				Errors []structures.FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(resp.body, &body); err != nil {
+				t.Fatalf("decode %s: %v", resp.body, err)
+			}
+
+			if body.Stored != tt.wantStored {
+				t.Errorf("got %d stored, want %d", body.Stored, tt.wantStored)
+			}
+			for i, field := range tt.wantFields {
+				if i >= len(body.Errors) || body.Errors[i].Field != field {
+					t.Errorf("got errors %+v, want %v", body.Errors, tt.wantFields)
+				}
+			}
+		})
+	}
+
+	// The rates of both documents are stored, the rejected ones are not.
+	resp := mustSend(t, app, request{method: "GET", url: "/api/v1/rates/"}, fiber.StatusOK)
+
+	var body struct {
+		Rates []structures.ExchangeRate `json:"rates"`
+	}
+	if err := json.Unmarshal(resp.body, &body); err != nil {
+		t.Fatalf("decode %s: %v", resp.body, err)
+	}
+	if len(body.Rates) != 3 {
+		t.Errorf("got rates %+v, want 3", body.Rates)
+	}
+}
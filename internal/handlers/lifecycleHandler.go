@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PauseSubscription godoc
+// @Summary Pause subscription
+// @Description Stops billing an active subscription from the month in from on, the current month by default.
+// @Description Paused months are not counted in sums. Other statuses give 409
+// @Tags Lifecycle
+// @Accept json
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Param pause body structures.PauseRequest false "first paused month"
+// @Param If-Match header string false "ETag the subscription must still have"
+// @Success 200 {object} structures.Subscription
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "Invalid transition"
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id}/pause [post]
+func (h *SubscriptionHandler) PauseSubscription(c *fiber.Ctx) error {
+	id, versions, err := parseLifecycleRequest(c)
+	if err != nil {
+		return err
+	}
+
+	var request structures.PauseRequest
+	if err := parseOptionalBody(c, &request); err != nil {
+		return badRequest("Invalid pause format", err)
+	}
+
+	subscription, err := h.subscriptionService.PauseSub(c.UserContext(), id, versions, &request)
+	if err != nil {
+		return err
+	}
+
+	return lifecycleResponse(c, &subscription)
+}
+
+// ResumeSubscription godoc
+// @Summary Resume subscription
+// @Description Bills a paused subscription again from the month in from on, the current month by default.
+// @Description Other statuses give 409
+// @Tags Lifecycle
+// @Accept json
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Param resume body structures.PauseRequest false "first billed month"
+// @Param If-Match header string false "ETag the subscription must still have"
+// @Success 200 {object} structures.Subscription
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "Invalid transition"
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id}/resume [post]
+func (h *SubscriptionHandler) ResumeSubscription(c *fiber.Ctx) error {
+	id, versions, err := parseLifecycleRequest(c)
+	if err != nil {
+		return err
+	}
+
+	var request structures.PauseRequest
+	if err := parseOptionalBody(c, &request); err != nil {
+		return badRequest("Invalid resume format", err)
+	}
+
+	subscription, err := h.subscriptionService.ResumeSub(c.UserContext(), id, versions, &request)
+	if err != nil {
+		return err
+	}
+
+	return lifecycleResponse(c, &subscription)
+}
+
+// CancelSubscription godoc
+// @Summary Cancel subscription
+// @Description Cancels a trial, active or paused subscription. at=period_end (default) sets end_date to the last
+// @Description month of the billing period already paid for, at=immediately to the current month.
+// @Description Cancelled and expired subscriptions and ones that have not started yet give 409
+// @Tags Lifecycle
+// @Accept json
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Param cancel body structures.CancelRequest false "when the subscription ends"
+// @Param If-Match header string false "ETag the subscription must still have"
+// @Success 200 {object} structures.Subscription
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "Invalid transition"
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubscription(c *fiber.Ctx) error {
+	id, versions, err := parseLifecycleRequest(c)
+	if err != nil {
+		return err
+	}
+
+	var request structures.CancelRequest
+	if err := parseOptionalBody(c, &request); err != nil {
+		return badRequest("Invalid cancel format", err)
+	}
+
+	subscription, err := h.subscriptionService.CancelSub(c.UserContext(), id, versions, &request)
+	if err != nil {
+		return err
+	}
+
+	return lifecycleResponse(c, &subscription)
+}
+
+func parseLifecycleRequest(c *fiber.Ctx) (int, []int, error) {
+	id, err := parseID(c)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	versions, err := ifMatchVersions(c)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return id, versions, nil
+}
+
+// parseOptionalBody parses the body into out unless it is empty.
+func parseOptionalBody(c *fiber.Ctx, out any) error {
+	if len(c.Body()) == 0 {
+		return nil
+	}
+
+	return c.BodyParser(out)
+}
+
+func lifecycleResponse(c *fiber.Ctx, subscription *structures.Subscription) error {
+	c.Set(fiber.HeaderETag, formatETag(subscription.Version))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"Subscription": subscription,
+	})
+}
@@ -1,30 +1,36 @@
 package handlers
 
 import (
-	"log/slog"
+	"encoding/json"
 	"strconv"
+	"strings"
 
 	"github.com/QwaQ-dev/servicesSubscription/internal/services"
 	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
-	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
 	"github.com/gofiber/fiber/v2"
 )
 
 type SubscriptionHandler struct {
 	subscriptionService *services.SubscriptionService
-	log                 *slog.Logger
 }
 
 func NewSubsriptionHandler(
 	subscriptionService *services.SubscriptionService,
-	log *slog.Logger,
 ) *SubscriptionHandler {
 	return &SubscriptionHandler{
 		subscriptionService: subscriptionService,
-		log:                 log,
 	}
 }
 
+func parseID(c *fiber.Ctx) (int, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid ID")
+	}
+
+	return id, nil
+}
+
 // CreateSubscription godoc
 // @Summary Create Subscription
 // @Description Creating new subscription
@@ -33,30 +39,23 @@ func NewSubsriptionHandler(
 // @Produce json
 // @Param subscription body structures.Subscription true "Subscription data"
 // @Success 200 {object} map[string]interface{} "message + id"
-// @Failure 400 {object} structures.ErrorResponse "Invalid subscription format"
-// @Failure 500 {object} structures.ErrorResponse "Error"
+// @Failure 400 {object} structures.Problem "Invalid subscription format"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem "Error"
 // @Router /subscription/ [post]
 func (h *SubscriptionHandler) CreateSubscription(c *fiber.Ctx) error {
-	const op = "handlers.subscriptionHandler.CreateSubscription"
-	log := h.log.With("op", op)
-
 	subscription := new(structures.Subscription)
 
 	if err := c.BodyParser(subscription); err != nil {
-		log.Error("Invalid subscription fromat", sl.Err(err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Invalid subscription format",
-		})
+		return badRequest("Invalid subscription format", err)
 	}
 
-	id, err := h.subscriptionService.CreateSub(subscription)
+	id, err := h.subscriptionService.CreateSub(c.UserContext(), subscription)
 	if err != nil {
-		log.Error("Failed to create subsciption", sl.Err(err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err,
-		})
+		return err
 	}
 
+	c.Set(fiber.HeaderETag, formatETag(subscription.Version))
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Subscription created successfully",
 		"id":      id,
@@ -65,56 +64,72 @@ func (h *SubscriptionHandler) CreateSubscription(c *fiber.Ctx) error {
 
 // GetAllSubscriptions godoc
 // @Summary Get All subscriptions
-// @Description List of all subscriptions
+// @Description Paginated list of subscriptions. Use next_cursor from the response as cursor for the next page
 // @Tags Subscriptions
 // @Produce json
-// @Success 200 {object} map[string][]structures.Subscription
-// @Failure 500 {object} structures.ErrorResponse
+// @Param limit query int false "page size, 50 by default, 500 at most"
+// @Param offset query int false "number of subscriptions to skip, cannot be combined with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "id, price, start_date or service_name, prefix with - for descending" default(-id)
+// @Param user_id query string false "user ID"
+// @Param service_name query string false "service name, a name in the catalog matches all its aliases"
+// @Param service_id query int false "catalog service ID"
+// @Param category query string false "subscription category, case-insensitive"
+// @Param tag query string false "tag the subscription has, case-insensitive"
+// @Param active_at query string false "month in MM-YYYY the subscription is active at"
+// @Param min_price query string false "minimal price, decimal like 99.90"
+// @Param max_price query string false "maximal price, decimal like 499.00"
+// @Param status query string false "trial, active, paused, cancelled or expired"
+// @Param include_deleted query bool false "include soft-deleted subscriptions"
+// @Success 200 {object} structures.SubscriptionPage
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
 // @Router /subscription/ [get]
 func (h *SubscriptionHandler) GetAllSubscriptions(c *fiber.Ctx) error {
-	const op = "handlers.subscriptionHandler.GetAllSubscriptions"
-	log := h.log.With("op", op)
+	var filter structures.SubscriptionFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
 
-	subscriptions, err := h.subscriptionService.GetAllSubs()
+	page, err := h.subscriptionService.GetAllSubs(c.UserContext(), &filter)
 	if err != nil {
-		log.Error("Failed to get all subscriptions", sl.Err(err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err,
-		})
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"subscriptions": subscriptions,
-	})
+	return c.Status(fiber.StatusOK).JSON(page)
 }
 
 // GetOneSubscription godoc
 // @Summary Get one subscription by ID
-// @Description returns subscription by ID
+// @Description returns subscription by ID with its version as ETag
 // @Tags Subscriptions
 // @Produce json
 // @Param id path int true "subscription ID"
+// @Param include_deleted query bool false "return the subscription even if it is soft-deleted"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} structures.Subscription
-// @Failure 400 {object} structures.ErrorResponse
-// @Failure 404 {object} structures.ErrorResponse
+// @Success 304 "Not Modified"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 500 {object} structures.Problem
 // @Router /subscription/{id} [get]
 func (h *SubscriptionHandler) GetOneSubscription(c *fiber.Ctx) error {
-	const op = "handlers.subscriptionHandler.GetOneSubscription"
-	log := h.log.With("op", op)
-
-	idStr := c.Params("id")
-	id, err := strconv.Atoi(idStr)
+	id, err := parseID(c)
 	if err != nil {
-		log.Error("invalid article id", slog.String("id", idStr))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
+		return err
 	}
 
-	subscription, err := h.subscriptionService.GetSubById(id)
+	subscription, err := h.subscriptionService.GetSubById(c.UserContext(), id, c.QueryBool("include_deleted"))
 	if err != nil {
-		log.Error("Subscription not found", slog.Int("id", id), slog.Any("err", err))
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Subscription not found",
-		})
+		return err
+	}
+
+	etag := formatETag(subscription.Version)
+	c.Set(fiber.HeaderETag, etag)
+
+	if !noneMatch(c, etag) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.Status(200).JSON(fiber.Map{
@@ -130,75 +145,252 @@ func (h *SubscriptionHandler) GetOneSubscription(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path int true "subscription ID"
 // @Param subscription body structures.Subscription true "subscription data"
+// @Param If-Match header string false "ETag the subscription must still have"
 // @Success 200 {object} map[string]string "message"
-// @Failure 400 {object} structures.ErrorResponse
-// @Failure 500 {object} structures.ErrorResponse
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 500 {object} structures.Problem
 // @Router /subscription/{id} [put]
 func (h *SubscriptionHandler) UpdateSubscription(c *fiber.Ctx) error {
-	const op = "handlers.subscriptionHandler.UpdateSubscription"
-	log := h.log.With("op", op)
-
-	idStr := c.Params("id")
-	id, err := strconv.Atoi(idStr)
+	id, err := parseID(c)
 	if err != nil {
-		log.Error("invalid article id", slog.String("id", idStr))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
+		return err
 	}
 
 	var subscription structures.Subscription
 	if err := c.BodyParser(&subscription); err != nil {
-		log.Error("Failed to parse subscription body", slog.Any("err", err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid subscription format",
-		})
+		return badRequest("Invalid subscription format", err)
 	}
 
-	if id == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "ID is required for update",
-		})
+	versions, err := ifMatchVersions(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.subscriptionService.UpdateSub(&subscription, id); err != nil {
-		log.Error("Failed to update subscription", slog.Any("err", err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update subscription",
-		})
+	if err := h.subscriptionService.UpdateSub(c.UserContext(), &subscription, id, versions); err != nil {
+		return err
 	}
 
+	c.Set(fiber.HeaderETag, formatETag(subscription.Version))
 	return c.Status(200).JSON(fiber.Map{
 		"message": "Subscription has been updated",
 	})
 }
 
+// PatchSubscription godoc
+// @Summary Partially update subscription
+// @Description Updates only the fields present in the patch. Accepts JSON Merge Patch (RFC 7386,
+// @Description application/merge-patch+json or application/json), where null clears end_date,
+// @Description and JSON Patch (RFC 6902, application/json-patch+json) on top-level fields
+// @Tags Subscriptions
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Param patch body object true "merge patch object or array of JSON Patch operations"
+// @Param If-Match header string false "ETag the subscription must still have"
+// @Success 200 {object} map[string]structures.Subscription "updated subscription"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "JSON Patch test operation failed"
+// @Failure 415 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id} [patch]
+func (h *SubscriptionHandler) PatchSubscription(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(c.Get(fiber.HeaderContentType), ";")[0]))
+	switch contentType {
+	case fiber.MIMEApplicationJSON:
+		contentType = services.MergePatchContentType
+	case services.MergePatchContentType, services.JSONPatchContentType:
+	default:
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "Use application/merge-patch+json or application/json-patch+json")
+	}
+
+	if !json.Valid(c.Body()) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid patch format")
+	}
+
+	versions, err := ifMatchVersions(c)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.subscriptionService.PatchSub(c.UserContext(), id, versions, c.Body(), contentType)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, formatETag(subscription.Version))
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"Subscription": subscription,
+	})
+}
+
+// SchedulePrice godoc
+// @Summary Schedule a price change
+// @Description Changes the subscription price from effective_from on, earlier months keep the old price.
+// @Description A change already scheduled for the same month is replaced
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Param change body structures.PriceChangeJSON true "new price in the currency of the subscription and the month it takes effect"
+// @Param If-Match header string false "ETag the subscription must still have"
+// @Success 200 {object} structures.Subscription
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id}/prices [post]
+func (h *SubscriptionHandler) SchedulePrice(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	var change structures.PriceChangeJSON
+	if err := c.BodyParser(&change); err != nil {
+		return badRequest("Invalid price change format", err)
+	}
+
+	versions, err := ifMatchVersions(c)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.subscriptionService.SchedulePrice(c.UserContext(), id, versions, &change)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, formatETag(subscription.Version))
+
+	return c.Status(200).JSON(fiber.Map{
+		"Subscription": subscription,
+	})
+}
+
+// DeletePrice godoc
+// @Summary Delete a scheduled price change
+// @Description Removes the price change scheduled for month, the price before it is charged on
+// @Tags Subscriptions
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Param month path string true "effective_from of the change in MM-YYYY"
+// @Param If-Match header string false "ETag the subscription must still have"
+// @Success 200 {object} structures.Subscription
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem "No subscription or no change for month"
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id}/prices/{month} [delete]
+func (h *SubscriptionHandler) DeletePrice(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	versions, err := ifMatchVersions(c)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.subscriptionService.DeletePrice(c.UserContext(), id, versions, c.Params("month"))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, formatETag(subscription.Version))
+
+	return c.Status(200).JSON(fiber.Map{
+		"Subscription": subscription,
+	})
+}
+
+// SetMembers godoc
+// @Summary Share a subscription
+// @Description Replaces the users sharing the cost with the owner. A member pays a fixed amount of every charge
+// @Description or weight parts (1 by default) of what the amounts leave, the owner takes 1 part unless listed.
+// @Description An empty list makes the subscription personal again
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Param members body []structures.MemberJSON true "members, amounts in the currency of the subscription"
+// @Param If-Match header string false "ETag the subscription must still have"
+// @Success 200 {object} structures.Subscription
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id}/members [put]
+func (h *SubscriptionHandler) SetMembers(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	var members []structures.MemberJSON
+	if err := c.BodyParser(&members); err != nil {
+		return badRequest("Invalid members format", err)
+	}
+
+	versions, err := ifMatchVersions(c)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.subscriptionService.SetMembers(c.UserContext(), id, versions, members)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, formatETag(subscription.Version))
+
+	return c.Status(200).JSON(fiber.Map{
+		"Subscription": subscription,
+	})
+}
+
 // DeleteSubscription godoc
 // @Summary Delete subscription
-// @Description Delete subscription by ID
+// @Description Soft-deletes subscription by ID. It can be restored until it is purged after the retention period
 // @Tags Subscriptions
 // @Produce json
 // @Param id path int true "subscription ID"
+// @Param If-Match header string false "ETag the subscription must still have"
 // @Success 200 {object} map[string]string "message"
-// @Failure 400 {object} structures.ErrorResponse
-// @Failure 500 {object} structures.ErrorResponse
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 500 {object} structures.Problem
 // @Router /subscription/{id} [delete]
 func (h *SubscriptionHandler) DeleteSubscription(c *fiber.Ctx) error {
-	const op = "handlers.subscriptionHandler.DeleteSubscription"
-	log := h.log.With("op", op)
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
 
-	idStr := c.Params("id")
-	id, err := strconv.Atoi(idStr)
+	versions, err := ifMatchVersions(c)
 	if err != nil {
-		log.Error("Invalid article id", slog.String("id", idStr))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid ID",
-		})
+		return err
 	}
 
-	if err := h.subscriptionService.DeleteSub(id); err != nil {
-		log.Error("Failed to delete article", slog.Int("id", id), slog.Any("err", err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete subscription",
-		})
+	if err := h.subscriptionService.DeleteSub(c.UserContext(), id, versions); err != nil {
+		return err
 	}
 
 	return c.Status(200).JSON(fiber.Map{
@@ -206,38 +398,222 @@ func (h *SubscriptionHandler) DeleteSubscription(c *fiber.Ctx) error {
 	})
 }
 
+// RestoreSubscription godoc
+// @Summary Restore subscription
+// @Description Restores a soft-deleted subscription by ID
+// @Tags Subscriptions
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Param If-Match header string false "ETag the deleted subscription must still have"
+// @Success 200 {object} structures.Subscription
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "Subscription is not deleted"
+// @Failure 412 {object} structures.Problem "If-Match does not match"
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id}/restore [post]
+func (h *SubscriptionHandler) RestoreSubscription(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	versions, err := ifMatchVersions(c)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.subscriptionService.RestoreSub(c.UserContext(), id, versions)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, formatETag(subscription.Version))
+
+	return c.Status(200).JSON(fiber.Map{
+		"Subscription": subscription,
+	})
+}
+
+// GetSubscriptionHistory godoc
+// @Summary Get subscription history
+// @Description Returns every change of the subscription, oldest first, with the snapshots before and after it
+// @Tags Audit
+// @Produce json
+// @Param id path int true "subscription ID"
+// @Success 200 {array} structures.AuditEntry
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 500 {object} structures.Problem
+// @Router /subscription/{id}/history [get]
+func (h *SubscriptionHandler) GetSubscriptionHistory(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.subscriptionService.GetHistory(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"history": entries,
+	})
+}
+
+// GetAudit godoc
+// @Summary Query the audit trail
+// @Description Paginated changes of all subscriptions, newest first
+// @Tags Audit
+// @Produce json
+// @Param limit query int false "page size, 50 by default, 500 at most"
+// @Param offset query int false "number of entries to skip"
+// @Param actor query string false "who made the change, see the X-Actor header"
+// @Param action query string false "create, update, delete, restore or purge"
+// @Param subscription_id query int false "subscription ID"
+// @Param from query string false "RFC 3339 timestamp, inclusive"
+// @Param to query string false "RFC 3339 timestamp, inclusive"
+// @Success 200 {object} structures.AuditPage
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /audit [get]
+func (h *SubscriptionHandler) GetAudit(c *fiber.Ctx) error {
+	var filter structures.AuditFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	page, err := h.subscriptionService.GetAudit(c.UserContext(), &filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
 // GetSumm godoc
 // @Summary Get summ of subscriptions prices
-// @Description Returns the sum of all subscriptions filtered by date, user and service
+// @Description Returns the sum of subscription prices for every month each subscription was active
+// @Description within the period, filtered by user and service. Subscriptions without end_date run to the end of the period.
+// @Description With user_id only the share of the user in the subscriptions it owns or is a member of is counted.
+// @Description Prices are converted to target_currency (RUB by default) with the rate of each month, rates lists the rates used.
+// @Description Amounts are decimal strings; each prorated or converted subscription-month is rounded half away from zero
+// @Description to minor units of target_currency before summing, rounding describes the rule
 // @Tags Sum
 // @Accept json
 // @Produce json
 // @Param counting body structures.Counting true "Filters"
-// @Success 200 {object} map[string]float64 "total"
-// @Failure 400 {object} structures.ErrorResponse
-// @Failure 500 {object} structures.ErrorResponse
+// @Param mode query string false "amortized spreads each charge over its billing period, charges counts it in the month it is charged" Enums(amortized, charges) default(amortized)
+// @Success 200 {object} structures.SpendTotal
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
 // @Router /summ/ [get]
 func (h *SubscriptionHandler) GetSumm(c *fiber.Ctx) error {
-	const op = "handlers.subscriptionHandler.GetSumm"
-	log := h.log.With("op", op)
+	var data structures.Counting
+	if err := c.BodyParser(&data); err != nil {
+		return badRequest("Invalid request body", err)
+	}
+	data.Mode = c.Query("mode")
 
+	total, err := h.subscriptionService.Counting(c.UserContext(), &data)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(200).JSON(total)
+}
+
+// GetMonthlySumm godoc
+// @Summary Get monthly breakdown of subscriptions prices
+// @Description Returns one bucket per month of the period with the total price and the number
+// @Description of active subscriptions, filtered and converted to target_currency like /summ/
+// @Tags Sum
+// @Accept json
+// @Produce json
+// @Param counting body structures.Counting true "Filters"
+// @Param mode query string false "amortized spreads each charge over its billing period, charges counts it in the month it is charged" Enums(amortized, charges) default(amortized)
+// @Success 200 {object} structures.MonthlySpendReport
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /summ/monthly [get]
+func (h *SubscriptionHandler) GetMonthlySumm(c *fiber.Ctx) error {
 	var data structures.Counting
 	if err := c.BodyParser(&data); err != nil {
-		log.Error("Failed to parse counting body", sl.Err(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return badRequest("Invalid request body", err)
 	}
+	data.Mode = c.Query("mode")
 
-	total, err := h.subscriptionService.Counting(&data)
+	report, err := h.subscriptionService.MonthlyCounting(c.UserContext(), &data)
 	if err != nil {
-		log.Error("Failed to get sum", sl.Err(err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get sum",
-		})
+		return err
 	}
 
-	return c.Status(200).JSON(fiber.Map{
-		"total": total,
-	})
+	return c.Status(200).JSON(report)
+}
+
+// GetGroupedSumm godoc
+// @Summary Get subscriptions prices grouped by service, user or category
+// @Description Returns totals per service_name, user_id or category for the period, sorted by amount.
+// @Description group_by is required, top limits the number of groups (0 means all). Amounts are converted to target_currency like /summ/
+// @Tags Sum
+// @Accept json
+// @Produce json
+// @Param counting body structures.Counting true "Filters"
+// @Param mode query string false "amortized spreads each charge over its billing period, charges counts it in the month it is charged" Enums(amortized, charges) default(amortized)
+// @Success 200 {object} structures.GroupSpendReport
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /summ/grouped [get]
+func (h *SubscriptionHandler) GetGroupedSumm(c *fiber.Ctx) error {
+	var data structures.Counting
+	if err := c.BodyParser(&data); err != nil {
+		return badRequest("Invalid request body", err)
+	}
+	data.Mode = c.Query("mode")
+
+	if data.GroupBy == "" {
+		return structures.NewFieldError("group_by", "is required")
+	}
+
+	report, err := h.subscriptionService.GroupedCounting(c.UserContext(), &data)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(200).JSON(report)
+}
+
+// GetSettlement godoc
+// @Summary Get who owes whom for shared subscriptions
+// @Description Returns what the members of shared subscriptions owe their owners for the period, netted between
+// @Description every two users and ordered by amount. Shares are counted and converted to target_currency like /summ/,
+// @Description user_id lists only the debts of and to the user
+// @Tags Sum
+// @Accept json
+// @Produce json
+// @Param counting body structures.Counting true "Filters"
+// @Param mode query string false "amortized spreads each charge over its billing period, charges counts it in the month it is charged" Enums(amortized, charges) default(amortized)
+// @Success 200 {object} structures.Settlement
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /summ/settlement [get]
+func (h *SubscriptionHandler) GetSettlement(c *fiber.Ctx) error {
+	var data structures.Counting
+	if err := c.BodyParser(&data); err != nil {
+		return badRequest("Invalid request body", err)
+	}
+	data.Mode = c.Query("mode")
+
+	settlement, err := h.subscriptionService.Settlement(c.UserContext(), &data)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(200).JSON(settlement)
 }
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// parseUserID returns the user UUID of the id path parameter in canonical form.
+func parseUserID(c *fiber.Ctx) (string, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return "", badRequest("Invalid user ID", err)
+	}
+
+	return id.String(), nil
+}
+
+// CreateUser godoc
+// @Summary Create user
+// @Description Creates a user subscriptions can refer to. The id is generated unless given
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param user body structures.User true "User data"
+// @Success 200 {object} map[string]interface{} "message + id"
+// @Failure 400 {object} structures.Problem "Invalid user format"
+// @Failure 409 {object} structures.Problem "id or email is taken"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /users/ [post]
+func (h *SubscriptionHandler) CreateUser(c *fiber.Ctx) error {
+	var user structures.User
+	if err := c.BodyParser(&user); err != nil {
+		return badRequest("Invalid user format", err)
+	}
+
+	id, err := h.subscriptionService.CreateUser(c.UserContext(), &user)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User created successfully",
+		"id":      id,
+	})
+}
+
+// GetUsers godoc
+// @Summary Get all users
+// @Description Paginated list of users, oldest first
+// @Tags Users
+// @Produce json
+// @Param limit query int false "page size, 50 by default, 500 at most"
+// @Param offset query int false "number of users to skip"
+// @Success 200 {object} structures.UserPage
+// @Failure 400 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /users/ [get]
+func (h *SubscriptionHandler) GetUsers(c *fiber.Ctx) error {
+	var filter structures.UserFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	page, err := h.subscriptionService.GetUsers(c.UserContext(), &filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// GetOneUser godoc
+// @Summary Get one user by ID
+// @Tags Users
+// @Produce json
+// @Param id path string true "user UUID"
+// @Success 200 {object} map[string]structures.User "User"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 500 {object} structures.Problem
+// @Router /users/{id} [get]
+func (h *SubscriptionHandler) GetOneUser(c *fiber.Ctx) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.subscriptionService.GetUserById(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"User": user,
+	})
+}
+
+// UpdateUser godoc
+// @Summary Update user
+// @Description Overwrites the name and email of the user
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "user UUID"
+// @Param user body structures.User true "User data"
+// @Success 200 {object} map[string]structures.User "updated User"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "email is taken"
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /users/{id} [put]
+func (h *SubscriptionHandler) UpdateUser(c *fiber.Ctx) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var user structures.User
+	if err := c.BodyParser(&user); err != nil {
+		return badRequest("Invalid user format", err)
+	}
+
+	if err := h.subscriptionService.UpdateUser(c.UserContext(), &user, id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"User": user,
+	})
+}
+
+// DeleteUser godoc
+// @Summary Delete user
+// @Description Deletes a user without subscriptions. Soft-deleted subscriptions count until they are purged
+// @Tags Users
+// @Produce json
+// @Param id path string true "user UUID"
+// @Success 200 {object} map[string]string "message"
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 409 {object} structures.Problem "User has subscriptions"
+// @Failure 500 {object} structures.Problem
+// @Router /users/{id} [delete]
+func (h *SubscriptionHandler) DeleteUser(c *fiber.Ctx) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.subscriptionService.DeleteUser(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User has been deleted",
+	})
+}
+
+// GetUserSubscriptions godoc
+// @Summary Get subscriptions of a user
+// @Description Paginated list of the subscriptions of the user, filtered and sorted like /subscription/
+// @Tags Users
+// @Produce json
+// @Param id path string true "user UUID"
+// @Param limit query int false "page size, 50 by default, 500 at most"
+// @Param offset query int false "number of subscriptions to skip, cannot be combined with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "id, price, start_date or service_name, prefix with - for descending" default(-id)
+// @Param service_name query string false "service name, a name in the catalog matches all its aliases"
+// @Param service_id query int false "catalog service ID"
+// @Param category query string false "subscription category, case-insensitive"
+// @Param tag query string false "tag the subscription has, case-insensitive"
+// @Param active_at query string false "month in MM-YYYY the subscription is active at"
+// @Param status query string false "trial, active, paused, cancelled or expired"
+// @Param include_deleted query bool false "include soft-deleted subscriptions"
+// @Success 200 {object} structures.SubscriptionPage
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /users/{id}/subscriptions [get]
+func (h *SubscriptionHandler) GetUserSubscriptions(c *fiber.Ctx) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var filter structures.SubscriptionFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return badRequest("Invalid query parameters", err)
+	}
+
+	page, err := h.subscriptionService.GetUserSubs(c.UserContext(), id, &filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// GetUserSummary godoc
+// @Summary Get summary of a user
+// @Description Number of subscriptions active or in trial this month, their amortized spend this month
+// @Description converted to target_currency like /summ/monthly, and the next charge within a year
+// @Tags Users
+// @Produce json
+// @Param id path string true "user UUID"
+// @Param target_currency query string false "currency of monthly_spend, RUB by default"
+// @Success 200 {object} structures.UserSummary
+// @Failure 400 {object} structures.Problem
+// @Failure 404 {object} structures.Problem
+// @Failure 422 {object} structures.Problem "Invalid fields"
+// @Failure 500 {object} structures.Problem
+// @Router /users/{id}/summary [get]
+func (h *SubscriptionHandler) GetUserSummary(c *fiber.Ctx) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return err
+	}
+
+	summary, err := h.subscriptionService.GetUserSummary(c.UserContext(), id, c.Query("target_currency"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(summary)
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+const auditColumns = `
+	id, subscription_id, action, actor, request_id, before, after, changed_at
+`
+
+// insertAudit records a change of a subscription within tx, so that the
+// change and its audit entry are committed together. The actor and the
+// request ID are taken from ctx.
+func insertAudit(ctx context.Context, tx *sql.Tx, action string, before, after *structures.Subscription) error {
+	info := structures.RequestInfoFrom(ctx)
+
+	subscriptionID := 0
+	if after != nil {
+		subscriptionID = after.ID
+	} else if before != nil {
+		subscriptionID = before.ID
+	}
+
+	beforeJSON, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO subscription_audit (subscription_id, action, actor, request_id, before, after)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb)
+	`
+
+	_, err = tx.ExecContext(ctx, query, subscriptionID, action, info.Actor, info.RequestID, beforeJSON, afterJSON)
+	return err
+}
+
+// snapshot encodes subscription for a jsonb column, nil becomes SQL NULL.
+func snapshot(subscription *structures.Subscription) (sql.NullString, error) {
+	if subscription == nil {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// scanAuditEntry scans a row selected with auditColumns.
+func scanAuditEntry(row rowScanner, entry *structures.AuditEntry) error {
+	var before, after []byte
+
+	err := row.Scan(
+		&entry.ID,
+		&entry.SubscriptionID,
+		&entry.Action,
+		&entry.Actor,
+		&entry.RequestID,
+		&before,
+		&after,
+		&entry.ChangedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if before != nil {
+		entry.Before = &structures.Subscription{}
+		if err := json.Unmarshal(before, entry.Before); err != nil {
+			return err
+		}
+	}
+
+	if after != nil {
+		entry.After = &structures.Subscription{}
+		if err := json.Unmarshal(after, entry.After); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SelectHistory returns the audit entries of the subscription with id, oldest first.
+func (r *SubscriptionRepo) SelectHistory(ctx context.Context, id int) ([]structures.AuditEntry, error) {
+	const op = "repository.subscriptionRepo.SelectHistory"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + auditColumns + `
+		FROM subscription_audit
+		WHERE subscription_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		log.Error("Failed to select history", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	entries := []structures.AuditEntry{}
+
+	for rows.Next() {
+		var entry structures.AuditEntry
+
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			log.Error("Failed to scan audit entry", sl.Err(err))
+			return nil, wrapError(op, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	return entries, nil
+}
+
+// SelectAudit returns a page of audit entries matching filter, newest first.
+func (r *SubscriptionRepo) SelectAudit(ctx context.Context, filter *structures.AuditFilter) (structures.AuditPage, error) {
+	const op = "repository.subscriptionRepo.SelectAudit"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	page := structures.AuditPage{Entries: []structures.AuditEntry{}}
+
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var conditions []string
+
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = "+arg(filter.Actor))
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = "+arg(filter.Action))
+	}
+	if filter.SubscriptionID != 0 {
+		conditions = append(conditions, "subscription_id = "+arg(filter.SubscriptionID))
+	}
+	if filter.From != "" {
+		conditions = append(conditions, "changed_at >= "+arg(filter.From)+"::timestamptz")
+	}
+	if filter.To != "" {
+		conditions = append(conditions, "changed_at <= "+arg(filter.To)+"::timestamptz")
+	}
+
+	countQuery := `
+		SELECT COUNT(*)
+		FROM subscription_audit
+	` + whereClause(conditions)
+
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&page.Total); err != nil {
+		log.Error("Failed to count audit entries", sl.Err(err))
+		return page, wrapError(op, err)
+	}
+
+	limit := "ALL"
+	if filter.Limit > 0 {
+		limit = arg(filter.Limit)
+	}
+
+	query := `
+		SELECT ` + auditColumns + `
+		FROM subscription_audit
+	` + whereClause(conditions) + fmt.Sprintf(`
+		ORDER BY id DESC
+		LIMIT %s OFFSET %s
+	`, limit, arg(filter.Offset))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error("Failed to select audit entries", sl.Err(err))
+		return page, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry structures.AuditEntry
+
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			log.Error("Failed to scan audit entry", sl.Err(err))
+			return page, wrapError(op, err)
+		}
+
+		page.Entries = append(page.Entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return page, wrapError(op, err)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+const budgetColumns = `id, user_id, category, COALESCE(service_id, 0), amount, currency`
+
+func scanBudget(row rowScanner, budget *structures.Budget) error {
+	return row.Scan(
+		&budget.ID,
+		&budget.UserID,
+		&budget.Category,
+		&budget.ServiceID,
+		&budget.Amount,
+		&budget.Currency,
+	)
+}
+
+// InsertBudget stores budget and sets its ID. A second budget of the user
+// with the same scope is a conflict.
+func (r *SubscriptionRepo) InsertBudget(ctx context.Context, budget *structures.Budget) (int, error) {
+	const op = "repository.subscriptionRepo.InsertBudget"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO budgets (user_id, category, service_id, amount, currency)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		budget.UserID,
+		budget.Category,
+		budget.ServiceID,
+		budget.Amount,
+		budget.Currency,
+	).Scan(&budget.ID)
+	if err != nil {
+		log.Error("Failed to insert budget", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	log.Info("Budget created", slog.Int("id", budget.ID))
+	return budget.ID, nil
+}
+
+// SelectBudgets returns the budgets of the user with userID, of every user
+// if it is empty, ordered by ID.
+func (r *SubscriptionRepo) SelectBudgets(ctx context.Context, userID string) ([]structures.Budget, error) {
+	const op = "repository.subscriptionRepo.SelectBudgets"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE ($1 = '' OR user_id = $1::uuid)
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		log.Error("Failed to select budgets", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	budgets := []structures.Budget{}
+
+	for rows.Next() {
+		var budget structures.Budget
+
+		if err := scanBudget(rows, &budget); err != nil {
+			log.Error("Failed to scan budget", sl.Err(err))
+			return nil, wrapError(op, err)
+		}
+
+		budgets = append(budgets, budget)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	return budgets, nil
+}
+
+func (r *SubscriptionRepo) SelectBudgetById(ctx context.Context, id int) (structures.Budget, error) {
+	const op = "repository.subscriptionRepo.SelectBudgetById"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var budget structures.Budget
+
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE id = $1`
+
+	if err := scanBudget(r.db.QueryRowContext(ctx, query, id), &budget); err != nil {
+		log.Error("Failed to select budget", slog.Int("id", id), sl.Err(err))
+		return budget, wrapError(op, err)
+	}
+
+	return budget, nil
+}
+
+// UpdateBudget overwrites the budget with id and sets the stored fields of
+// budget.
+func (r *SubscriptionRepo) UpdateBudget(ctx context.Context, budget *structures.Budget, id int) error {
+	const op = "repository.subscriptionRepo.UpdateBudget"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE budgets
+		SET user_id = $1,
+			category = $2,
+			service_id = NULLIF($3, 0),
+			amount = $4,
+			currency = $5
+		WHERE id = $6
+		RETURNING ` + budgetColumns
+
+	var updated structures.Budget
+
+	err := scanBudget(r.db.QueryRowContext(
+		ctx,
+		query,
+		budget.UserID,
+		budget.Category,
+		budget.ServiceID,
+		budget.Amount,
+		budget.Currency,
+		id,
+	), &updated)
+	if err != nil {
+		log.Error("Failed to update budget", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	*budget = updated
+
+	log.Info("Budget updated", slog.Int("id", id))
+	return nil
+}
+
+func (r *SubscriptionRepo) DeleteBudget(ctx context.Context, id int) error {
+	const op = "repository.subscriptionRepo.DeleteBudget"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1`, id)
+	if err != nil {
+		log.Error("Failed to delete budget", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error("Failed to get affected rows", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if affected == 0 {
+		return wrapError(op, sql.ErrNoRows)
+	}
+
+	log.Info("Budget deleted", slog.Int("id", id))
+	return nil
+}
@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+// The row of the name itself is not listed among the aliases.
+const serviceColumns = `
+	id, name, category, website, default_price, currency,
+	COALESCE((
+		SELECT json_agg(a.alias ORDER BY a.alias COLLATE "C")
+		FROM service_aliases a
+		WHERE a.service_id = services.id AND a.alias <> services.name
+	), '[]')
+`
+
+func scanService(row rowScanner, service *structures.Service) error {
+	var aliases []byte
+
+	err := row.Scan(
+		&service.ID,
+		&service.Name,
+		&service.Category,
+		&service.Website,
+		&service.DefaultPrice,
+		&service.Currency,
+		&aliases,
+	)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(aliases, &service.Aliases)
+}
+
+// InsertService stores service with its aliases and sets its ID. A name or
+// alias already used by another service is a conflict.
+func (r *SubscriptionRepo) InsertService(ctx context.Context, service *structures.Service) (int, error) {
+	const op = "repository.subscriptionRepo.InsertService"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO services (name, category, website, default_price, currency)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	var id int
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		service.Name,
+		service.Category,
+		service.Website,
+		service.DefaultPrice,
+		service.Currency,
+	).Scan(&id)
+	if err != nil {
+		log.Error("Failed to insert service", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	if err := insertAliases(ctx, tx, id, service); err != nil {
+		log.Error("Failed to insert aliases", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit service", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	service.ID = id
+
+	log.Info("Service created", slog.Int("id", id))
+	return id, nil
+}
+
+// insertAliases stores the keys of the name and aliases of service.
+func insertAliases(ctx context.Context, tx *sql.Tx, id int, service *structures.Service) error {
+	query := `INSERT INTO service_aliases (key, alias, service_id) VALUES ($1, $2, $3)`
+
+	names := append([]string{service.Name}, service.Aliases...)
+	for i, key := range service.Keys() {
+		if _, err := tx.ExecContext(ctx, query, key, names[i], id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SelectServices returns the whole catalog ordered by name.
+func (r *SubscriptionRepo) SelectServices(ctx context.Context) ([]structures.Service, error) {
+	const op = "repository.subscriptionRepo.SelectServices"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+serviceColumns+` FROM services ORDER BY lower(name) COLLATE "C", id`)
+	if err != nil {
+		log.Error("Failed to select services", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	services := []structures.Service{}
+
+	for rows.Next() {
+		var service structures.Service
+
+		if err := scanService(rows, &service); err != nil {
+			log.Error("Failed to scan service", sl.Err(err))
+			return nil, wrapError(op, err)
+		}
+
+		services = append(services, service)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	return services, nil
+}
+
+func (r *SubscriptionRepo) SelectServiceById(ctx context.Context, id int) (structures.Service, error) {
+	const op = "repository.subscriptionRepo.SelectServiceById"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var service structures.Service
+
+	query := `SELECT ` + serviceColumns + ` FROM services WHERE id = $1`
+
+	if err := scanService(r.db.QueryRowContext(ctx, query, id), &service); err != nil {
+		log.Error("Failed to select service", slog.Int("id", id), sl.Err(err))
+		return service, wrapError(op, err)
+	}
+
+	return service, nil
+}
+
+// SelectServiceByKey returns the service whose name or alias has key, see
+// structures.ServiceKey.
+func (r *SubscriptionRepo) SelectServiceByKey(ctx context.Context, key string) (structures.Service, error) {
+	const op = "repository.subscriptionRepo.SelectServiceByKey"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var service structures.Service
+
+	query := `
+		SELECT ` + serviceColumns + `
+		FROM services
+		WHERE id = (SELECT service_id FROM service_aliases WHERE key = $1)
+	`
+
+	if err := scanService(r.db.QueryRowContext(ctx, query, key), &service); err != nil {
+		log.Debug("Service not found by key", slog.String("key", key), sl.Err(err))
+		return service, wrapError(op, err)
+	}
+
+	return service, nil
+}
+
+// UpdateService overwrites the service with id and its aliases. When the
+// name changes, the subscriptions of the service are renamed too.
+func (r *SubscriptionRepo) UpdateService(ctx context.Context, service *structures.Service, id int) error {
+	const op = "repository.subscriptionRepo.UpdateService"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE services
+		SET name = $1,
+			category = $2,
+			website = $3,
+			default_price = $4,
+			currency = $5
+		WHERE id = $6
+		RETURNING id
+	`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		service.Name,
+		service.Category,
+		service.Website,
+		service.DefaultPrice,
+		service.Currency,
+		id,
+	).Scan(&service.ID)
+	if err != nil {
+		log.Error("Failed to update service", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM service_aliases WHERE service_id = $1`, id); err != nil {
+		log.Error("Failed to delete aliases", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := insertAliases(ctx, tx, id, service); err != nil {
+		log.Error("Failed to insert aliases", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	renamed, err := setSubsService(ctx, tx, service, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE service_id = $1 AND service_name <> $2
+		ORDER BY id
+		FOR UPDATE
+	`, id, service.Name)
+	if err != nil {
+		log.Error("Failed to rename subscriptions", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit service", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	log.Info("Service updated", slog.Int("id", id), slog.Int("renamed", renamed))
+	return nil
+}
+
+// DeleteService removes the service with id. Services still referred to by
+// a subscription, soft-deleted ones included, are a conflict.
+func (r *SubscriptionRepo) DeleteService(ctx context.Context, id int) error {
+	const op = "repository.subscriptionRepo.DeleteService"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM services WHERE id = $1`, id)
+	if err != nil {
+		log.Error("Failed to delete service", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error("Failed to get affected rows", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if affected == 0 {
+		return wrapError(op, sql.ErrNoRows)
+	}
+
+	log.Info("Service deleted", slog.Int("id", id))
+	return nil
+}
+
+// SelectUnmatchedNames returns the names of the subscriptions that are not
+// deleted and have no service, most used first.
+func (r *SubscriptionRepo) SelectUnmatchedNames(ctx context.Context) ([]structures.UnmatchedName, error) {
+	const op = "repository.subscriptionRepo.SelectUnmatchedNames"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT service_name, COUNT(*)
+		FROM subscriptions
+		WHERE service_id IS NULL AND deleted_at IS NULL
+		GROUP BY service_name
+		ORDER BY COUNT(*) DESC, service_name COLLATE "C"
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Error("Failed to select unmatched names", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	names := []structures.UnmatchedName{}
+
+	for rows.Next() {
+		var name structures.UnmatchedName
+
+		if err := rows.Scan(&name.ServiceName, &name.Subscriptions); err != nil {
+			log.Error("Failed to scan unmatched name", sl.Err(err))
+			return nil, wrapError(op, err)
+		}
+
+		names = append(names, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	return names, nil
+}
+
+// AssignService gives the subscriptions that are not deleted, have no
+// service and are named serviceName the service and its name. It returns
+// how many there were.
+func (r *SubscriptionRepo) AssignService(ctx context.Context, serviceName string, service *structures.Service) (int, error) {
+	const op = "repository.subscriptionRepo.AssignService"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	assigned, err := setSubsService(ctx, tx, service, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE service_id IS NULL AND deleted_at IS NULL AND service_name = $1
+		ORDER BY id
+		FOR UPDATE
+	`, serviceName)
+	if err != nil {
+		log.Error("Failed to assign service", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit service assignment", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	return assigned, nil
+}
+
+// setSubsService gives the subscriptions selected by query within tx the
+// ID and name of service, recording every change in the audit trail.
+func setSubsService(ctx context.Context, tx *sql.Tx, service *structures.Service, query string, args ...any) (int, error) {
+	subscriptions, err := selectSubs(ctx, tx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	update := `
+		UPDATE subscriptions
+		SET service_id = $1,
+			service_name = $2,
+			version = version + 1
+		WHERE id = $3
+		RETURNING ` + subscriptionColumns
+
+	for i := range subscriptions {
+		before := &subscriptions[i]
+
+		var after structures.Subscription
+
+		if err := scanSubscription(tx.QueryRowContext(ctx, update, service.ID, service.Name, before.ID), &after); err != nil {
+			return 0, err
+		}
+
+		if err := insertAudit(ctx, tx, structures.AuditUpdate, before, &after); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(subscriptions), nil
+}
@@ -0,0 +1,681 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+// The contract tests run against every SubscriptionRepository: the memory
+// storage always, Postgres when TEST_DATABASE_URL points to a database the
+// tests may wipe. CI runs them with one and fails without it.
+
+const testDatabaseURL = "TEST_DATABASE_URL"
+
+type repoFactory func(t *testing.T) SubscriptionRepository
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestMemoryContract(t *testing.T) {
+	runContract(t, func(t *testing.T) SubscriptionRepository {
+		return NewMemorySubscriptionRepo(testLogger())
+	})
+}
+
+func TestPostgresContract(t *testing.T) {
+	url := os.Getenv(testDatabaseURL)
+	if url == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal(testDatabaseURL + " must be set in CI")
+		}
+		t.Skip(testDatabaseURL + " is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := migrateUp(db, "test", testLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	runContract(t, func(t *testing.T) SubscriptionRepository {
+		_, err := db.Exec(`
+			TRUNCATE subscriptions, subscription_audit, subscription_prices, subscription_pauses,
+				subscription_members, exchange_rates, users, services, service_aliases, budgets
+			RESTART IDENTITY CASCADE
+		`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return NewSubsriptionRepo(db, 5*time.Second, testLogger())
+	})
+}
+
+func runContract(t *testing.T, newRepo repoFactory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo SubscriptionRepository)
+	}{
+		{"SubscriptionLifecycle", testSubscriptionLifecycle},
+		{"ScheduledPrices", testScheduledPrices},
+		{"ListFilters", testListFilters},
+		{"ListByServiceName", testListByServiceName},
+		{"ServiceOrder", testServiceOrder},
+		{"Spend", testSpend},
+		{"Renewals", testRenewals},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+func mustInsertUser(t *testing.T, repo SubscriptionRepository) string {
+	t.Helper()
+
+	id, err := repo.InsertUser(context.Background(), &structures.User{Name: "test"})
+	if err != nil {
+		t.Fatalf("InsertUser: %v", err)
+	}
+
+	return id
+}
+
+func mustInsertSub(t *testing.T, repo SubscriptionRepository, subscription structures.Subscription) structures.Subscription {
+	t.Helper()
+
+	if subscription.Currency == "" {
+		subscription.Currency = structures.DefaultCurrency
+	}
+	if subscription.BillingPeriod == "" {
+		subscription.BillingPeriod = structures.BillingMonthly
+	}
+	if subscription.Status == "" {
+		subscription.Status = structures.StatusActive
+	}
+	if subscription.StartDate == "" {
+		subscription.StartDate = "01-2025"
+	}
+
+	if _, err := repo.InsertSub(context.Background(), &subscription); err != nil {
+		t.Fatalf("InsertSub %q: %v", subscription.ServiceName, err)
+	}
+
+	return subscription
+}
+
+func testSubscriptionLifecycle(t *testing.T, repo SubscriptionRepository) {
+	ctx := context.Background()
+	userID := mustInsertUser(t, repo)
+
+	inserted := mustInsertSub(t, repo, structures.Subscription{
+		ServiceName: "Netflix",
+		Price:       59900,
+		UserID:      userID,
+		Tags:        []string{"video"},
+	})
+	if inserted.ID == 0 || inserted.Version != 1 {
+		t.Fatalf("InsertSub set id %d and version %d", inserted.ID, inserted.Version)
+	}
+
+	stored, err := repo.SelectSubById(ctx, inserted.ID, false)
+	if err != nil {
+		t.Fatalf("SelectSubById: %v", err)
+	}
+	if stored.ServiceName != "Netflix" || stored.Price != 59900 || stored.UserID != userID || stored.StartDate != "01-2025" {
+		t.Fatalf("SelectSubById = %+v", stored)
+	}
+
+	stored.Price = 69900
+	if err := repo.UpdateSub(ctx, &stored, stored.ID, []int{stored.Version + 1}); !errors.Is(err, structures.ErrPreconditionFailed) {
+		t.Fatalf("UpdateSub with a stale version: got %v, want ErrPreconditionFailed", err)
+	}
+	if err := repo.UpdateSub(ctx, &stored, stored.ID, []int{stored.Version}); err != nil {
+		t.Fatalf("UpdateSub: %v", err)
+	}
+
+	updated, err := repo.SelectSubById(ctx, stored.ID, false)
+	if err != nil {
+		t.Fatalf("SelectSubById: %v", err)
+	}
+	if updated.Price != 69900 || updated.Version != 2 {
+		t.Fatalf("after UpdateSub price %d version %d, want 69900 and 2", updated.Price, updated.Version)
+	}
+
+	if err := repo.DeleteSub(ctx, stored.ID, nil); err != nil {
+		t.Fatalf("DeleteSub: %v", err)
+	}
+	if _, err := repo.SelectSubById(ctx, stored.ID, false); !errors.Is(err, structures.ErrNotFound) {
+		t.Fatalf("SelectSubById of a deleted sub: got %v, want ErrNotFound", err)
+	}
+	if err := repo.UpdateSub(ctx, &stored, stored.ID, nil); !errors.Is(err, structures.ErrNotFound) {
+		t.Fatalf("UpdateSub of a deleted sub: got %v, want ErrNotFound", err)
+	}
+
+	deleted, err := repo.SelectSubById(ctx, stored.ID, true)
+	if err != nil || deleted.DeletedAt == nil {
+		t.Fatalf("SelectSubById including deleted = %+v, %v", deleted, err)
+	}
+
+	if err := repo.RestoreSub(ctx, stored.ID, nil); err != nil {
+		t.Fatalf("RestoreSub: %v", err)
+	}
+	if _, err := repo.SelectSubById(ctx, stored.ID, false); err != nil {
+		t.Fatalf("SelectSubById after RestoreSub: %v", err)
+	}
+
+	history, err := repo.SelectHistory(ctx, stored.ID)
+	if err != nil {
+		t.Fatalf("SelectHistory: %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("SelectHistory returned %d entries, want create, update, delete and restore", len(history))
+	}
+}
+
+func testScheduledPrices(t *testing.T, repo SubscriptionRepository) {
+	ctx := context.Background()
+	userID := mustInsertUser(t, repo)
+	inserted := mustInsertSub(t, repo, structures.Subscription{ServiceName: "Netflix", Price: 59900, UserID: userID})
+
+	for _, change := range []structures.PriceChange{
+		{EffectiveFrom: "09-2025", Price: 79900},
+		{EffectiveFrom: "03-2025", Price: 69900},
+		{EffectiveFrom: "09-2025", Price: 89900},
+	} {
+		if err := repo.SchedulePrice(ctx, inserted.ID, &change, nil); err != nil {
+			t.Fatalf("SchedulePrice %s: %v", change.EffectiveFrom, err)
+		}
+	}
+
+	if err := repo.DeletePrice(ctx, inserted.ID, "03-2025", []int{1}); !errors.Is(err, structures.ErrPreconditionFailed) {
+		t.Fatalf("DeletePrice with a stale version: got %v, want ErrPreconditionFailed", err)
+	}
+	if err := repo.DeletePrice(ctx, inserted.ID, "03-2025", []int{4}); err != nil {
+		t.Fatalf("DeletePrice: %v", err)
+	}
+	if err := repo.DeletePrice(ctx, inserted.ID, "03-2025", nil); !errors.Is(err, structures.ErrNotFound) {
+		t.Fatalf("DeletePrice of a deleted change: got %v, want ErrNotFound", err)
+	}
+
+	stored, err := repo.SelectSubById(ctx, inserted.ID, false)
+	if err != nil {
+		t.Fatalf("SelectSubById: %v", err)
+	}
+
+	want := []structures.PriceChange{{EffectiveFrom: "09-2025", Price: 89900}}
+	if !slices.Equal(stored.Prices, want) || stored.Version != 5 {
+		t.Fatalf("got prices %v at version %d, want %v at version 5", stored.Prices, stored.Version, want)
+	}
+}
+
+func testListFilters(t *testing.T, repo SubscriptionRepository) {
+	ctx := context.Background()
+	alice := mustInsertUser(t, repo)
+	bob := mustInsertUser(t, repo)
+
+	mustInsertSub(t, repo, structures.Subscription{ServiceName: "Spotify", Price: 16900, UserID: alice, Tags: []string{"music"}})
+	mustInsertSub(t, repo, structures.Subscription{ServiceName: "Netflix", Price: 59900, UserID: alice, EndDate: "03-2025"})
+	mustInsertSub(t, repo, structures.Subscription{ServiceName: "Spotify", Price: 16900, UserID: bob, StartDate: "06-2025"})
+	mustInsertSub(t, repo, structures.Subscription{ServiceName: "Netflix JP", Price: 450, Currency: "JPY", UserID: bob})
+	deleted := mustInsertSub(t, repo, structures.Subscription{ServiceName: "Okko", Price: 39900, UserID: bob})
+	if err := repo.DeleteSub(ctx, deleted.ID, nil); err != nil {
+		t.Fatalf("DeleteSub: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter structures.SubscriptionFilter
+		want   int
+	}{
+		{"everything not deleted", structures.SubscriptionFilter{}, 4},
+		{"including deleted", structures.SubscriptionFilter{IncludeDeleted: true}, 5},
+		{"user", structures.SubscriptionFilter{UserID: alice}, 2},
+		{"service name", structures.SubscriptionFilter{ServiceName: "Spotify"}, 2},
+		{"tag", structures.SubscriptionFilter{Tag: "music"}, 1},
+		{"active at", structures.SubscriptionFilter{ActiveAt: "04-2025"}, 2},
+		{"price range across exponents", structures.SubscriptionFilter{MinPrice: "200", MaxPrice: "599.00"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Limit = 10
+			page, err := repo.SelectAllSubs(ctx, &tt.filter)
+			if err != nil {
+				t.Fatalf("SelectAllSubs: %v", err)
+			}
+			if page.Total != tt.want || len(page.Subscriptions) != tt.want {
+				t.Fatalf("got total %d and %d subscriptions, want %d", page.Total, len(page.Subscriptions), tt.want)
+			}
+		})
+	}
+
+	// 450 yen sort between 169.00 and 599.00 rubles.
+	page, err := repo.SelectAllSubs(ctx, &structures.SubscriptionFilter{Sort: structures.SortByPrice, Limit: 10})
+	if err != nil {
+		t.Fatalf("SelectAllSubs: %v", err)
+	}
+
+	var names []string
+	for _, subscription := range page.Subscriptions {
+		names = append(names, subscription.ServiceName)
+	}
+	if want := []string{"Spotify", "Spotify", "Netflix JP", "Netflix"}; !slices.Equal(names, want) {
+		t.Fatalf("sorted by price got %v, want %v", names, want)
+	}
+}
+
+// testListByServiceName checks that both storages order names byte by byte,
+// so a cursor from one page continues at the same place everywhere.
+func testListByServiceName(t *testing.T, repo SubscriptionRepository) {
+	ctx := context.Background()
+	userID := mustInsertUser(t, repo)
+
+	names := []string{"b", "B", "apple", "Zoom", "Яндекс Плюс", "Ärzte", "a", "b", "Ёлка", "_x"}
+	var inserted []structures.Subscription
+	for _, name := range names {
+		inserted = append(inserted, mustInsertSub(t, repo, structures.Subscription{
+			ServiceName: name,
+			Price:       100,
+			UserID:      userID,
+		}))
+	}
+
+	byName := func(a, b structures.Subscription) int {
+		return cmp.Or(strings.Compare(a.ServiceName, b.ServiceName), cmp.Compare(a.ID, b.ID))
+	}
+
+	for _, sort := range []string{structures.SortByServiceName, "-" + structures.SortByServiceName} {
+		t.Run(sort, func(t *testing.T) {
+			want := slices.Clone(inserted)
+			slices.SortFunc(want, byName)
+			if strings.HasPrefix(sort, "-") {
+				slices.Reverse(want)
+			}
+
+			filter := structures.SubscriptionFilter{Sort: sort, Limit: 3}
+			var got []structures.Subscription
+			for range len(names) {
+				page, err := repo.SelectAllSubs(ctx, &filter)
+				if err != nil {
+					t.Fatalf("SelectAllSubs: %v", err)
+				}
+				got = append(got, page.Subscriptions...)
+
+				if page.NextCursor == "" {
+					break
+				}
+				filter.Cursor = page.NextCursor
+			}
+
+			if !slices.EqualFunc(got, want, func(a, b structures.Subscription) bool { return a.ID == b.ID }) {
+				t.Fatalf("got order %v, want %v", serviceNames(got), serviceNames(want))
+			}
+		})
+	}
+}
+
+func serviceNames(subscriptions []structures.Subscription) []string {
+	names := make([]string, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		names = append(names, subscription.ServiceName)
+	}
+
+	return names
+}
+
+func testServiceOrder(t *testing.T, repo SubscriptionRepository) {
+	ctx := context.Background()
+
+	for _, service := range []structures.Service{
+		{Name: "okko", Aliases: []string{"Окко", "Okko TV"}},
+		{Name: "Кинопоиск"},
+		{Name: "Apple TV", Aliases: []string{"apple tv+", "Apple TV Plus"}},
+		{Name: "Netflix"},
+	} {
+		service.Currency = structures.DefaultCurrency
+		if _, err := repo.InsertService(ctx, &service); err != nil {
+			t.Fatalf("InsertService %q: %v", service.Name, err)
+		}
+	}
+
+	services, err := repo.SelectServices(ctx)
+	if err != nil {
+		t.Fatalf("SelectServices: %v", err)
+	}
+
+	var got []string
+	for _, service := range services {
+		got = append(got, service.Name)
+		if !slices.IsSorted(service.Aliases) {
+			t.Errorf("aliases of %q are not sorted: %v", service.Name, service.Aliases)
+		}
+	}
+
+	want := []string{"Apple TV", "Netflix", "okko", "Кинопоиск"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("SelectServices order %v, want %v", got, want)
+	}
+}
+
+// testSpend checks that both storages bill, convert and split the same way,
+// see structures.Subscription, and group the spend alike.
+func testSpend(t *testing.T, repo SubscriptionRepository) {
+	ctx := context.Background()
+	alice := mustInsertUser(t, repo)
+	bob := mustInsertUser(t, repo)
+	carol := mustInsertUser(t, repo)
+
+	// 300.00 and 400.00 from March: carol pays 50.00, bob twice what alice
+	// pays of the rest.
+	netflix := mustInsertSub(t, repo, structures.Subscription{ServiceName: "Netflix", Price: 30000, Category: "video", UserID: alice})
+	if err := repo.SchedulePrice(ctx, netflix.ID, &structures.PriceChange{EffectiveFrom: "03-2025", Price: 40000}, nil); err != nil {
+		t.Fatalf("SchedulePrice: %v", err)
+	}
+	members := []structures.Member{{UserID: bob, Weight: 2}, {UserID: carol, Amount: 5000}}
+	if err := repo.SetMembers(ctx, netflix.ID, members, nil); err != nil {
+		t.Fatalf("SetMembers: %v", err)
+	}
+
+	// A third of 10.00 a month from February at 90 and then 100 rubles.
+	mustInsertSub(t, repo, structures.Subscription{
+		ServiceName:   "Spotify",
+		Price:         1000,
+		Currency:      "USD",
+		Category:      "music",
+		BillingPeriod: structures.BillingQuarterly,
+		UserID:        bob,
+		StartDate:     "02-2025",
+	})
+	if err := repo.UpsertRates(ctx, []structures.ExchangeRate{
+		{Month: "01-2025", From: "USD", To: "RUB", Rate: "90"},
+		{Month: "03-2025", From: "USD", To: "RUB", Rate: "100"},
+	}); err != nil {
+		t.Fatalf("UpsertRates: %v", err)
+	}
+
+	// Billed in February and April only, after a free trial and a pause.
+	okko := mustInsertSub(t, repo, structures.Subscription{
+		ServiceName:  "Okko",
+		Price:        20000,
+		Category:     "video",
+		UserID:       carol,
+		TrialEndDate: "01-2025",
+	})
+	pause := structures.Lifecycle{Status: structures.StatusActive, Pauses: []structures.Pause{{From: "03-2025", To: "03-2025"}}}
+	if err := repo.SetLifecycle(ctx, okko.ID, &pause, nil); err != nil {
+		t.Fatalf("SetLifecycle: %v", err)
+	}
+
+	// 7.00 every Wednesday, 1 January 2025 is one.
+	gym := mustInsertSub(t, repo, structures.Subscription{
+		ServiceName:   "Gym",
+		Price:         700,
+		Category:      "sport",
+		BillingPeriod: structures.BillingWeekly,
+		UserID:        alice,
+	})
+
+	period := structures.Counting{StartDate: "01-2025", EndDate: "04-2025", TargetCurrency: "RUB", Mode: structures.CountAmortized}
+	with := func(change func(data *structures.Counting)) structures.Counting {
+		data := period
+		change(&data)
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		data    structures.Counting
+		query   structures.SpendQuery
+		want    []structures.SpendGroup
+		missing []structures.MissingRate
+	}{
+		{
+			name: "total",
+			data: period,
+			want: []structures.SpendGroup{{Total: 288570, Subscriptions: 4}},
+		},
+		{
+			name:  "by month",
+			data:  period,
+			query: structures.SpendQuery{ByMonth: true},
+			want: []structures.SpendGroup{
+				{Month: "01-2025", Total: 33100, Subscriptions: 2},
+				{Month: "02-2025", Total: 82770, Subscriptions: 4},
+				{Month: "03-2025", Total: 76400, Subscriptions: 3},
+				{Month: "04-2025", Total: 96300, Subscriptions: 4},
+			},
+		},
+		{
+			name:  "charges by month",
+			data:  with(func(data *structures.Counting) { data.Mode = structures.CountCharges }),
+			query: structures.SpendQuery{ByMonth: true},
+			want: []structures.SpendGroup{
+				{Month: "01-2025", Total: 33500, Subscriptions: 2},
+				{Month: "02-2025", Total: 142800, Subscriptions: 4},
+				{Month: "03-2025", Total: 42800, Subscriptions: 2},
+				{Month: "04-2025", Total: 63500, Subscriptions: 3},
+			},
+		},
+		{
+			name:  "top categories",
+			data:  period,
+			query: structures.SpendQuery{GroupBy: structures.GroupByCategory, Top: 2},
+			want: []structures.SpendGroup{
+				{Key: "video", Total: 180000, Subscriptions: 2},
+				{Key: "music", Total: 96570, Subscriptions: 1},
+			},
+		},
+		{
+			name:  "by user",
+			data:  period,
+			query: structures.SpendQuery{GroupBy: structures.GroupByUser},
+			want: []structures.SpendGroup{
+				{Key: bob, Total: 176570, Subscriptions: 2},
+				{Key: carol, Total: 60000, Subscriptions: 2},
+				{Key: alice, Total: 52000, Subscriptions: 2},
+			},
+		},
+		{
+			// 250.00 of Netflix split 1:2, the cent left goes to bob.
+			name:  "largest remainders",
+			data:  with(func(data *structures.Counting) { data.EndDate = "01-2025" }),
+			query: structures.SpendQuery{GroupBy: structures.GroupByUser},
+			want: []structures.SpendGroup{
+				{Key: bob, Total: 16667, Subscriptions: 1},
+				{Key: alice, Total: 11433, Subscriptions: 2},
+				{Key: carol, Total: 5000, Subscriptions: 1},
+			},
+		},
+		{
+			name: "share of a user",
+			data: with(func(data *structures.Counting) { data.UserID = bob }),
+			want: []structures.SpendGroup{{Total: 176570, Subscriptions: 2}},
+		},
+		{
+			name:  "debts",
+			data:  period,
+			query: structures.SpendQuery{GroupBy: structures.GroupByDebt},
+			want: []structures.SpendGroup{
+				{Key: bob, Owner: alice, Total: 80000, Subscriptions: 1, SubscriptionIDs: []int{netflix.ID}},
+				{Key: carol, Owner: alice, Total: 20000, Subscriptions: 1, SubscriptionIDs: []int{netflix.ID}},
+			},
+		},
+		{
+			name:  "debts of a user",
+			data:  with(func(data *structures.Counting) { data.UserID = carol }),
+			query: structures.SpendQuery{GroupBy: structures.GroupByDebt},
+			want: []structures.SpendGroup{
+				{Key: carol, Owner: alice, Total: 20000, Subscriptions: 1, SubscriptionIDs: []int{netflix.ID}},
+			},
+		},
+		{
+			name:  "by subscription",
+			data:  with(func(data *structures.Counting) { data.EndDate = "01-2025" }),
+			query: structures.SpendQuery{ByMonth: true, GroupBy: structures.GroupBySubscription},
+			want: []structures.SpendGroup{
+				{Month: "01-2025", SubscriptionID: netflix.ID, ServiceName: "Netflix", Owner: alice, Total: 30000, Subscriptions: 1},
+				{Month: "01-2025", SubscriptionID: gym.ID, ServiceName: "Gym", Owner: alice, Total: 3100, Subscriptions: 1},
+			},
+		},
+		{
+			name: "inverse rate",
+			data: with(func(data *structures.Counting) {
+				data.EndDate = "01-2025"
+				data.Category = "video"
+				data.TargetCurrency = "USD"
+			}),
+			want: []structures.SpendGroup{{Total: 333, Subscriptions: 1}},
+		},
+		{
+			name:    "missing rates",
+			data:    with(func(data *structures.Counting) { data.TargetCurrency = "EUR" }),
+			want:    []structures.SpendGroup{{Subscriptions: 4}},
+			missing: []structures.MissingRate{{Currency: "RUB", Month: "01-2025"}, {Currency: "USD", Month: "02-2025"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spend, err := repo.SelectSpend(ctx, &tt.data, &tt.query)
+			if err != nil {
+				t.Fatalf("SelectSpend: %v", err)
+			}
+			if !reflect.DeepEqual(spend.Groups, tt.want) {
+				t.Errorf("got groups %+v\nwant %+v", spend.Groups, tt.want)
+			}
+			if !slices.Equal(spend.MissingRates, tt.missing) {
+				t.Errorf("got missing rates %v, want %v", spend.MissingRates, tt.missing)
+			}
+		})
+	}
+
+	spend, err := repo.SelectSpend(ctx, &period, &structures.SpendQuery{})
+	if err != nil {
+		t.Fatalf("SelectSpend: %v", err)
+	}
+	want := []structures.AppliedRate{
+		{Month: "02-2025", From: "USD", To: "RUB", Rate: "90", RateMonth: "01-2025"},
+		{Month: "03-2025", From: "USD", To: "RUB", Rate: "100", RateMonth: "03-2025"},
+		{Month: "04-2025", From: "USD", To: "RUB", Rate: "100", RateMonth: "03-2025"},
+	}
+	if !slices.Equal(spend.Rates, want) {
+		t.Fatalf("got rates %v, want %v", spend.Rates, want)
+	}
+}
+
+func testRenewals(t *testing.T, repo SubscriptionRepository) {
+	ctx := context.Background()
+	alice := mustInsertUser(t, repo)
+	bob := mustInsertUser(t, repo)
+
+	// 7.00 every Wednesday, 1 January 2025 is one.
+	gym := mustInsertSub(t, repo, structures.Subscription{
+		ServiceName:   "Gym",
+		Price:         700,
+		BillingPeriod: structures.BillingWeekly,
+		UserID:        alice,
+	})
+	netflix := mustInsertSub(t, repo, structures.Subscription{ServiceName: "Netflix", Price: 30000, UserID: alice, StartDate: "02-2025"})
+	okko := mustInsertSub(t, repo, structures.Subscription{
+		ServiceName:  "Okko",
+		Price:        20000,
+		UserID:       bob,
+		TrialEndDate: "01-2025",
+		TrialPrice:   100,
+	})
+	deleted := mustInsertSub(t, repo, structures.Subscription{ServiceName: "Ivi", Price: 500, UserID: alice})
+	if err := repo.DeleteSub(ctx, deleted.ID, nil); err != nil {
+		t.Fatalf("DeleteSub: %v", err)
+	}
+
+	renewal := func(date string, subscription structures.Subscription, amount structures.Money) structures.Renewal {
+		return structures.Renewal{
+			SubscriptionID: subscription.ID,
+			ServiceName:    subscription.ServiceName,
+			UserID:         subscription.UserID,
+			Charge:         structures.Charge{Date: date, Amount: amount, Currency: structures.DefaultCurrency},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query structures.RenewalQuery
+		want  []structures.Renewal
+	}{
+		{
+			name:  "all",
+			query: structures.RenewalQuery{From: "2025-01-20", Until: "2025-02-12", Limit: 10},
+			want: []structures.Renewal{
+				renewal("2025-01-22", gym, 700),
+				renewal("2025-01-29", gym, 700),
+				renewal("2025-02-01", netflix, 30000),
+				renewal("2025-02-01", okko, 20000),
+				renewal("2025-02-05", gym, 700),
+				renewal("2025-02-12", gym, 700),
+			},
+		},
+		{
+			name:  "limit",
+			query: structures.RenewalQuery{From: "2025-01-20", Until: "2025-02-12", Limit: 3},
+			want: []structures.Renewal{
+				renewal("2025-01-22", gym, 700),
+				renewal("2025-01-29", gym, 700),
+				renewal("2025-02-01", netflix, 30000),
+			},
+		},
+		{
+			name:  "after",
+			query: structures.RenewalQuery{From: "2025-01-20", Until: "2025-02-12", AfterDate: "2025-02-01", AfterID: netflix.ID, Limit: 10},
+			want: []structures.Renewal{
+				renewal("2025-02-01", okko, 20000),
+				renewal("2025-02-05", gym, 700),
+				renewal("2025-02-12", gym, 700),
+			},
+		},
+		{
+			name:  "trial of a user",
+			query: structures.RenewalQuery{From: "2025-01-01", Until: "2025-02-28", UserID: bob, Limit: 10},
+			want: []structures.Renewal{
+				renewal("2025-01-01", okko, 100),
+				renewal("2025-02-01", okko, 20000),
+			},
+		},
+		{
+			name:  "none",
+			query: structures.RenewalQuery{From: "2024-11-01", Until: "2024-12-31", Limit: 10},
+			want:  []structures.Renewal{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renewals, err := repo.SelectRenewals(ctx, &tt.query)
+			if err != nil {
+				t.Fatalf("SelectRenewals: %v", err)
+			}
+			if !reflect.DeepEqual(renewals, tt.want) {
+				t.Errorf("SelectRenewals = %+v, want %+v", renewals, tt.want)
+			}
+		})
+	}
+}
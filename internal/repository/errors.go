@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/lib/pq"
+)
+
+// wrapError annotates err with op and the domain error it corresponds to,
+// so that callers can match it with errors.Is.
+func wrapError(op string, err error) error {
+	if kind := classifyError(err); kind != nil {
+		return fmt.Errorf("%s: %w: %w", op, kind, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func classifyError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return structures.ErrNotFound
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled):
+		return structures.ErrUnavailable
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "22":
+			// data exception: malformed uuid, date out of range, etc.
+			return structures.ErrValidation
+		case "23":
+			if pqErr.Code.Name() == "unique_violation" ||
+				pqErr.Code.Name() == "foreign_key_violation" ||
+				pqErr.Code.Name() == "exclusion_violation" {
+				return structures.ErrConflict
+			}
+			return structures.ErrValidation
+		case "08", "53", "57":
+			// connection exception, insufficient resources, operator intervention
+			return structures.ErrUnavailable
+		}
+		return nil
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return structures.ErrUnavailable
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+// UpsertRates stores rates in one transaction, replacing the rate of a pair
+// already stored for the same month.
+func (r *SubscriptionRepo) UpsertRates(ctx context.Context, rates []structures.ExchangeRate) error {
+	const op = "repository.subscriptionRepo.UpsertRates"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO exchange_rates (month, from_currency, to_currency, rate)
+		VALUES (to_date($1, 'MM-YYYY'), $2, $3, $4)
+		ON CONFLICT (month, from_currency, to_currency) DO UPDATE
+		SET rate = EXCLUDED.rate
+	`
+
+	for _, rate := range rates {
+		if _, err := tx.ExecContext(ctx, query, rate.Month, rate.From, rate.To, string(rate.Rate)); err != nil {
+			log.Error("Failed to upsert rate", sl.Err(err))
+			return wrapError(op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit rates", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	log.Info("Exchange rates stored", slog.Int("count", len(rates)))
+	return nil
+}
+
+// SelectRates returns the rates matching filter ordered by month and pair.
+func (r *SubscriptionRepo) SelectRates(ctx context.Context, filter *structures.ExchangeRateFilter) ([]structures.ExchangeRate, error) {
+	const op = "repository.subscriptionRepo.SelectRates"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var conditions []string
+
+	if filter.Currency != "" {
+		conditions = append(conditions, fmt.Sprintf("(from_currency = %[1]s OR to_currency = %[1]s)", arg(filter.Currency)))
+	}
+	if filter.From != "" {
+		conditions = append(conditions, "month >= to_date("+arg(filter.From)+", 'MM-YYYY')")
+	}
+	if filter.To != "" {
+		conditions = append(conditions, "month <= to_date("+arg(filter.To)+", 'MM-YYYY')")
+	}
+
+	query := `
+		SELECT to_char(month, 'MM-YYYY'), from_currency, to_currency, rate
+		FROM exchange_rates
+	` + whereClause(conditions) + `
+		ORDER BY exchange_rates.month, from_currency, to_currency
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error("Failed to select rates", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	rates := []structures.ExchangeRate{}
+
+	for rows.Next() {
+		var rate structures.ExchangeRate
+		var value string
+
+		if err := rows.Scan(&rate.Month, &rate.From, &rate.To, &value); err != nil {
+			log.Error("Failed to scan rate", sl.Err(err))
+			return nil, wrapError(op, err)
+		}
+
+		// numeric pads the rate with zeros to its scale.
+		if rate.Rate, err = structures.ParseRate(value); err != nil {
+			log.Error("Failed to parse rate", sl.Err(err))
+			return nil, wrapError(op, err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+// SetLifecycle replaces the status, end date, pauses and cancellation time
+// of the subscription with id. versions works like in UpdateSub.
+func (r *SubscriptionRepo) SetLifecycle(ctx context.Context, id int, lifecycle *structures.Lifecycle, versions []int) error {
+	const op = "repository.subscriptionsRepo.SetLifecycle"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockSub(ctx, tx, id)
+	if err != nil {
+		log.Error("Failed to lock sub", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := checkWritable(op, &before, versions); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM subscription_pauses WHERE subscription_id = $1`, id); err != nil {
+		log.Error("Failed to delete pauses", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	for _, pause := range lifecycle.Pauses {
+		query := `
+			INSERT INTO subscription_pauses (subscription_id, paused_from, paused_to)
+			VALUES ($1, to_date($2, 'MM-YYYY'), to_date(NULLIF($3, ''), 'MM-YYYY'))
+		`
+
+		if _, err := tx.ExecContext(ctx, query, id, pause.From, pause.To); err != nil {
+			log.Error("Failed to insert pause", sl.Err(err))
+			return wrapError(op, err)
+		}
+	}
+
+	query := `
+		UPDATE subscriptions
+		SET status = $1,
+			end_date = to_date(NULLIF($2, ''), 'MM-YYYY'),
+			cancelled_at = $3,
+			version = version + 1
+		WHERE id = $4
+		RETURNING ` + subscriptionColumns
+
+	var after structures.Subscription
+
+	err = scanSubscription(tx.QueryRowContext(ctx, query, lifecycle.Status, lifecycle.EndDate, lifecycle.CancelledAt, id), &after)
+	if err != nil {
+		log.Error("Failed to update lifecycle", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := insertAudit(ctx, tx, structures.AuditUpdate, &before, &after); err != nil {
+		log.Error("Failed to audit lifecycle change", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit lifecycle change", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	log.Info("Subscription status changed", slog.Int("id", id), slog.String("status", after.Status))
+	return nil
+}
+
+// AdvanceStatuses ends the trials and expires the subscriptions that are
+// over in month.
+func (r *SubscriptionRepo) AdvanceStatuses(ctx context.Context, month time.Time) (int, error) {
+	const op = "repository.subscriptionsRepo.AdvanceStatuses"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE deleted_at IS NULL
+		  AND status IN ('trial', 'active', 'paused')
+		  AND (end_date < $1 OR (status = 'trial' AND (trial_end_date IS NULL OR trial_end_date < $1)))
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	due, err := selectSubs(ctx, tx, query, month)
+	if err != nil {
+		log.Error("Failed to select subs", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	for i := range due {
+		before := &due[i]
+
+		query := `
+			UPDATE subscriptions
+			SET status = $1,
+				version = version + 1
+			WHERE id = $2
+			RETURNING ` + subscriptionColumns
+
+		var after structures.Subscription
+
+		if err := scanSubscription(tx.QueryRowContext(ctx, query, before.StatusIn(month), before.ID), &after); err != nil {
+			log.Error("Failed to update status", sl.Err(err))
+			return 0, wrapError(op, err)
+		}
+
+		if err := insertAudit(ctx, tx, structures.AuditUpdate, before, &after); err != nil {
+			log.Error("Failed to audit status change", sl.Err(err))
+			return 0, wrapError(op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit status changes", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	return len(due), nil
+}
+
+// selectSubs returns the subscriptions selected by query within tx.
+func selectSubs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]structures.Subscription, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var subscriptions []structures.Subscription
+
+	for rows.Next() {
+		var subscription structures.Subscription
+
+		if err := scanSubscription(rows, &subscription); err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+// SetMembers replaces the members of the subscription with id. versions
+// works like in UpdateSub.
+func (r *SubscriptionRepo) SetMembers(ctx context.Context, id int, members []structures.Member, versions []int) error {
+	const op = "repository.subscriptionsRepo.SetMembers"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockSub(ctx, tx, id)
+	if err != nil {
+		log.Error("Failed to lock sub", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := checkWritable(op, &before, versions); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM subscription_members WHERE subscription_id = $1`, id); err != nil {
+		log.Error("Failed to delete members", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	for _, member := range members {
+		query := `
+			INSERT INTO subscription_members (subscription_id, user_id, weight, amount)
+			VALUES ($1, $2, $3, $4)
+		`
+
+		if _, err := tx.ExecContext(ctx, query, id, member.UserID, member.Weight, member.Amount); err != nil {
+			log.Error("Failed to insert member", sl.Err(err))
+			return wrapError(op, err)
+		}
+	}
+
+	query := `
+		UPDATE subscriptions
+		SET version = version + 1
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	var after structures.Subscription
+
+	if err := scanSubscription(tx.QueryRowContext(ctx, query, id), &after); err != nil {
+		log.Error("Failed to bump sub version", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := insertAudit(ctx, tx, structures.AuditUpdate, &before, &after); err != nil {
+		log.Error("Failed to audit members change", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit members change", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	log.Info("Subscription members set", slog.Int("id", id), slog.Int("members", len(members)))
+	return nil
+}
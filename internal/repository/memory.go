@@ -0,0 +1,658 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+	"github.com/google/uuid"
+)
+
+// MemorySubscriptionRepo keeps subscriptions in process memory. It follows the
+// semantics of SubscriptionRepo and is meant for tests and local development.
+// The methods of every domain interface of SubscriptionRepository are in a
+// memory*Repo.go file of their own, the subscriptions are here.
+type MemorySubscriptionRepo struct {
+	mu            sync.RWMutex
+	subscriptions map[int]structures.Subscription
+	nextID        int
+	audit         []structures.AuditEntry
+	rates         map[exchangeRateKey]structures.ExchangeRate
+	users         map[string]structures.User
+	services      map[int]structures.Service
+	serviceKeys   map[string]int
+	nextServiceID int
+	budgets       map[int]structures.Budget
+	nextBudgetID  int
+	log           *slog.Logger
+}
+
+type exchangeRateKey struct {
+	month, from, to string
+}
+
+func NewMemorySubscriptionRepo(log *slog.Logger) *MemorySubscriptionRepo {
+	return &MemorySubscriptionRepo{
+		subscriptions: make(map[int]structures.Subscription),
+		rates:         make(map[exchangeRateKey]structures.ExchangeRate),
+		users:         make(map[string]structures.User),
+		services:      make(map[int]structures.Service),
+		serviceKeys:   make(map[string]int),
+		budgets:       make(map[int]structures.Budget),
+		nextID:        1,
+		nextServiceID: 1,
+		nextBudgetID:  1,
+		log:           log,
+	}
+}
+
+func (r *MemorySubscriptionRepo) InsertSub(ctx context.Context, subscription *structures.Subscription) (int, error) {
+	const op = "repository.memorySubscriptionRepo.InsertSub"
+	log := r.log.With("op", op)
+
+	stored, err := normalizeSubscription(subscription)
+	if err == nil {
+		err = checkStatus(subscription.Status)
+	}
+	if err != nil {
+		log.Error("Failed to insert sub", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err = r.checkUser(op, stored.UserID)
+	if err == nil {
+		err = r.checkService(op, stored.ServiceID)
+	}
+	if err != nil {
+		log.Error("Failed to insert sub", sl.Err(err))
+		return 0, err
+	}
+
+	stored.ID = r.nextID
+	stored.Version = 1
+	stored.DeletedAt = nil
+	stored.Prices = nil
+	stored.Pauses = nil
+	stored.CancelledAt = nil
+	stored.Members = nil
+	r.nextID++
+	r.subscriptions[stored.ID] = stored
+	r.record(ctx, structures.AuditCreate, nil, &stored)
+
+	subscription.ID = stored.ID
+	subscription.Version = stored.Version
+
+	log.Debug("Inserted successfully", slog.Int("id", stored.ID))
+	return stored.ID, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectAllSubs(ctx context.Context, filter *structures.SubscriptionFilter) (structures.SubscriptionPage, error) {
+	const op = "repository.memorySubscriptionRepo.SelectAllSubs"
+
+	page := structures.SubscriptionPage{Subscriptions: []structures.Subscription{}}
+
+	sortField, desc := filter.SortField()
+	column, ok := sortColumns[sortField]
+	if !ok {
+		return page, fmt.Errorf("%s: %w", op, structures.NewFieldError("sort", "unknown sort field"))
+	}
+
+	var activeAt time.Time
+	if filter.ActiveAt != "" {
+		month, err := structures.ParseMonth(filter.ActiveAt)
+		if err != nil {
+			return page, fmt.Errorf("%s: %w", op, structures.NewFieldError("active_at", "must be a month in MM-YYYY format"))
+		}
+		activeAt = month
+	}
+
+	minPrice, maxPrice, err := priceBounds(filter)
+	if err != nil {
+		return page, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.mu.RLock()
+	var matched []structures.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.DeletedAt != nil && !filter.IncludeDeleted {
+			continue
+		}
+		if filter.UserID != "" && !strings.EqualFold(subscription.UserID, filter.UserID) {
+			continue
+		}
+		if filter.ServiceName != "" && subscription.ServiceName != filter.ServiceName {
+			continue
+		}
+		if filter.ServiceID != 0 && subscription.ServiceID != filter.ServiceID {
+			continue
+		}
+		if filter.Category != "" && subscription.Category != filter.Category {
+			continue
+		}
+		if filter.Tag != "" && !slices.Contains(subscription.Tags, filter.Tag) {
+			continue
+		}
+		if !activeAt.IsZero() && !subscription.IsActiveIn(activeAt) {
+			continue
+		}
+		if minPrice != nil && subscription.ScaledPrice() < *minPrice {
+			continue
+		}
+		if maxPrice != nil && subscription.ScaledPrice() > *maxPrice {
+			continue
+		}
+		if filter.Status != "" && subscription.Status != filter.Status {
+			continue
+		}
+		matched = append(matched, subscription)
+	}
+	r.mu.RUnlock()
+
+	page.Total = len(matched)
+
+	// order reports how a subscription compares to (value, id) in the requested direction.
+	order := func(subscription *structures.Subscription, value string, id int) int {
+		result := compareSortKey(sortField, subscription, value, id)
+		if desc {
+			return -result
+		}
+		return result
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return order(&matched[i], column.value(&matched[j]), matched[j].ID) < 0
+	})
+
+	if filter.Cursor != "" {
+		cursor, err := structures.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return page, fmt.Errorf("%s: %w", op, structures.NewFieldError("cursor", "is malformed"))
+		}
+
+		start := sort.Search(len(matched), func(i int) bool {
+			return order(&matched[i], cursor.Value, cursor.ID) > 0
+		})
+		matched = matched[start:]
+	}
+
+	if filter.Offset >= len(matched) {
+		return page, nil
+	}
+	matched = matched[filter.Offset:]
+
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		last := matched[filter.Limit-1]
+		page.NextCursor = structures.EncodeCursor(structures.PageCursor{
+			Sort:  filter.Sort,
+			Value: column.value(&last),
+			ID:    last.ID,
+		})
+		matched = matched[:filter.Limit]
+	}
+
+	page.Subscriptions = append(page.Subscriptions, matched...)
+	return page, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectSubById(ctx context.Context, id int, includeDeleted bool) (structures.Subscription, error) {
+	const op = "repository.memorySubscriptionRepo.SelectSubById"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscription, ok := r.subscriptions[id]
+	if !ok || (subscription.DeletedAt != nil && !includeDeleted) {
+		return subscription, fmt.Errorf("%s: %w: no subs with id:%d", op, structures.ErrNotFound, id)
+	}
+
+	return subscription, nil
+}
+
+func (r *MemorySubscriptionRepo) UpdateSub(ctx context.Context, subscription *structures.Subscription, id int, versions []int) error {
+	const op = "repository.memorySubscriptionRepo.UpdateSub"
+	log := r.log.With("op", op)
+
+	stored, err := normalizeSubscription(subscription)
+	if err != nil {
+		log.Error("Failed to update sub", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.checkVersion(op, id, versions)
+	if err != nil {
+		return err
+	}
+
+	err = r.checkUser(op, stored.UserID)
+	if err == nil {
+		err = r.checkService(op, stored.ServiceID)
+	}
+	if err != nil {
+		log.Error("Failed to update sub", sl.Err(err))
+		return err
+	}
+
+	stored.ID = id
+	stored.Version = current.Version + 1
+	stored.DeletedAt = nil
+	stored.Prices = current.Prices
+	stored.Status = current.Status
+	stored.Pauses = current.Pauses
+	stored.CancelledAt = current.CancelledAt
+	stored.Members = current.Members
+	r.subscriptions[id] = stored
+	r.record(ctx, structures.AuditUpdate, &current, &stored)
+	subscription.Version = stored.Version
+
+	log.Info("Subscription updated", slog.Int("id", id), slog.Int("version", stored.Version))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) DeleteSub(ctx context.Context, id int, versions []int) error {
+	const op = "repository.memorySubscriptionRepo.DeleteSub"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.checkVersion(op, id, versions)
+	if err != nil {
+		return err
+	}
+
+	deleted := current
+	deletedAt := time.Now()
+	deleted.DeletedAt = &deletedAt
+	deleted.Version++
+	r.subscriptions[id] = deleted
+	r.record(ctx, structures.AuditDelete, &current, &deleted)
+
+	log.Info("Subscription deleted", slog.Int("id", id))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) RestoreSub(ctx context.Context, id int, versions []int) error {
+	const op = "repository.memorySubscriptionRepo.RestoreSub"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.subscriptions[id]
+	switch {
+	case !ok:
+		return fmt.Errorf("%s: %w: no subs with id:%d", op, structures.ErrNotFound, id)
+	case current.DeletedAt == nil:
+		return fmt.Errorf("%s: %w: sub %d is not deleted", op, structures.ErrConflict, id)
+	case !versionMatches(versions, current.Version):
+		return fmt.Errorf("%s: %w: sub %d was modified", op, structures.ErrPreconditionFailed, id)
+	}
+
+	restored := current
+	restored.DeletedAt = nil
+	restored.Version++
+	r.subscriptions[id] = restored
+	r.record(ctx, structures.AuditRestore, &current, &restored)
+
+	log.Info("Subscription restored", slog.Int("id", id))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for id, subscription := range r.subscriptions {
+		if subscription.DeletedAt != nil && subscription.DeletedAt.Before(before) {
+			delete(r.subscriptions, id)
+			r.record(ctx, structures.AuditPurge, &subscription, nil)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (r *MemorySubscriptionRepo) SchedulePrice(ctx context.Context, id int, change *structures.PriceChange, versions []int) error {
+	const op = "repository.memorySubscriptionRepo.SchedulePrice"
+	log := r.log.With("op", op)
+
+	effective, err := structures.ParseMonth(change.EffectiveFrom)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, structures.NewFieldError("effective_from", "must be a month in MM-YYYY format"))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.checkVersion(op, id, versions)
+	if err != nil {
+		return err
+	}
+
+	updated := current
+	updated.Prices = nil
+	for _, scheduled := range current.Prices {
+		if month, _ := structures.ParseMonth(scheduled.EffectiveFrom); !month.Equal(effective) {
+			updated.Prices = append(updated.Prices, scheduled)
+		}
+	}
+	updated.Prices = append(updated.Prices, *change)
+	sort.Slice(updated.Prices, func(i, j int) bool {
+		a, _ := structures.ParseMonth(updated.Prices[i].EffectiveFrom)
+		b, _ := structures.ParseMonth(updated.Prices[j].EffectiveFrom)
+		return a.Before(b)
+	})
+	updated.Version++
+
+	r.subscriptions[id] = updated
+	r.record(ctx, structures.AuditUpdate, &current, &updated)
+
+	log.Info("Price change scheduled", slog.Int("id", id), slog.String("effective_from", change.EffectiveFrom))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) DeletePrice(ctx context.Context, id int, effectiveFrom string, versions []int) error {
+	const op = "repository.memorySubscriptionRepo.DeletePrice"
+	log := r.log.With("op", op)
+
+	effective, err := structures.ParseMonth(effectiveFrom)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, structures.NewFieldError("effective_from", "must be a month in MM-YYYY format"))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.checkVersion(op, id, versions)
+	if err != nil {
+		return err
+	}
+
+	updated := current
+	updated.Prices = slices.DeleteFunc(slices.Clone(current.Prices), func(change structures.PriceChange) bool {
+		month, _ := structures.ParseMonth(change.EffectiveFrom)
+		return month.Equal(effective)
+	})
+	if len(updated.Prices) == len(current.Prices) {
+		return fmt.Errorf("%s: %w: no price change for %s", op, structures.ErrNotFound, effectiveFrom)
+	}
+	if len(updated.Prices) == 0 {
+		updated.Prices = nil
+	}
+	updated.Version++
+
+	r.subscriptions[id] = updated
+	r.record(ctx, structures.AuditUpdate, &current, &updated)
+
+	log.Info("Price change deleted", slog.Int("id", id), slog.String("effective_from", effectiveFrom))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) SetMembers(ctx context.Context, id int, members []structures.Member, versions []int) error {
+	const op = "repository.memorySubscriptionRepo.SetMembers"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.checkVersion(op, id, versions)
+	if err != nil {
+		return err
+	}
+
+	updated := current
+	updated.Members = nil
+	for _, member := range members {
+		userID, err := uuid.Parse(member.UserID)
+		if err != nil {
+			return fmt.Errorf("%s: %w: invalid member user_id: %v", op, structures.ErrValidation, err)
+		}
+		member.UserID = userID.String()
+
+		if member.Weight < 0 || member.Amount < 0 || (member.Weight > 0 && member.Amount > 0) {
+			return fmt.Errorf("%s: %w: invalid share of member %s", op, structures.ErrValidation, member.UserID)
+		}
+		duplicate := slices.ContainsFunc(updated.Members, func(other structures.Member) bool {
+			return other.UserID == member.UserID
+		})
+		if duplicate {
+			return fmt.Errorf("%s: %w: duplicate member %s", op, structures.ErrConflict, member.UserID)
+		}
+		if err := r.checkUser(op, member.UserID); err != nil {
+			return err
+		}
+
+		updated.Members = append(updated.Members, member)
+	}
+	slices.SortFunc(updated.Members, func(a, b structures.Member) int {
+		return strings.Compare(a.UserID, b.UserID)
+	})
+	updated.Version++
+
+	r.subscriptions[id] = updated
+	r.record(ctx, structures.AuditUpdate, &current, &updated)
+
+	log.Info("Subscription members set", slog.Int("id", id), slog.Int("members", len(members)))
+	return nil
+}
+
+// isPayer reports whether userID owns subscription or is one of its members.
+func isPayer(subscription *structures.Subscription, userID string) bool {
+	if strings.EqualFold(subscription.UserID, userID) {
+		return true
+	}
+
+	return slices.ContainsFunc(subscription.Members, func(member structures.Member) bool {
+		return strings.EqualFold(member.UserID, userID)
+	})
+}
+
+func (r *MemorySubscriptionRepo) SetLifecycle(ctx context.Context, id int, lifecycle *structures.Lifecycle, versions []int) error {
+	const op = "repository.memorySubscriptionRepo.SetLifecycle"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.checkVersion(op, id, versions)
+	if err != nil {
+		return err
+	}
+
+	updated := current
+	updated.Status = lifecycle.Status
+	updated.EndDate = lifecycle.EndDate
+	updated.Pauses = slices.Clone(lifecycle.Pauses)
+	updated.CancelledAt = lifecycle.CancelledAt
+	updated.Version++
+
+	_, err = normalizeSubscription(&updated)
+	if err == nil {
+		err = checkStatus(updated.Status)
+	}
+	if err != nil {
+		log.Error("Failed to update lifecycle", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.subscriptions[id] = updated
+	r.record(ctx, structures.AuditUpdate, &current, &updated)
+
+	log.Info("Subscription status changed", slog.Int("id", id), slog.String("status", updated.Status))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) AdvanceStatuses(ctx context.Context, month time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]int, 0, len(r.subscriptions))
+	for id := range r.subscriptions {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	advanced := 0
+	for _, id := range ids {
+		current := r.subscriptions[id]
+		if current.DeletedAt != nil {
+			continue
+		}
+
+		status := current.StatusIn(month)
+		if status == current.Status {
+			continue
+		}
+
+		updated := current
+		updated.Status = status
+		updated.Version++
+		r.subscriptions[id] = updated
+		r.record(ctx, structures.AuditUpdate, &current, &updated)
+		advanced++
+	}
+
+	return advanced, nil
+}
+
+// checkUser mirrors the user_id foreign key. r.mu must be held.
+func (r *MemorySubscriptionRepo) checkUser(op, userID string) error {
+	if _, ok := r.users[userID]; !ok {
+		return fmt.Errorf("%s: %w: no users with id:%s", op, structures.ErrConflict, userID)
+	}
+
+	return nil
+}
+
+// checkVersion returns the stored subscription if it exists, is not deleted and
+// its version is one of versions, an empty list accepting any version. r.mu must be held.
+func (r *MemorySubscriptionRepo) checkVersion(op string, id int, versions []int) (structures.Subscription, error) {
+	current, ok := r.subscriptions[id]
+	if !ok {
+		return current, fmt.Errorf("%s: %w: no subs with id:%d", op, structures.ErrNotFound, id)
+	}
+
+	return current, checkWritable(op, &current, versions)
+}
+
+// normalizeSubscription rejects values Postgres would refuse to store and
+// returns the subscription as Postgres would return it.
+func normalizeSubscription(subscription *structures.Subscription) (structures.Subscription, error) {
+	stored := *subscription
+
+	userID, err := uuid.Parse(subscription.UserID)
+	if err != nil {
+		return stored, fmt.Errorf("%w: invalid user_id: %v", structures.ErrValidation, err)
+	}
+	stored.UserID = userID.String()
+
+	stored.Tags = slices.Clone(subscription.Tags)
+	if len(stored.Tags) == 0 {
+		stored.Tags = nil
+	}
+
+	if !structures.IsCurrencyCode(subscription.Currency) {
+		return stored, fmt.Errorf("%w: invalid currency: %q", structures.ErrValidation, subscription.Currency)
+	}
+
+	validPeriod := false
+	switch subscription.BillingPeriod {
+	case structures.BillingWeekly, structures.BillingMonthly, structures.BillingQuarterly, structures.BillingYearly:
+		validPeriod = subscription.BillingMonths == 0
+	case structures.BillingCustom:
+		validPeriod = subscription.BillingMonths > 0
+	}
+	if !validPeriod {
+		return stored, fmt.Errorf("%w: invalid billing period: %q", structures.ErrValidation, subscription.BillingPeriod)
+	}
+
+	if subscription.TrialEndDate != "" {
+		if _, err := structures.ParseMonth(subscription.TrialEndDate); err != nil {
+			return stored, fmt.Errorf("%w: invalid trial_end_date: %v", structures.ErrValidation, err)
+		}
+	}
+
+	for _, pause := range subscription.Pauses {
+		if _, err := structures.ParseMonth(pause.From); err != nil {
+			return stored, fmt.Errorf("%w: invalid pause: %v", structures.ErrValidation, err)
+		}
+	}
+
+	if _, err := structures.ParseMonth(subscription.StartDate); err != nil {
+		return stored, fmt.Errorf("%w: invalid start_date: %v", structures.ErrValidation, err)
+	}
+
+	if subscription.EndDate != "" {
+		if _, err := structures.ParseMonth(subscription.EndDate); err != nil {
+			return stored, fmt.Errorf("%w: invalid end_date: %v", structures.ErrValidation, err)
+		}
+	}
+
+	return stored, nil
+}
+
+// checkStatus mirrors the status check constraint, UpdateSub keeps the
+// stored status so only inserts and lifecycle changes check it.
+func checkStatus(status string) error {
+	switch status {
+	case structures.StatusTrial, structures.StatusActive, structures.StatusPaused,
+		structures.StatusCancelled, structures.StatusExpired:
+		return nil
+	}
+
+	return fmt.Errorf("%w: invalid status: %q", structures.ErrValidation, status)
+}
+
+// compareSortKey compares the sort key of subscription with (value, id),
+// value being formatted like sortColumn.value.
+func compareSortKey(field string, subscription *structures.Subscription, value string, id int) int {
+	var result int
+
+	switch field {
+	case structures.SortByID:
+		other, _ := strconv.Atoi(value)
+		result = compareInts(subscription.ID, other)
+	case structures.SortByPrice:
+		other, _ := strconv.ParseInt(value, 10, 64)
+		result = cmp.Compare(int64(subscription.ScaledPrice()), other)
+	case structures.SortByStartDate:
+		own, _ := structures.ParseMonth(subscription.StartDate)
+		other, _ := structures.ParseMonth(value)
+		result = own.Compare(other)
+	case structures.SortByServiceName:
+		result = strings.Compare(subscription.ServiceName, value)
+	}
+
+	if result != 0 {
+		return result
+	}
+
+	return compareInts(subscription.ID, id)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
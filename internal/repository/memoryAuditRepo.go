@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+// record appends an audit entry for a change made by the actor in ctx. r.mu must be held.
+func (r *MemorySubscriptionRepo) record(ctx context.Context, action string, before, after *structures.Subscription) {
+	info := structures.RequestInfoFrom(ctx)
+
+	entry := structures.AuditEntry{
+		ID:        len(r.audit) + 1,
+		Action:    action,
+		Actor:     info.Actor,
+		RequestID: info.RequestID,
+		ChangedAt: time.Now(),
+	}
+
+	if before != nil {
+		snapshot := *before
+		entry.Before = &snapshot
+		entry.SubscriptionID = before.ID
+	}
+
+	if after != nil {
+		snapshot := *after
+		entry.After = &snapshot
+		entry.SubscriptionID = after.ID
+	}
+
+	r.audit = append(r.audit, entry)
+}
+
+func (r *MemorySubscriptionRepo) SelectHistory(ctx context.Context, id int) ([]structures.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []structures.AuditEntry{}
+	for _, entry := range r.audit {
+		if entry.SubscriptionID == id {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectAudit(ctx context.Context, filter *structures.AuditFilter) (structures.AuditPage, error) {
+	const op = "repository.memorySubscriptionRepo.SelectAudit"
+
+	page := structures.AuditPage{Entries: []structures.AuditEntry{}}
+
+	var from, to time.Time
+	if filter.From != "" {
+		parsed, err := time.Parse(time.RFC3339, filter.From)
+		if err != nil {
+			return page, fmt.Errorf("%s: %w", op, structures.NewFieldError("from", "must be an RFC 3339 timestamp"))
+		}
+		from = parsed
+	}
+	if filter.To != "" {
+		parsed, err := time.Parse(time.RFC3339, filter.To)
+		if err != nil {
+			return page, fmt.Errorf("%s: %w", op, structures.NewFieldError("to", "must be an RFC 3339 timestamp"))
+		}
+		to = parsed
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []structures.AuditEntry
+	for i := len(r.audit) - 1; i >= 0; i-- {
+		entry := r.audit[i]
+
+		if filter.Actor != "" && entry.Actor != filter.Actor {
+			continue
+		}
+		if filter.Action != "" && entry.Action != filter.Action {
+			continue
+		}
+		if filter.SubscriptionID != 0 && entry.SubscriptionID != filter.SubscriptionID {
+			continue
+		}
+		if !from.IsZero() && entry.ChangedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && entry.ChangedAt.After(to) {
+			continue
+		}
+
+		matched = append(matched, entry)
+	}
+
+	page.Total = len(matched)
+
+	if filter.Offset < len(matched) {
+		matched = matched[filter.Offset:]
+	} else {
+		matched = nil
+	}
+
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+
+	page.Entries = append(page.Entries, matched...)
+
+	return page, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/google/uuid"
+)
+
+func (r *MemorySubscriptionRepo) InsertBudget(ctx context.Context, budget *structures.Budget) (int, error) {
+	const op = "repository.memorySubscriptionRepo.InsertBudget"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.checkBudget(op, budget, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	stored.ID = r.nextBudgetID
+	r.nextBudgetID++
+	r.budgets[stored.ID] = stored
+	budget.ID = stored.ID
+
+	log.Info("Budget created", slog.Int("id", stored.ID))
+	return stored.ID, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectBudgets(ctx context.Context, userID string) ([]structures.Budget, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	budgets := []structures.Budget{}
+	for _, budget := range r.budgets {
+		if userID == "" || strings.EqualFold(budget.UserID, userID) {
+			budgets = append(budgets, budget)
+		}
+	}
+
+	slices.SortFunc(budgets, func(a, b structures.Budget) int {
+		return a.ID - b.ID
+	})
+
+	return budgets, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectBudgetById(ctx context.Context, id int) (structures.Budget, error) {
+	const op = "repository.memorySubscriptionRepo.SelectBudgetById"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	budget, ok := r.budgets[id]
+	if !ok {
+		return budget, fmt.Errorf("%s: %w: no budgets with id:%d", op, structures.ErrNotFound, id)
+	}
+
+	return budget, nil
+}
+
+func (r *MemorySubscriptionRepo) UpdateBudget(ctx context.Context, budget *structures.Budget, id int) error {
+	const op = "repository.memorySubscriptionRepo.UpdateBudget"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.budgets[id]; !ok {
+		return fmt.Errorf("%s: %w: no budgets with id:%d", op, structures.ErrNotFound, id)
+	}
+
+	stored, err := r.checkBudget(op, budget, id)
+	if err != nil {
+		return err
+	}
+
+	stored.ID = id
+	r.budgets[id] = stored
+	*budget = stored
+
+	log.Info("Budget updated", slog.Int("id", id))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) DeleteBudget(ctx context.Context, id int) error {
+	const op = "repository.memorySubscriptionRepo.DeleteBudget"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.budgets[id]; !ok {
+		return fmt.Errorf("%s: %w: no budgets with id:%d", op, structures.ErrNotFound, id)
+	}
+
+	delete(r.budgets, id)
+
+	log.Info("Budget deleted", slog.Int("id", id))
+	return nil
+}
+
+// checkBudget mirrors the constraints of the budgets table for budget
+// stored under id, 0 for a new one, and returns it as Postgres would
+// store it. r.mu must be held.
+func (r *MemorySubscriptionRepo) checkBudget(op string, budget *structures.Budget, id int) (structures.Budget, error) {
+	stored := *budget
+
+	userID, err := uuid.Parse(budget.UserID)
+	if err != nil {
+		return stored, fmt.Errorf("%s: %w: invalid user_id: %v", op, structures.ErrValidation, err)
+	}
+	stored.UserID = userID.String()
+
+	if stored.Amount <= 0 || !structures.IsCurrencyCode(stored.Currency) || (stored.Category != "" && stored.ServiceID != 0) {
+		return stored, fmt.Errorf("%s: %w: invalid budget", op, structures.ErrValidation)
+	}
+
+	err = r.checkUser(op, stored.UserID)
+	if err == nil {
+		err = r.checkService(op, stored.ServiceID)
+	}
+	if err != nil {
+		return stored, err
+	}
+
+	for _, other := range r.budgets {
+		if other.ID != id && other.UserID == stored.UserID && other.Category == stored.Category && other.ServiceID == stored.ServiceID {
+			return stored, fmt.Errorf("%s: %w: user %s already has this budget", op, structures.ErrConflict, stored.UserID)
+		}
+	}
+
+	return stored, nil
+}
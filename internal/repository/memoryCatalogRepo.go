@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+func (r *MemorySubscriptionRepo) InsertService(ctx context.Context, service *structures.Service) (int, error) {
+	const op = "repository.memorySubscriptionRepo.InsertService"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *service
+	stored.ID = r.nextServiceID
+	stored.Aliases = slices.Clone(service.Aliases)
+	slices.Sort(stored.Aliases)
+
+	if err := r.setServiceKeys(op, &stored); err != nil {
+		return 0, err
+	}
+
+	r.nextServiceID++
+	r.services[stored.ID] = stored
+	service.ID = stored.ID
+
+	log.Info("Service created", slog.Int("id", stored.ID))
+	return stored.ID, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectServices(ctx context.Context) ([]structures.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	services := make([]structures.Service, 0, len(r.services))
+	for _, service := range r.services {
+		services = append(services, service)
+	}
+
+	slices.SortFunc(services, func(a, b structures.Service) int {
+		return cmp.Or(strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)), cmp.Compare(a.ID, b.ID))
+	})
+
+	return services, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectServiceById(ctx context.Context, id int) (structures.Service, error) {
+	const op = "repository.memorySubscriptionRepo.SelectServiceById"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	service, ok := r.services[id]
+	if !ok {
+		return service, fmt.Errorf("%s: %w: no services with id:%d", op, structures.ErrNotFound, id)
+	}
+
+	return service, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectServiceByKey(ctx context.Context, key string) (structures.Service, error) {
+	const op = "repository.memorySubscriptionRepo.SelectServiceByKey"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	service, ok := r.services[r.serviceKeys[key]]
+	if !ok {
+		return service, fmt.Errorf("%s: %w: no services with key:%s", op, structures.ErrNotFound, key)
+	}
+
+	return service, nil
+}
+
+func (r *MemorySubscriptionRepo) UpdateService(ctx context.Context, service *structures.Service, id int) error {
+	const op = "repository.memorySubscriptionRepo.UpdateService"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.services[id]
+	if !ok {
+		return fmt.Errorf("%s: %w: no services with id:%d", op, structures.ErrNotFound, id)
+	}
+
+	stored := *service
+	stored.ID = id
+	stored.Aliases = slices.Clone(service.Aliases)
+	slices.Sort(stored.Aliases)
+
+	r.deleteServiceKeys(id)
+	if err := r.setServiceKeys(op, &stored); err != nil {
+		// Keep the catalog as it was, like a rolled back transaction.
+		_ = r.setServiceKeys(op, &current)
+		return err
+	}
+
+	r.services[id] = stored
+	service.ID = id
+
+	renamed := r.setSubsService(ctx, &stored, func(subscription *structures.Subscription) bool {
+		return subscription.ServiceID == id && subscription.ServiceName != stored.Name
+	})
+
+	log.Info("Service updated", slog.Int("id", id), slog.Int("renamed", renamed))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) DeleteService(ctx context.Context, id int) error {
+	const op = "repository.memorySubscriptionRepo.DeleteService"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.services[id]; !ok {
+		return fmt.Errorf("%s: %w: no services with id:%d", op, structures.ErrNotFound, id)
+	}
+
+	for _, subscription := range r.subscriptions {
+		if subscription.ServiceID == id {
+			return fmt.Errorf("%s: %w: service %d has subscriptions", op, structures.ErrConflict, id)
+		}
+	}
+
+	r.deleteServiceKeys(id)
+	delete(r.services, id)
+	for budgetID, budget := range r.budgets {
+		if budget.ServiceID == id {
+			delete(r.budgets, budgetID)
+		}
+	}
+
+	log.Info("Service deleted", slog.Int("id", id))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) SelectUnmatchedNames(ctx context.Context) ([]structures.UnmatchedName, error) {
+	r.mu.RLock()
+	counts := make(map[string]int)
+	for _, subscription := range r.subscriptions {
+		if subscription.ServiceID == 0 && subscription.DeletedAt == nil {
+			counts[subscription.ServiceName]++
+		}
+	}
+	r.mu.RUnlock()
+
+	names := []structures.UnmatchedName{}
+	for name, count := range counts {
+		names = append(names, structures.UnmatchedName{ServiceName: name, Subscriptions: count})
+	}
+
+	slices.SortFunc(names, func(a, b structures.UnmatchedName) int {
+		return cmp.Or(cmp.Compare(b.Subscriptions, a.Subscriptions), strings.Compare(a.ServiceName, b.ServiceName))
+	})
+
+	return names, nil
+}
+
+func (r *MemorySubscriptionRepo) AssignService(ctx context.Context, serviceName string, service *structures.Service) (int, error) {
+	const op = "repository.memorySubscriptionRepo.AssignService"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.services[service.ID]; !ok {
+		return 0, fmt.Errorf("%s: %w: no services with id:%d", op, structures.ErrConflict, service.ID)
+	}
+
+	return r.setSubsService(ctx, service, func(subscription *structures.Subscription) bool {
+		return subscription.ServiceID == 0 && subscription.DeletedAt == nil && subscription.ServiceName == serviceName
+	}), nil
+}
+
+// setSubsService gives the subscriptions matching match the ID and name of
+// service and returns how many there were. r.mu must be held.
+func (r *MemorySubscriptionRepo) setSubsService(
+	ctx context.Context,
+	service *structures.Service,
+	match func(subscription *structures.Subscription) bool,
+) int {
+	ids := make([]int, 0, len(r.subscriptions))
+	for id, subscription := range r.subscriptions {
+		if match(&subscription) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		current := r.subscriptions[id]
+
+		updated := current
+		updated.ServiceID = service.ID
+		updated.ServiceName = service.Name
+		updated.Version++
+		r.subscriptions[id] = updated
+		r.record(ctx, structures.AuditUpdate, &current, &updated)
+	}
+
+	return len(ids)
+}
+
+// setServiceKeys mirrors the primary key of service_aliases. r.mu must be held.
+func (r *MemorySubscriptionRepo) setServiceKeys(op string, service *structures.Service) error {
+	keys := service.Keys()
+
+	for i, key := range keys {
+		if owner, ok := r.serviceKeys[key]; (ok && owner != service.ID) || slices.Contains(keys[:i], key) {
+			return fmt.Errorf("%s: %w: name %q is taken", op, structures.ErrConflict, key)
+		}
+	}
+
+	for _, key := range keys {
+		r.serviceKeys[key] = service.ID
+	}
+
+	return nil
+}
+
+// deleteServiceKeys drops the keys of the service with id. r.mu must be held.
+func (r *MemorySubscriptionRepo) deleteServiceKeys(id int) {
+	for key, owner := range r.serviceKeys {
+		if owner == id {
+			delete(r.serviceKeys, key)
+		}
+	}
+}
+
+// checkService mirrors the service_id foreign key. r.mu must be held.
+func (r *MemorySubscriptionRepo) checkService(op string, serviceID int) error {
+	if _, ok := r.services[serviceID]; serviceID != 0 && !ok {
+		return fmt.Errorf("%s: %w: no services with id:%d", op, structures.ErrConflict, serviceID)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+func (r *MemorySubscriptionRepo) UpsertRates(ctx context.Context, rates []structures.ExchangeRate) error {
+	const op = "repository.memorySubscriptionRepo.UpsertRates"
+
+	for _, rate := range rates {
+		if _, err := structures.ParseMonth(rate.Month); err != nil {
+			return fmt.Errorf("%s: %w: invalid month: %v", op, structures.ErrValidation, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rate := range rates {
+		r.rates[exchangeRateKey{month: rate.Month, from: rate.From, to: rate.To}] = rate
+	}
+
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) SelectRates(ctx context.Context, filter *structures.ExchangeRateFilter) ([]structures.ExchangeRate, error) {
+	const op = "repository.memorySubscriptionRepo.SelectRates"
+
+	var from, to time.Time
+	if filter.From != "" {
+		month, err := structures.ParseMonth(filter.From)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, structures.NewFieldError("from", "must be a month in MM-YYYY format"))
+		}
+		from = month
+	}
+	if filter.To != "" {
+		month, err := structures.ParseMonth(filter.To)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, structures.NewFieldError("to", "must be a month in MM-YYYY format"))
+		}
+		to = month
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := []structures.ExchangeRate{}
+	for _, rate := range r.rates {
+		if filter.Currency != "" && rate.From != filter.Currency && rate.To != filter.Currency {
+			continue
+		}
+
+		month, _ := structures.ParseMonth(rate.Month)
+		if !from.IsZero() && month.Before(from) {
+			continue
+		}
+		if !to.IsZero() && month.After(to) {
+			continue
+		}
+
+		rates = append(rates, rate)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		a, _ := structures.ParseMonth(rates[i].Month)
+		b, _ := structures.ParseMonth(rates[j].Month)
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		if rates[i].From != rates[j].From {
+			return rates[i].From < rates[j].From
+		}
+		return rates[i].To < rates[j].To
+	})
+
+	return rates, nil
+}
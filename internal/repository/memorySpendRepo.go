@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+func (r *MemorySubscriptionRepo) SelectCountedSubs(ctx context.Context, data *structures.Counting) ([]structures.Subscription, error) {
+	const op = "repository.memorySubscriptionRepo.SelectCountedSubs"
+
+	start, err := structures.ParseMonth(data.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, structures.NewFieldError("start_date", "must be a month in MM-YYYY format"))
+	}
+
+	end, err := structures.ParseMonth(data.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, structures.NewFieldError("end_date", "must be a month in MM-YYYY format"))
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscriptions := []structures.Subscription{}
+	for _, subscription := range r.subscriptions {
+		if subscription.DeletedAt != nil && !data.IncludeDeleted {
+			continue
+		}
+		if data.UserID != "" && !isPayer(&subscription, data.UserID) {
+			continue
+		}
+		if data.ServiceName != "" && subscription.ServiceName != data.ServiceName {
+			continue
+		}
+		if data.ServiceID != 0 && subscription.ServiceID != data.ServiceID {
+			continue
+		}
+		if data.Category != "" && subscription.Category != data.Category {
+			continue
+		}
+		if data.Tag != "" && !slices.Contains(subscription.Tags, data.Tag) {
+			continue
+		}
+
+		subStart, _ := structures.ParseMonth(subscription.StartDate)
+		if subStart.After(end) {
+			continue
+		}
+		if subscription.EndDate != "" {
+			subEnd, _ := structures.ParseMonth(subscription.EndDate)
+			if subEnd.Before(start) {
+				continue
+			}
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].ID < subscriptions[j].ID
+	})
+
+	return subscriptions, nil
+}
+
+// SelectSpend counts the spend with the billing, pricing and sharing rules
+// of structures.Subscription.
+func (r *MemorySubscriptionRepo) SelectSpend(
+	ctx context.Context,
+	data *structures.Counting,
+	query *structures.SpendQuery,
+) (structures.Spend, error) {
+	subscriptions, err := r.SelectCountedSubs(ctx, data)
+	if err != nil {
+		return structures.Spend{}, err
+	}
+
+	target := data.TargetCurrency
+	if target == "" {
+		target = structures.DefaultCurrency
+	}
+
+	// Rates before the period are needed too, the last of them stays in
+	// effect until the next one.
+	rates, err := r.SelectRates(ctx, &structures.ExchangeRateFilter{To: data.EndDate})
+	if err != nil {
+		return structures.Spend{}, err
+	}
+
+	conv := structures.NewConverter(target, rates)
+
+	start, _ := structures.ParseMonth(data.StartDate)
+	end, _ := structures.ParseMonth(data.EndDate)
+
+	type groupKey struct {
+		month      time.Time
+		key, owner string
+		id         int
+	}
+
+	groups := make(map[groupKey]*structures.SpendGroup)
+	months := make(map[groupKey]time.Time)
+	seen := make(map[groupKey]map[int]bool)
+
+	add := func(month time.Time, subscription *structures.Subscription, payer string, amount structures.Money) {
+		var k groupKey
+		if query.ByMonth {
+			k.month = month
+		}
+
+		switch query.GroupBy {
+		case structures.GroupByService:
+			k.key = subscription.ServiceName
+		case structures.GroupByCategory:
+			k.key = subscription.Category
+		case structures.GroupByUser:
+			k.key = payer
+		case structures.GroupByDebt:
+			k.key, k.owner = payer, subscription.UserID
+		case structures.GroupBySubscription:
+			k.id, k.owner = subscription.ID, subscription.UserID
+		}
+
+		group, ok := groups[k]
+		if !ok {
+			group = &structures.SpendGroup{Key: k.key, Owner: k.owner, SubscriptionID: k.id}
+			if query.ByMonth {
+				group.Month = structures.FormatMonth(month)
+			}
+			if query.GroupBy == structures.GroupBySubscription {
+				group.ServiceName = subscription.ServiceName
+			}
+			groups[k] = group
+			months[k] = k.month
+			seen[k] = make(map[int]bool)
+		}
+
+		group.Total += amount
+		if !seen[k][subscription.ID] {
+			seen[k][subscription.ID] = true
+			group.Subscriptions++
+			if query.GroupBy == structures.GroupByDebt {
+				group.SubscriptionIDs = append(group.SubscriptionIDs, subscription.ID)
+			}
+		}
+	}
+
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+
+		for month := start; !month.After(end); month = month.AddDate(0, 1, 0) {
+			if !subscription.IsBilledIn(month) {
+				continue
+			}
+			if data.Mode == structures.CountCharges && len(subscription.ChargeDatesIn(month)) == 0 {
+				continue
+			}
+
+			amount := conv.Convert(subscription.AmountIn(month, data.Mode), subscription.Currency, month)
+
+			for _, share := range subscription.Shares(month, amount) {
+				switch {
+				case query.GroupBy == structures.GroupByDebt:
+					if share.UserID == subscription.UserID {
+						continue
+					}
+					if data.UserID != "" && !strings.EqualFold(share.UserID, data.UserID) && !strings.EqualFold(subscription.UserID, data.UserID) {
+						continue
+					}
+				case data.UserID != "" && !strings.EqualFold(share.UserID, data.UserID):
+					continue
+				}
+
+				add(month, subscription, share.UserID, share.Amount)
+			}
+		}
+	}
+
+	spend := structures.Spend{
+		Groups:       []structures.SpendGroup{},
+		Rates:        conv.AppliedRates(),
+		MissingRates: conv.MissingRates(),
+	}
+
+	if query.GroupBy == "" && !query.ByMonth && len(groups) == 0 {
+		spend.Groups = append(spend.Groups, structures.SpendGroup{})
+	}
+
+	keys := slices.Collect(maps.Keys(groups))
+	slices.SortFunc(keys, func(a, b groupKey) int {
+		return cmp.Or(
+			months[a].Compare(months[b]),
+			cmp.Compare(groups[b].Total, groups[a].Total),
+			strings.Compare(a.key, b.key),
+			cmp.Compare(a.id, b.id),
+			strings.Compare(a.owner, b.owner),
+		)
+	})
+
+	for _, k := range keys {
+		spend.Groups = append(spend.Groups, *groups[k])
+	}
+
+	if query.Top > 0 && len(spend.Groups) > query.Top {
+		spend.Groups = spend.Groups[:query.Top]
+	}
+
+	return spend, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectRenewals(ctx context.Context, query *structures.RenewalQuery) ([]structures.Renewal, error) {
+	const op = "repository.memorySubscriptionRepo.SelectRenewals"
+
+	from, err := time.Parse(structures.DateLayout, query.From)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	until, err := time.Parse(structures.DateLayout, query.Until)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	subscriptions, err := r.SelectCountedSubs(ctx, &structures.Counting{
+		StartDate: structures.FormatMonth(from),
+		EndDate:   structures.FormatMonth(until),
+		UserID:    query.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	first := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(until.Year(), until.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	renewals := []structures.Renewal{}
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+
+		for _, charge := range subscription.Charges(first, last) {
+			// Dates in DateLayout compare like the days they name.
+			if charge.Date < query.From || charge.Date > query.Until {
+				continue
+			}
+			if query.AfterDate != "" && (charge.Date < query.AfterDate ||
+				charge.Date == query.AfterDate && subscription.ID <= query.AfterID) {
+				continue
+			}
+
+			renewals = append(renewals, structures.Renewal{
+				SubscriptionID: subscription.ID,
+				ServiceName:    subscription.ServiceName,
+				UserID:         subscription.UserID,
+				Charge:         charge,
+			})
+		}
+	}
+
+	slices.SortFunc(renewals, func(a, b structures.Renewal) int {
+		return cmp.Or(strings.Compare(a.Date, b.Date), cmp.Compare(a.SubscriptionID, b.SubscriptionID))
+	})
+
+	if len(renewals) > query.Limit {
+		renewals = renewals[:query.Limit]
+	}
+
+	return renewals, nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/google/uuid"
+)
+
+func (r *MemorySubscriptionRepo) InsertUser(ctx context.Context, user *structures.User) (string, error) {
+	const op = "repository.memorySubscriptionRepo.InsertUser"
+	log := r.log.With("op", op)
+
+	stored := *user
+	if stored.ID == "" {
+		stored.ID = uuid.NewString()
+	}
+
+	id, err := uuid.Parse(stored.ID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: invalid id: %v", op, structures.ErrValidation, err)
+	}
+	stored.ID = id.String()
+	stored.CreatedAt = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[stored.ID]; ok {
+		return "", fmt.Errorf("%s: %w: user %s already exists", op, structures.ErrConflict, stored.ID)
+	}
+
+	if err := r.checkEmail(op, &stored); err != nil {
+		return "", err
+	}
+
+	r.users[stored.ID] = stored
+	*user = stored
+
+	log.Info("User created", slog.String("id", stored.ID))
+	return stored.ID, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectUsers(ctx context.Context, filter *structures.UserFilter) (structures.UserPage, error) {
+	r.mu.RLock()
+	users := make([]structures.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(users, func(a, b structures.User) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+
+	page := structures.UserPage{Users: []structures.User{}, Total: len(users)}
+
+	if filter.Offset >= len(users) {
+		return page, nil
+	}
+	users = users[filter.Offset:]
+
+	if filter.Limit > 0 && len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+
+	page.Users = append(page.Users, users...)
+	return page, nil
+}
+
+func (r *MemorySubscriptionRepo) SelectUserById(ctx context.Context, id string) (structures.User, error) {
+	const op = "repository.memorySubscriptionRepo.SelectUserById"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[strings.ToLower(id)]
+	if !ok {
+		return user, fmt.Errorf("%s: %w: no users with id:%s", op, structures.ErrNotFound, id)
+	}
+
+	return user, nil
+}
+
+func (r *MemorySubscriptionRepo) UpdateUser(ctx context.Context, user *structures.User, id string) error {
+	const op = "repository.memorySubscriptionRepo.UpdateUser"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.users[strings.ToLower(id)]
+	if !ok {
+		return fmt.Errorf("%s: %w: no users with id:%s", op, structures.ErrNotFound, id)
+	}
+
+	updated := current
+	updated.Name = user.Name
+	updated.Email = user.Email
+
+	if err := r.checkEmail(op, &updated); err != nil {
+		return err
+	}
+
+	r.users[updated.ID] = updated
+	*user = updated
+
+	log.Info("User updated", slog.String("id", updated.ID))
+	return nil
+}
+
+func (r *MemorySubscriptionRepo) DeleteUser(ctx context.Context, id string) error {
+	const op = "repository.memorySubscriptionRepo.DeleteUser"
+	log := r.log.With("op", op)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id = strings.ToLower(id)
+	if _, ok := r.users[id]; !ok {
+		return fmt.Errorf("%s: %w: no users with id:%s", op, structures.ErrNotFound, id)
+	}
+
+	for _, subscription := range r.subscriptions {
+		if isPayer(&subscription, id) {
+			return fmt.Errorf("%s: %w: user %s has subscriptions", op, structures.ErrConflict, id)
+		}
+	}
+
+	delete(r.users, id)
+	for budgetID, budget := range r.budgets {
+		if budget.UserID == id {
+			delete(r.budgets, budgetID)
+		}
+	}
+
+	log.Info("User deleted", slog.String("id", id))
+	return nil
+}
+
+// checkEmail mirrors the unique index on lower(email). r.mu must be held.
+func (r *MemorySubscriptionRepo) checkEmail(op string, user *structures.User) error {
+	if user.Email == "" {
+		return nil
+	}
+
+	for _, other := range r.users {
+		if other.ID != user.ID && strings.EqualFold(other.Email, user.Email) {
+			return fmt.Errorf("%s: %w: email %s is taken", op, structures.ErrConflict, user.Email)
+		}
+	}
+
+	return nil
+}
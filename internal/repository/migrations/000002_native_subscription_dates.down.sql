@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS public.subscriptions_period_idx;
+DROP INDEX IF EXISTS public.subscriptions_service_name_idx;
+DROP INDEX IF EXISTS public.subscriptions_user_id_idx;
+
+ALTER TABLE public.subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_end_date_month_check,
+    DROP CONSTRAINT IF EXISTS subscriptions_start_date_month_check;
+
+ALTER TABLE public.subscriptions
+    ALTER COLUMN start_date TYPE text USING to_char(start_date, 'MM-YYYY'),
+    ALTER COLUMN end_date TYPE text USING to_char(end_date, 'MM-YYYY');
@@ -0,0 +1,25 @@
+DO $$
+DECLARE
+    bad_rows integer;
+BEGIN
+    SELECT COUNT(*) INTO bad_rows
+    FROM public.subscriptions
+    WHERE start_date !~ '^(0[1-9]|1[0-2])-[0-9]{4}$'
+       OR (COALESCE(end_date, '') <> '' AND end_date !~ '^(0[1-9]|1[0-2])-[0-9]{4}$');
+
+    IF bad_rows > 0 THEN
+        RAISE EXCEPTION 'subscriptions: % rows have start_date or end_date not in MM-YYYY format', bad_rows;
+    END IF;
+END $$;
+
+ALTER TABLE public.subscriptions
+    ALTER COLUMN start_date TYPE date USING to_date(start_date, 'MM-YYYY'),
+    ALTER COLUMN end_date TYPE date USING to_date(NULLIF(end_date, ''), 'MM-YYYY');
+
+ALTER TABLE public.subscriptions
+    ADD CONSTRAINT subscriptions_start_date_month_check CHECK (EXTRACT(DAY FROM start_date) = 1),
+    ADD CONSTRAINT subscriptions_end_date_month_check CHECK (EXTRACT(DAY FROM end_date) = 1);
+
+CREATE INDEX IF NOT EXISTS subscriptions_user_id_idx ON public.subscriptions (user_id);
+CREATE INDEX IF NOT EXISTS subscriptions_service_name_idx ON public.subscriptions (service_name);
+CREATE INDEX IF NOT EXISTS subscriptions_period_idx ON public.subscriptions (start_date, end_date);
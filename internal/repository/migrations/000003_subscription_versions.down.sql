@@ -0,0 +1,2 @@
+ALTER TABLE public.subscriptions
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS public.subscriptions_deleted_at_idx;
+
+DELETE FROM public.subscriptions WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE public.subscriptions
+    DROP COLUMN IF EXISTS deleted_at;
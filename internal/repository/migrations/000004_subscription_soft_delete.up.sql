@@ -0,0 +1,6 @@
+ALTER TABLE public.subscriptions
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS subscriptions_deleted_at_idx
+    ON public.subscriptions (deleted_at)
+    WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,20 @@
+-- No foreign key to subscriptions: the history outlives purged subscriptions.
+CREATE TABLE IF NOT EXISTS public.subscription_audit (
+    id bigserial PRIMARY KEY,
+    subscription_id integer NOT NULL,
+    action text NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge')),
+    actor text NOT NULL,
+    request_id text NOT NULL DEFAULT '',
+    before jsonb,
+    after jsonb,
+    changed_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS subscription_audit_subscription_id_idx
+    ON public.subscription_audit (subscription_id, id);
+
+CREATE INDEX IF NOT EXISTS subscription_audit_actor_idx
+    ON public.subscription_audit (actor, changed_at);
+
+CREATE INDEX IF NOT EXISTS subscription_audit_changed_at_idx
+    ON public.subscription_audit (changed_at);
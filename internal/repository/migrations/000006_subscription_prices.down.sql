@@ -0,0 +1,2 @@
+-- subscriptions_pkey is kept, ids are unique either way.
+DROP TABLE IF EXISTS public.subscription_prices;
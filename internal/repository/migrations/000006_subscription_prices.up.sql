@@ -0,0 +1,23 @@
+-- The prices reference subscriptions, which were created without a primary key.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conrelid = 'public.subscriptions'::regclass
+          AND contype = 'p'
+    ) THEN
+        ALTER TABLE public.subscriptions ADD CONSTRAINT subscriptions_pkey PRIMARY KEY (id);
+    END IF;
+END $$;
+
+-- Price changes scheduled after start_date, subscriptions.price is charged
+-- until the first of them takes effect.
+CREATE TABLE IF NOT EXISTS public.subscription_prices (
+    subscription_id integer NOT NULL REFERENCES public.subscriptions (id) ON DELETE CASCADE,
+    effective_from date NOT NULL,
+    price integer NOT NULL,
+    PRIMARY KEY (subscription_id, effective_from),
+    CONSTRAINT subscription_prices_effective_from_month_check CHECK (EXTRACT(DAY FROM effective_from) = 1),
+    CONSTRAINT subscription_prices_price_check CHECK (price >= 0)
+);
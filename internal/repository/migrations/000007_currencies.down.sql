@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS public.exchange_rates;
+
+ALTER TABLE public.subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_currency_check,
+    DROP COLUMN IF EXISTS currency;
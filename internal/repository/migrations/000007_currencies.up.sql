@@ -0,0 +1,17 @@
+-- Prices were always rubles.
+ALTER TABLE public.subscriptions
+    ADD COLUMN IF NOT EXISTS currency char(3) NOT NULL DEFAULT 'RUB';
+
+ALTER TABLE public.subscriptions
+    ADD CONSTRAINT subscriptions_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+
+CREATE TABLE IF NOT EXISTS public.exchange_rates (
+    month date NOT NULL,
+    from_currency char(3) NOT NULL,
+    to_currency char(3) NOT NULL,
+    rate numeric(20, 10) NOT NULL,
+    PRIMARY KEY (month, from_currency, to_currency),
+    CONSTRAINT exchange_rates_month_check CHECK (EXTRACT(DAY FROM month) = 1),
+    CONSTRAINT exchange_rates_pair_check CHECK (from_currency <> to_currency),
+    CONSTRAINT exchange_rates_rate_check CHECK (rate > 0)
+);
@@ -0,0 +1,21 @@
+-- Fractional amounts are rounded half away from zero to whole units.
+UPDATE public.subscription_prices p
+SET price = round(p.price::numeric / CASE
+        WHEN s.currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN s.currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        WHEN s.currency IN ('CLF', 'UYW') THEN 10000
+        ELSE 100
+    END)
+FROM public.subscriptions s
+WHERE s.id = p.subscription_id;
+
+ALTER TABLE public.subscription_prices
+    ALTER COLUMN price TYPE integer;
+
+ALTER TABLE public.subscriptions
+    ALTER COLUMN price TYPE integer USING round(price::numeric / CASE
+        WHEN currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        WHEN currency IN ('CLF', 'UYW') THEN 10000
+        ELSE 100
+    END)::integer;
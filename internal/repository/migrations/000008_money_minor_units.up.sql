@@ -0,0 +1,22 @@
+-- Prices were whole units of their currency, they are minor units now: the
+-- ISO 4217 exponent of the currency, see structures.CurrencyExponent.
+ALTER TABLE public.subscriptions
+    ALTER COLUMN price TYPE bigint USING price::bigint * CASE
+        WHEN currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        WHEN currency IN ('CLF', 'UYW') THEN 10000
+        ELSE 100
+    END;
+
+ALTER TABLE public.subscription_prices
+    ALTER COLUMN price TYPE bigint;
+
+UPDATE public.subscription_prices p
+SET price = p.price * CASE
+        WHEN s.currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN s.currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        WHEN s.currency IN ('CLF', 'UYW') THEN 10000
+        ELSE 100
+    END
+FROM public.subscriptions s
+WHERE s.id = p.subscription_id;
@@ -0,0 +1,6 @@
+ALTER TABLE public.subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_billing_months_positive_check,
+    DROP CONSTRAINT IF EXISTS subscriptions_billing_months_check,
+    DROP CONSTRAINT IF EXISTS subscriptions_billing_period_check,
+    DROP COLUMN IF EXISTS billing_months,
+    DROP COLUMN IF EXISTS billing_period;
@@ -0,0 +1,12 @@
+-- Existing subscriptions were all billed monthly.
+ALTER TABLE public.subscriptions
+    ADD COLUMN IF NOT EXISTS billing_period text NOT NULL DEFAULT 'monthly',
+    ADD COLUMN IF NOT EXISTS billing_months integer;
+
+ALTER TABLE public.subscriptions
+    ADD CONSTRAINT subscriptions_billing_period_check
+        CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly', 'custom')),
+    ADD CONSTRAINT subscriptions_billing_months_check
+        CHECK ((billing_period = 'custom') = (billing_months IS NOT NULL)),
+    ADD CONSTRAINT subscriptions_billing_months_positive_check
+        CHECK (billing_months > 0);
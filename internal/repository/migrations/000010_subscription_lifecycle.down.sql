@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS public.subscription_pauses;
+
+DROP INDEX IF EXISTS public.subscriptions_status_idx;
+
+ALTER TABLE public.subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_trial_end_date_check,
+    DROP CONSTRAINT IF EXISTS subscriptions_trial_price_check,
+    DROP CONSTRAINT IF EXISTS subscriptions_status_check,
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS trial_price,
+    DROP COLUMN IF EXISTS trial_end_date,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,28 @@
+ALTER TABLE public.subscriptions
+    ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS trial_end_date date,
+    ADD COLUMN IF NOT EXISTS trial_price bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cancelled_at timestamptz;
+
+ALTER TABLE public.subscriptions
+    ADD CONSTRAINT subscriptions_status_check
+        CHECK (status IN ('trial', 'active', 'paused', 'cancelled', 'expired')),
+    ADD CONSTRAINT subscriptions_trial_price_check CHECK (trial_price >= 0),
+    ADD CONSTRAINT subscriptions_trial_end_date_check
+        CHECK (trial_end_date IS NULL OR trial_end_date >= start_date);
+
+-- Subscriptions that ended before this month are expired.
+UPDATE public.subscriptions
+SET status = 'expired'
+WHERE end_date < date_trunc('month', now());
+
+CREATE TABLE IF NOT EXISTS public.subscription_pauses (
+    subscription_id integer NOT NULL REFERENCES public.subscriptions (id) ON DELETE CASCADE,
+    paused_from date NOT NULL,
+    paused_to date,
+    PRIMARY KEY (subscription_id, paused_from),
+    CONSTRAINT subscription_pauses_month_check CHECK (EXTRACT(DAY FROM paused_from) = 1),
+    CONSTRAINT subscription_pauses_period_check CHECK (paused_to IS NULL OR paused_to >= paused_from)
+);
+
+CREATE INDEX IF NOT EXISTS subscriptions_status_idx ON public.subscriptions (status);
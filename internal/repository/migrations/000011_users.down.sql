@@ -0,0 +1,5 @@
+ALTER TABLE public.subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_user_id_fkey,
+    ALTER COLUMN user_id SET DEFAULT uuid_generate_v4();
+
+DROP TABLE IF EXISTS public.users;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS public.users (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name text NOT NULL DEFAULT '',
+    email text,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_idx ON public.users (lower(email));
+
+-- Every user_id already referenced by a subscription becomes a user.
+INSERT INTO public.users (id)
+SELECT DISTINCT user_id FROM public.subscriptions
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE public.subscriptions
+    ALTER COLUMN user_id DROP DEFAULT,
+    ADD CONSTRAINT subscriptions_user_id_fkey
+        FOREIGN KEY (user_id) REFERENCES public.users (id) ON DELETE RESTRICT;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS public.subscriptions_service_id_idx;
+
+ALTER TABLE public.subscriptions
+    DROP COLUMN IF EXISTS service_id;
+
+DROP TABLE IF EXISTS public.service_aliases;
+
+DROP TABLE IF EXISTS public.services;
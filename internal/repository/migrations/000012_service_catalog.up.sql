@@ -0,0 +1,69 @@
+CREATE TABLE IF NOT EXISTS public.services (
+    id serial PRIMARY KEY,
+    name text NOT NULL,
+    category text NOT NULL DEFAULT '',
+    website text NOT NULL DEFAULT '',
+    default_price bigint NOT NULL DEFAULT 0 CHECK (default_price >= 0),
+    currency text NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$')
+);
+
+-- Names and aliases in the form of structures.ServiceKey, the name of a
+-- service is one of its keys too.
+CREATE TABLE IF NOT EXISTS public.service_aliases (
+    key text PRIMARY KEY,
+    alias text NOT NULL,
+    service_id integer NOT NULL REFERENCES public.services (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS service_aliases_service_id_idx ON public.service_aliases (service_id);
+
+ALTER TABLE public.subscriptions
+    ADD COLUMN IF NOT EXISTS service_id integer REFERENCES public.services (id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS subscriptions_service_id_idx ON public.subscriptions (service_id);
+
+INSERT INTO public.services (name, category, website) VALUES
+    ('Yandex Plus', 'bundle', 'https://plus.yandex.ru'),
+    ('Kinopoisk', 'video', 'https://www.kinopoisk.ru'),
+    ('Okko', 'video', 'https://okko.tv'),
+    ('ivi', 'video', 'https://www.ivi.ru'),
+    ('Wink', 'video', 'https://wink.ru'),
+    ('Netflix', 'video', 'https://www.netflix.com'),
+    ('YouTube Premium', 'video', 'https://www.youtube.com/premium'),
+    ('Spotify', 'music', 'https://www.spotify.com'),
+    ('Apple Music', 'music', 'https://music.apple.com'),
+    ('VK Music', 'music', 'https://vk.com/music'),
+    ('Telegram Premium', 'messaging', 'https://telegram.org'),
+    ('iCloud+', 'storage', 'https://www.icloud.com');
+
+INSERT INTO public.service_aliases (key, alias, service_id)
+SELECT lower(name), name, id FROM public.services;
+
+INSERT INTO public.service_aliases (key, alias, service_id)
+SELECT lower(a.alias), a.alias, s.id
+FROM (VALUES
+    ('Yandex Plus', 'Яндекс Плюс'),
+    ('Yandex Plus', 'Яндекс.Плюс'),
+    ('Yandex Plus', 'Yandex.Plus'),
+    ('Kinopoisk', 'Кинопоиск'),
+    ('Okko', 'Окко'),
+    ('ivi', 'Иви'),
+    ('Wink', 'Винк'),
+    ('Netflix', 'Нетфликс'),
+    ('YouTube Premium', 'Ютуб Премиум'),
+    ('Spotify', 'Спотифай'),
+    ('VK Music', 'VK Музыка'),
+    ('VK Music', 'ВК Музыка'),
+    ('Telegram Premium', 'Телеграм Премиум'),
+    ('iCloud+', 'iCloud')
+) AS a (service, alias)
+JOIN public.services s ON s.name = a.service;
+
+-- Existing names found in the catalog get its canonical name, the rest
+-- is listed by GET /services/unmatched.
+UPDATE public.subscriptions sub
+SET service_id = a.service_id,
+    service_name = s.name
+FROM public.service_aliases a
+JOIN public.services s ON s.id = a.service_id
+WHERE a.key = replace(lower(regexp_replace(btrim(sub.service_name), '\s+', ' ', 'g')), 'ё', 'е');
@@ -0,0 +1,12 @@
+ALTER TABLE public.subscriptions
+    ADD COLUMN IF NOT EXISTS category text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tags text[] NOT NULL DEFAULT '{}';
+
+-- Subscriptions of catalog services take the category of the service.
+UPDATE public.subscriptions sub
+SET category = s.category
+FROM public.services s
+WHERE s.id = sub.service_id;
+
+CREATE INDEX IF NOT EXISTS subscriptions_category_idx ON public.subscriptions (category);
+CREATE INDEX IF NOT EXISTS subscriptions_tags_idx ON public.subscriptions USING gin (tags);
@@ -0,0 +1,14 @@
+-- Users sharing the cost of a subscription with its owner. A member pays
+-- either a fixed amount of every charge or weight parts of the rest.
+CREATE TABLE IF NOT EXISTS public.subscription_members (
+    subscription_id integer NOT NULL REFERENCES public.subscriptions (id) ON DELETE CASCADE,
+    user_id uuid NOT NULL REFERENCES public.users (id) ON DELETE RESTRICT,
+    weight integer NOT NULL DEFAULT 0,
+    amount bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (subscription_id, user_id),
+    CONSTRAINT subscription_members_weight_check CHECK (weight >= 0),
+    CONSTRAINT subscription_members_amount_check CHECK (amount >= 0),
+    CONSTRAINT subscription_members_share_check CHECK (weight = 0 OR amount = 0)
+);
+
+CREATE INDEX IF NOT EXISTS subscription_members_user_id_idx ON public.subscription_members (user_id);
@@ -0,0 +1,14 @@
+-- Monthly budgets of users on every subscription, on a category or on a
+-- catalog service. A user has at most one budget per scope.
+CREATE TABLE IF NOT EXISTS public.budgets (
+    id serial PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    category text NOT NULL DEFAULT '',
+    service_id integer REFERENCES public.services (id) ON DELETE CASCADE,
+    amount bigint NOT NULL CHECK (amount > 0),
+    currency text NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$'),
+    CONSTRAINT budgets_scope_check CHECK (category = '' OR service_id IS NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS budgets_scope_idx
+    ON public.budgets (user_id, category, COALESCE(service_id, 0));
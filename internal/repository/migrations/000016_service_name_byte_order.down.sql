@@ -0,0 +1 @@
+DROP INDEX IF EXISTS public.subscriptions_service_name_c_idx;
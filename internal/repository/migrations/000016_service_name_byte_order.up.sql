@@ -0,0 +1,3 @@
+-- The list sorted by service_name compares names byte by byte, like the
+-- in-memory storage does, whatever the collation of the database is.
+CREATE INDEX IF NOT EXISTS subscriptions_service_name_c_idx ON public.subscriptions (service_name COLLATE "C", id);
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
@@ -27,38 +28,48 @@ func InitDatabase(cfg config.Database, log *slog.Logger) (*sql.DB, error) {
 		return nil, err
 	}
 
-	if err = db.Ping(); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.QueryTimeout)
+	defer cancel()
+
+	if err = db.PingContext(ctx); err != nil {
 		log.Error("Error with pinging database", sl.Err(err))
 		return nil, err
 	}
 
+	if err = migrateUp(db, cfg.DBname, log); err != nil {
+		return nil, err
+	}
+
+	log.Info("Database connected successfully")
+	return db, nil
+}
+
+// migrateUp applies the embedded migrations db has not seen yet.
+func migrateUp(db *sql.DB, dbName string, log *slog.Logger) error {
 	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
 		log.Error("failed to create migrate driver", sl.Err(err))
-		return nil, err
+		return err
 	}
 
 	sourceDriver, err := migrateiofs.New(migrationsFS, "migrations")
 	if err != nil {
 		log.Error("failed to create iofs source driver", sl.Err(err))
-		return nil, err
+		return err
 	}
 
 	m, err := migrate.NewWithInstance(
 		"iofs",
 		sourceDriver,
-		cfg.DBname,
+		dbName,
 		driver,
 	)
 	if err != nil {
 		log.Error("failed to create migrate instance", sl.Err(err))
-		return nil, err
+		return err
 	}
 
-	runMigrations(m, "up", log)
-
-	log.Info("Database connected successfully")
-	return db, nil
+	return runMigrations(m, "up", log)
 }
 
 func runMigrations(m *migrate.Migrate, direction string, log *slog.Logger) error {
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+// SubscriptionRepository is the storage used by services.SubscriptionService,
+// made of one interface per domain so that code and fakes needing one of
+// them stay small. SubscriptionRepo keeps everything in Postgres,
+// MemorySubscriptionRepo in memory.
+type SubscriptionRepository interface {
+	SubRepository
+	SpendRepository
+	AuditRepository
+	RateRepository
+	UserRepository
+	CatalogRepository
+	BudgetRepository
+}
+
+// SubRepository stores subscriptions with their scheduled prices, members
+// and lifecycle. Non-empty versions make a change conditional on the
+// current version of the subscription.
+type SubRepository interface {
+	InsertSub(ctx context.Context, subscription *structures.Subscription) (int, error)
+	SelectAllSubs(ctx context.Context, filter *structures.SubscriptionFilter) (structures.SubscriptionPage, error)
+	SelectSubById(ctx context.Context, id int, includeDeleted bool) (structures.Subscription, error)
+	UpdateSub(ctx context.Context, subscription *structures.Subscription, id int, versions []int) error
+	DeleteSub(ctx context.Context, id int, versions []int) error
+	RestoreSub(ctx context.Context, id int, versions []int) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+	SchedulePrice(ctx context.Context, id int, change *structures.PriceChange, versions []int) error
+	DeletePrice(ctx context.Context, id int, effectiveFrom string, versions []int) error
+
+	// SetMembers replaces the users sharing the subscription with id, who
+	// must exist and cannot be deleted while they do.
+	SetMembers(ctx context.Context, id int, members []structures.Member, versions []int) error
+
+	// SetLifecycle stores the status, end date, pauses and cancellation time
+	// of the subscription with id. AdvanceStatuses stores the status in
+	// month, see Subscription.StatusIn, of every subscription it changed for
+	// and returns how many there were.
+	SetLifecycle(ctx context.Context, id int, lifecycle *structures.Lifecycle, versions []int) error
+	AdvanceStatuses(ctx context.Context, month time.Time) (int, error)
+}
+
+// SpendRepository counts what subscriptions cost.
+type SpendRepository interface {
+	// SelectCountedSubs returns the subscriptions matching the Counting
+	// filters, SelectSpend what they cost in the period grouped as query
+	// asks, see Subscription.AmountIn and Subscription.Shares.
+	SelectCountedSubs(ctx context.Context, data *structures.Counting) ([]structures.Subscription, error)
+	SelectSpend(ctx context.Context, data *structures.Counting, query *structures.SpendQuery) (structures.Spend, error)
+
+	// SelectRenewals returns the charges of the subscriptions that are not
+	// deleted selected by query, see Subscription.Charges.
+	SelectRenewals(ctx context.Context, query *structures.RenewalQuery) ([]structures.Renewal, error)
+}
+
+// AuditRepository reads the audit trail. Every change of a subscription is
+// recorded in it together with the actor and the request ID from
+// structures.RequestInfoFrom.
+type AuditRepository interface {
+	SelectHistory(ctx context.Context, id int) ([]structures.AuditEntry, error)
+	SelectAudit(ctx context.Context, filter *structures.AuditFilter) (structures.AuditPage, error)
+}
+
+type RateRepository interface {
+	UpsertRates(ctx context.Context, rates []structures.ExchangeRate) error
+	SelectRates(ctx context.Context, filter *structures.ExchangeRateFilter) ([]structures.ExchangeRate, error)
+}
+
+// UserRepository stores users. InsertUser generates the ID of a user
+// without one. Subscriptions can only refer to existing users, which cannot
+// be deleted while they do.
+type UserRepository interface {
+	InsertUser(ctx context.Context, user *structures.User) (string, error)
+	SelectUsers(ctx context.Context, filter *structures.UserFilter) (structures.UserPage, error)
+	SelectUserById(ctx context.Context, id string) (structures.User, error)
+	UpdateUser(ctx context.Context, user *structures.User, id string) error
+	DeleteUser(ctx context.Context, id string) error
+}
+
+// CatalogRepository stores the service catalog: every name and alias has a
+// structures.ServiceKey unique across services. Subscriptions can only
+// refer to existing services, which cannot be deleted while they do.
+// UpdateService and AssignService change subscriptions like UpdateSub.
+type CatalogRepository interface {
+	InsertService(ctx context.Context, service *structures.Service) (int, error)
+	SelectServices(ctx context.Context) ([]structures.Service, error)
+	SelectServiceById(ctx context.Context, id int) (structures.Service, error)
+	SelectServiceByKey(ctx context.Context, key string) (structures.Service, error)
+	UpdateService(ctx context.Context, service *structures.Service, id int) error
+	DeleteService(ctx context.Context, id int) error
+	SelectUnmatchedNames(ctx context.Context) ([]structures.UnmatchedName, error)
+	AssignService(ctx context.Context, serviceName string, service *structures.Service) (int, error)
+}
+
+// BudgetRepository stores budgets. They belong to existing users and
+// services and are deleted with them, a user has at most one budget per
+// scope.
+type BudgetRepository interface {
+	InsertBudget(ctx context.Context, budget *structures.Budget) (int, error)
+	SelectBudgets(ctx context.Context, userID string) ([]structures.Budget, error)
+	SelectBudgetById(ctx context.Context, id int) (structures.Budget, error)
+	UpdateBudget(ctx context.Context, budget *structures.Budget, id int) error
+	DeleteBudget(ctx context.Context, id int) error
+}
+
+var (
+	_ SubscriptionRepository = (*SubscriptionRepo)(nil)
+	_ SubscriptionRepository = (*MemorySubscriptionRepo)(nil)
+)
+
+// versionMatches reports whether version is one of versions, an empty list
+// matching any version.
+func versionMatches(versions []int, version int) bool {
+	return len(versions) == 0 || slices.Contains(versions, version)
+}
+
+// checkWritable checks that subscription can be updated or deleted: it must
+// not be soft-deleted and its version must match versions.
+func checkWritable(op string, subscription *structures.Subscription, versions []int) error {
+	if subscription.DeletedAt != nil {
+		return fmt.Errorf("%s: %w: no subs with id:%d", op, structures.ErrNotFound, subscription.ID)
+	}
+
+	if !versionMatches(versions, subscription.Version) {
+		return fmt.Errorf("%s: %w: sub %d was modified", op, structures.ErrPreconditionFailed, subscription.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,522 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+// chargeCTE expands the subscriptions matching the Counting filters ($1-$8)
+// into the months of the period they are billed in, see IsBilledIn, with
+// the amount of a single charge, how many charges there are and the day of
+// the month the first one is on, see ChargeDatesIn.
+var chargeCTE = `
+	WITH months AS (
+		SELECT month::date AS month,
+			(month + interval '1 month')::date - month::date AS days
+		FROM generate_series(
+			to_date($1, 'MM-YYYY'),
+			to_date($2, 'MM-YYYY'),
+			interval '1 month'
+		) AS month
+	),
+	subs AS (
+		SELECT id, service_name, category, user_id, currency, price,
+			billing_period, trial_end_date, trial_price, start_date, end_date,
+			COALESCE((trial_end_date + interval '1 month')::date, start_date) AS billing_start,
+			CASE billing_period
+				WHEN 'monthly' THEN 1
+				WHEN 'quarterly' THEN 3
+				WHEN 'yearly' THEN 12
+				WHEN 'custom' THEN billing_months
+				ELSE 0
+			END AS period
+		FROM subscriptions
+		WHERE start_date <= to_date($2, 'MM-YYYY')
+		  AND (end_date IS NULL OR end_date >= to_date($1, 'MM-YYYY'))
+		  AND ($3 = '' OR user_id = $3::uuid OR EXISTS (
+			SELECT 1
+			FROM subscription_members m
+			WHERE m.subscription_id = subscriptions.id AND m.user_id = $3::uuid
+		  ))
+		  AND ($4 = '' OR service_name = $4)
+		  AND ($5 OR deleted_at IS NULL)
+		  AND ($6 = 0 OR service_id = $6)
+		  AND ($7 = '' OR category = $7)
+		  AND ($8 = '' OR $8 = ANY(tags))
+	),
+	billed AS (
+		SELECT m.month, m.days, s.id, s.service_name, s.category, s.user_id, s.currency,
+			s.billing_period, s.period, s.trial_price,
+			s.trial_end_date IS NOT NULL AND m.month <= s.trial_end_date AS trial,
+			COALESCE(p.price, s.price) AS price,
+			(extract(year FROM m.month)::int - extract(year FROM s.billing_start)::int) * 12
+				+ extract(month FROM m.month)::int - extract(month FROM s.billing_start)::int AS elapsed,
+			m.month - s.billing_start AS elapsed_days
+		FROM months m
+		JOIN subs s
+			ON s.start_date <= m.month
+		   AND (s.end_date IS NULL OR s.end_date >= m.month)
+		LEFT JOIN LATERAL (
+			SELECT p.price
+			FROM subscription_prices p
+			WHERE p.subscription_id = s.id
+			  AND p.effective_from <= m.month
+			ORDER BY p.effective_from DESC
+			LIMIT 1
+		) p ON true
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM subscription_pauses z
+			WHERE z.subscription_id = s.id
+			  AND z.paused_from <= m.month
+			  AND (z.paused_to IS NULL OR z.paused_to >= m.month)
+		)
+		  AND (s.trial_end_date IS NULL OR m.month > s.trial_end_date OR s.trial_price > 0)
+	),
+	charged AS (
+		SELECT billed.*,
+			CASE WHEN trial THEN trial_price ELSE price END AS charge,
+			CASE
+				WHEN trial THEN 1
+				WHEN billing_period = 'weekly' THEN (days - (7 - elapsed_days % 7) % 7 + 6) / 7
+				WHEN period > 0 THEN CASE WHEN elapsed % period = 0 THEN 1 ELSE 0 END
+				ELSE 0
+			END AS charges,
+			CASE
+				WHEN NOT trial AND billing_period = 'weekly' THEN (7 - elapsed_days % 7) % 7
+				ELSE 0
+			END AS first_day
+		FROM billed
+	)
+`
+
+// spendCTE computes in SQL what structures.Subscription and the memory
+// storage compute in Go. It takes the billed months of chargeCTE with what
+// they cost in mode $10, see AmountIn, converted to currency $9 with the
+// rate of each month, see Money.Convert. $11 is what amounts in $9 scaled to
+// structures.MaxExponent are divided by. The shares of the users paying for
+// every month follow Subscription.Shares.
+//
+// Converted amounts are rounded half away from zero with integer division,
+// numeric division would round the quotient to its scale first. Rates have
+// at most 10 fractional digits.
+var spendCTE = chargeCTE + `,
+	amounts AS (
+		SELECT month, id, service_name, category, user_id, currency, price,
+			CASE
+				WHEN $10 = 'charges' THEN charge * charges
+				WHEN trial THEN charge
+				WHEN billing_period = 'weekly' THEN round(charge::numeric * days / 7)::bigint
+				WHEN period > 0 THEN round(charge::numeric / period)::bigint
+				ELSE 0
+			END AS amount
+		FROM charged
+		WHERE $10 <> 'charges' OR charges > 0
+	),
+	applied AS (
+		SELECT month, currency, inverted,
+			CASE WHEN inverted THEN inverse_rate ELSE direct_rate END AS rate,
+			CASE WHEN inverted THEN inverse_month ELSE direct_month END AS rate_month
+		FROM (
+			SELECT c.month, c.currency,
+				d.rate AS direct_rate, d.month AS direct_month,
+				i.rate AS inverse_rate, i.month AS inverse_month,
+				d.month IS NULL OR COALESCE(i.month > d.month, false) AS inverted
+			FROM (SELECT DISTINCT month, currency FROM amounts WHERE currency <> $9) c
+			LEFT JOIN LATERAL (
+				SELECT r.rate, r.month
+				FROM exchange_rates r
+				WHERE r.from_currency = c.currency AND r.to_currency = $9 AND r.month <= c.month
+				ORDER BY r.month DESC
+				LIMIT 1
+			) d ON true
+			LEFT JOIN LATERAL (
+				SELECT r.rate, r.month
+				FROM exchange_rates r
+				WHERE r.from_currency = $9 AND r.to_currency = c.currency AND r.month <= c.month
+				ORDER BY r.month DESC
+				LIMIT 1
+			) i ON true
+		) rates
+	),
+	converted AS (
+		SELECT a.month, a.id, a.service_name, a.category, a.user_id, a.price,
+			CASE
+				WHEN a.currency = $9 THEN a.amount
+				WHEN r.rate IS NULL THEN 0
+				ELSE sign(x.num) * div(2 * abs(x.num) + x.den, 2 * x.den)
+			END::bigint AS amount
+		FROM amounts a
+		LEFT JOIN applied r ON r.month = a.month AND r.currency = a.currency
+		CROSS JOIN LATERAL (
+			SELECT
+				CASE WHEN r.inverted
+					THEN a.amount::numeric * ` + exponentScale("a.currency") + ` * 10000000000
+					ELSE a.amount::numeric * ` + exponentScale("a.currency") + ` * r.rate * 10000000000
+				END AS num,
+				CASE WHEN r.inverted
+					THEN $11::numeric * r.rate * 10000000000
+					ELSE $11::numeric * 10000000000
+				END AS den
+		) x
+	),
+	payers AS (
+		SELECT s.id, s.user_id AS payer, 0::bigint AS payer_index,
+			COALESCE(m.weight, 0) AS weight, COALESCE(m.amount, 0) AS fixed
+		FROM subs s
+		LEFT JOIN subscription_members m ON m.subscription_id = s.id AND m.user_id = s.user_id
+		UNION ALL
+		SELECT m.subscription_id, m.user_id,
+			row_number() OVER (PARTITION BY m.subscription_id ORDER BY m.user_id),
+			m.weight, m.amount
+		FROM subscription_members m
+		JOIN subs s ON s.id = m.subscription_id AND m.user_id <> s.user_id
+	),
+	parts AS (
+		SELECT month, id, amount, payer, payer_index, fixed, weight, price,
+			sum(fixed) OVER sub_month AS total_fixed,
+			sum(weight) OVER sub_month AS total_weight
+		FROM (
+			SELECT c.month, c.id, c.amount, p.payer, p.payer_index,
+				CASE WHEN p.fixed > 0 AND c.price > 0 THEN p.fixed ELSE 0 END AS fixed,
+				CASE WHEN p.fixed > 0 THEN 0 WHEN p.weight > 0 THEN p.weight ELSE 1 END AS weight,
+				GREATEST(c.price, 1) AS price
+			FROM converted c
+			JOIN payers p ON p.id = c.id
+		) payer_months
+		WINDOW sub_month AS (PARTITION BY month, id)
+	),
+	splits AS (
+		SELECT month, id, amount, payer, payer_index,
+			div(amount * part, whole) AS share,
+			mod(amount * part, whole) AS remainder
+		FROM (
+			SELECT parts.*,
+				CASE
+					WHEN total_fixed > price THEN fixed::numeric
+					WHEN total_weight = 0 THEN fixed + CASE WHEN payer_index = 0 THEN price - total_fixed ELSE 0 END
+					ELSE fixed::numeric * total_weight + (price - total_fixed) * weight
+				END AS part,
+				CASE
+					WHEN total_fixed > price THEN total_fixed
+					WHEN total_weight = 0 THEN price::numeric
+					ELSE price::numeric * total_weight
+				END AS whole
+			FROM parts
+		) fractions
+	),
+	shares AS (
+		SELECT month, id, payer,
+			(share + CASE
+				WHEN row_number() OVER (PARTITION BY month, id ORDER BY remainder DESC, payer_index)
+					<= amount - sum(share) OVER (PARTITION BY month, id) THEN 1
+				ELSE 0
+			END)::bigint AS amount
+		FROM splits
+	)
+`
+
+// SelectSpend counts the spend in Postgres and returns only the groups
+// query asks for.
+func (r *SubscriptionRepo) SelectSpend(
+	ctx context.Context,
+	data *structures.Counting,
+	query *structures.SpendQuery,
+) (structures.Spend, error) {
+	const op = "repository.subscriptionRepo.SelectSpend"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	target := data.TargetCurrency
+	if target == "" {
+		target = structures.DefaultCurrency
+	}
+
+	args := []any{
+		data.StartDate,
+		data.EndDate,
+		data.UserID,
+		data.ServiceName,
+		data.IncludeDeleted,
+		data.ServiceID,
+		data.Category,
+		data.Tag,
+		target,
+		data.Mode,
+		pow10(structures.MaxExponent - structures.CurrencyExponent(target)),
+	}
+
+	// The shares are only split when they are filtered or grouped by payer,
+	// without that they add up to the amount of every month.
+	counted := `
+		SELECT month, id, service_name, category, user_id AS owner, user_id AS payer, amount
+		FROM converted
+	`
+	switch {
+	case query.GroupBy == structures.GroupByDebt:
+		counted = `
+			SELECT c.month, c.id, c.service_name, c.category, c.user_id AS owner, s.payer, s.amount
+			FROM shares s
+			JOIN converted c ON c.month = s.month AND c.id = s.id
+			WHERE s.payer <> c.user_id
+			  AND ($3 = '' OR s.payer = $3::uuid OR c.user_id = $3::uuid)
+		`
+	case data.UserID != "" || query.GroupBy == structures.GroupByUser:
+		counted = `
+			SELECT c.month, c.id, c.service_name, c.category, c.user_id AS owner, s.payer, s.amount
+			FROM shares s
+			JOIN converted c ON c.month = s.month AND c.id = s.id
+			WHERE $3 = '' OR s.payer = $3::uuid
+		`
+	}
+
+	// The columns of a group, the ones not grouped by stay empty.
+	month, key, id, serviceName, owner, ids := "''", "''", "0", "''", "''", "'null'::json"
+	var groupBy, orderBy []string
+	if query.ByMonth {
+		month = "to_char(month, 'MM-YYYY')"
+		groupBy = append(groupBy, "month")
+		orderBy = append(orderBy, "month")
+	}
+
+	switch query.GroupBy {
+	case structures.GroupByService:
+		key = "service_name"
+		groupBy = append(groupBy, key)
+		orderBy = append(orderBy, "sum(amount) DESC", key+` COLLATE "C"`)
+	case structures.GroupByCategory:
+		key = "category"
+		groupBy = append(groupBy, key)
+		orderBy = append(orderBy, "sum(amount) DESC", key+` COLLATE "C"`)
+	case structures.GroupByUser:
+		key = "payer::text"
+		groupBy = append(groupBy, key)
+		orderBy = append(orderBy, "sum(amount) DESC", key+` COLLATE "C"`)
+	case structures.GroupByDebt:
+		key, owner = "payer::text", "owner::text"
+		ids = "json_agg(DISTINCT id ORDER BY id)"
+		groupBy = append(groupBy, key, owner)
+		orderBy = append(orderBy, "sum(amount) DESC", key+` COLLATE "C"`, owner+` COLLATE "C"`)
+	case structures.GroupBySubscription:
+		id, serviceName, owner = "id", "service_name", "owner::text"
+		groupBy = append(groupBy, id, serviceName, owner)
+		orderBy = append(orderBy, "sum(amount) DESC", id)
+	}
+
+	statement := spendCTE + `,
+		counted AS (` + counted + `)
+		SELECT ` + strings.Join([]string{month, key, id, serviceName, owner}, ", ") + `,
+			COALESCE(sum(amount), 0)::bigint,
+			count(DISTINCT id),
+			` + ids + `,
+			(
+				SELECT COALESCE(json_agg(json_build_object(
+					'month', to_char(applied.month, 'MM-YYYY'),
+					'currency', applied.currency,
+					'rate', applied.rate::text,
+					'rate_month', to_char(applied.rate_month, 'MM-YYYY'),
+					'inverted', applied.inverted
+				) ORDER BY applied.month, applied.currency), '[]')
+				FROM applied
+			)
+		FROM counted
+	`
+	if len(groupBy) > 0 {
+		statement += "GROUP BY " + strings.Join(groupBy, ", ") + "\nORDER BY " + strings.Join(orderBy, ", ")
+	}
+	if query.Top > 0 {
+		args = append(args, query.Top)
+		statement += fmt.Sprintf("\nLIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		log.Error("Failed to select spend", sl.Err(err))
+		return structures.Spend{}, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	spend := structures.Spend{
+		Groups:       []structures.SpendGroup{},
+		Rates:        []structures.AppliedRate{},
+		MissingRates: []structures.MissingRate{},
+	}
+
+	// Every row holds all the rates.
+	var rates []byte
+
+	for rows.Next() {
+		var group structures.SpendGroup
+		var subscriptionIDs []byte
+
+		err := rows.Scan(
+			&group.Month,
+			&group.Key,
+			&group.SubscriptionID,
+			&group.ServiceName,
+			&group.Owner,
+			&group.Total,
+			&group.Subscriptions,
+			&subscriptionIDs,
+			&rates,
+		)
+		if err != nil {
+			log.Error("Failed to scan spend", sl.Err(err))
+			return structures.Spend{}, wrapError(op, err)
+		}
+
+		if err := json.Unmarshal(subscriptionIDs, &group.SubscriptionIDs); err != nil {
+			log.Error("Failed to decode subscription ids", sl.Err(err))
+			return structures.Spend{}, wrapError(op, err)
+		}
+
+		spend.Groups = append(spend.Groups, group)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return structures.Spend{}, wrapError(op, err)
+	}
+
+	if rates == nil {
+		return spend, nil
+	}
+
+	var applied []struct {
+		Month     string  `json:"month"`
+		Currency  string  `json:"currency"`
+		Rate      *string `json:"rate"`
+		RateMonth string  `json:"rate_month"`
+		Inverted  bool    `json:"inverted"`
+	}
+	if err := json.Unmarshal(rates, &applied); err != nil {
+		log.Error("Failed to decode rates", sl.Err(err))
+		return structures.Spend{}, wrapError(op, err)
+	}
+
+	missing := make(map[string]bool)
+	for _, rate := range applied {
+		// The rates are ordered by month, the first missing one is reported.
+		if rate.Rate == nil {
+			if !missing[rate.Currency] {
+				missing[rate.Currency] = true
+				spend.MissingRates = append(spend.MissingRates, structures.MissingRate{Currency: rate.Currency, Month: rate.Month})
+			}
+			continue
+		}
+
+		// numeric pads the rate with zeros to its scale.
+		value, err := structures.ParseRate(*rate.Rate)
+		if err != nil {
+			log.Error("Failed to parse rate", sl.Err(err))
+			return structures.Spend{}, wrapError(op, err)
+		}
+
+		spend.Rates = append(spend.Rates, structures.AppliedRate{
+			Month:     rate.Month,
+			From:      rate.Currency,
+			To:        target,
+			Rate:      value,
+			RateMonth: rate.RateMonth,
+			Inverted:  rate.Inverted,
+		})
+	}
+
+	slices.SortFunc(spend.MissingRates, func(a, b structures.MissingRate) int {
+		return strings.Compare(a.Currency, b.Currency)
+	})
+
+	return spend, nil
+}
+
+func (r *SubscriptionRepo) SelectRenewals(ctx context.Context, query *structures.RenewalQuery) ([]structures.Renewal, error) {
+	const op = "repository.subscriptionRepo.SelectRenewals"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	from, err := time.Parse(structures.DateLayout, query.From)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	until, err := time.Parse(structures.DateLayout, query.Until)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Every charge of a weekly subscription is a week after the previous
+	// one, the others are charged once a month on its first day.
+	statement := chargeCTE + `,
+		renewals AS (
+			SELECT c.month + c.first_day + 7 * k AS date, c.id, c.service_name,
+				c.user_id::text AS user_id, c.charge, c.currency
+			FROM charged c
+			CROSS JOIN LATERAL generate_series(0, c.charges - 1) AS k
+		)
+		SELECT to_char(date, 'YYYY-MM-DD'), id, service_name, user_id, charge, currency
+		FROM renewals
+		WHERE date BETWEEN $9::date AND $10::date
+		  AND ($11 = '' OR (date, id) > ($11::date, $12))
+		ORDER BY date, id
+		LIMIT $13
+	`
+
+	rows, err := r.db.QueryContext(ctx, statement,
+		structures.FormatMonth(from),
+		structures.FormatMonth(until),
+		query.UserID,
+		"",
+		false,
+		0,
+		"",
+		"",
+		query.From,
+		query.Until,
+		query.AfterDate,
+		query.AfterID,
+		query.Limit,
+	)
+	if err != nil {
+		log.Error("Failed to select renewals", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	renewals := []structures.Renewal{}
+	for rows.Next() {
+		var renewal structures.Renewal
+		if err := rows.Scan(
+			&renewal.Date,
+			&renewal.SubscriptionID,
+			&renewal.ServiceName,
+			&renewal.UserID,
+			&renewal.Amount,
+			&renewal.Currency,
+		); err != nil {
+			log.Error("Failed to scan renewal", sl.Err(err))
+			return nil, wrapError(op, err)
+		}
+
+		renewals = append(renewals, renewal)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	return renewals, nil
+}
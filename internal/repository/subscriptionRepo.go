@@ -1,229 +1,962 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
 	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+	"github.com/lib/pq"
 )
 
+// Dates are stored as the first day of the month and exposed as MM-YYYY.
+// Scheduled price changes, pauses and members are aggregated into JSON arrays.
+const subscriptionColumns = `
+	id, service_name, price, currency,
+	billing_period, COALESCE(billing_months, 0),
+	user_id,
+	to_char(start_date, 'MM-YYYY'),
+	COALESCE(to_char(end_date, 'MM-YYYY'), ''),
+	version, deleted_at,
+	COALESCE((
+		SELECT json_agg(json_build_object(
+			'effective_from', to_char(p.effective_from, 'MM-YYYY'),
+			'price', p.price
+		) ORDER BY p.effective_from)
+		FROM subscription_prices p
+		WHERE p.subscription_id = subscriptions.id
+	), '[]'),
+	COALESCE(to_char(trial_end_date, 'MM-YYYY'), ''),
+	trial_price, status, cancelled_at,
+	COALESCE((
+		SELECT json_agg(json_build_object(
+			'from', to_char(z.paused_from, 'MM-YYYY'),
+			'to', COALESCE(to_char(z.paused_to, 'MM-YYYY'), '')
+		) ORDER BY z.paused_from)
+		FROM subscription_pauses z
+		WHERE z.subscription_id = subscriptions.id
+	), '[]'),
+	COALESCE(service_id, 0),
+	category, tags,
+	COALESCE((
+		SELECT json_agg(json_build_object(
+			'user_id', m.user_id,
+			'weight', m.weight,
+			'amount', m.amount
+		) ORDER BY m.user_id)
+		FROM subscription_members m
+		WHERE m.subscription_id = subscriptions.id
+	), '[]')
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSubscription scans a row selected with subscriptionColumns.
+func scanSubscription(row rowScanner, subscription *structures.Subscription) error {
+	var prices, pauses, members []byte
+
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.ServiceName,
+		&subscription.Price,
+		&subscription.Currency,
+		&subscription.BillingPeriod,
+		&subscription.BillingMonths,
+		&subscription.UserID,
+		&subscription.StartDate,
+		&subscription.EndDate,
+		&subscription.Version,
+		&subscription.DeletedAt,
+		&prices,
+		&subscription.TrialEndDate,
+		&subscription.TrialPrice,
+		&subscription.Status,
+		&subscription.CancelledAt,
+		&pauses,
+		&subscription.ServiceID,
+		&subscription.Category,
+		pq.Array(&subscription.Tags),
+		&members,
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(subscription.Tags) == 0 {
+		subscription.Tags = nil
+	}
+
+	// The prices column holds minor units, while structures.PriceChange
+	// has no JSON form of its own.
+	var changes []struct {
+		EffectiveFrom string `json:"effective_from"`
+		Price         int64  `json:"price"`
+	}
+	if err := json.Unmarshal(prices, &changes); err != nil {
+		return err
+	}
+
+	subscription.Prices = nil
+	for _, change := range changes {
+		subscription.Prices = append(subscription.Prices, structures.PriceChange{
+			EffectiveFrom: change.EffectiveFrom,
+			Price:         structures.Money(change.Price),
+		})
+	}
+
+	subscription.Pauses = nil
+	if err := json.Unmarshal(pauses, &subscription.Pauses); err != nil {
+		return err
+	}
+	if len(subscription.Pauses) == 0 {
+		subscription.Pauses = nil
+	}
+
+	// Amounts are minor units like prices.
+	var shares []struct {
+		UserID string `json:"user_id"`
+		Weight int    `json:"weight"`
+		Amount int64  `json:"amount"`
+	}
+	if err := json.Unmarshal(members, &shares); err != nil {
+		return err
+	}
+
+	subscription.Members = nil
+	for _, share := range shares {
+		subscription.Members = append(subscription.Members, structures.Member{
+			UserID: share.UserID,
+			Weight: share.Weight,
+			Amount: structures.Money(share.Amount),
+		})
+	}
+
+	return nil
+}
+
+type sortColumn struct {
+	column string
+	cast   string
+	value  func(subscription *structures.Subscription) string
+}
+
+var sortColumns = map[string]sortColumn{
+	structures.SortByID: {
+		column: "id",
+		cast:   "%s::integer",
+		value:  func(s *structures.Subscription) string { return strconv.Itoa(s.ID) },
+	},
+	structures.SortByPrice: {
+		column: scaledPrice,
+		cast:   "%s::numeric",
+		value:  func(s *structures.Subscription) string { return strconv.FormatInt(int64(s.ScaledPrice()), 10) },
+	},
+	structures.SortByStartDate: {
+		column: "start_date",
+		cast:   "to_date(%s, 'MM-YYYY')",
+		value:  func(s *structures.Subscription) string { return s.StartDate },
+	},
+	structures.SortByServiceName: {
+		column: `service_name COLLATE "C"`,
+		cast:   "%s::text",
+		value:  func(s *structures.Subscription) string { return s.ServiceName },
+	},
+}
+
+// scaledPrice is structures.Subscription.ScaledPrice in SQL.
+var scaledPrice = "price::numeric * " + exponentScale("currency")
+
+// exponentScale returns what minor units of the currency in column are
+// multiplied by to scale them to structures.MaxExponent.
+func exponentScale(column string) string {
+	exponents := structures.CurrencyExponents()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CASE %s", column)
+	for _, currency := range slices.Sorted(maps.Keys(exponents)) {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", currency, pow10(structures.MaxExponent-exponents[currency]))
+	}
+	fmt.Fprintf(&b, " ELSE %d END", pow10(structures.MaxExponent-structures.CurrencyExponent("")))
+
+	return b.String()
+}
+
+func pow10(exponent int) int64 {
+	unit := int64(1)
+	for range exponent {
+		unit *= 10
+	}
+
+	return unit
+}
+
+// priceBounds parses the min_price and max_price of filter, nil if unset.
+func priceBounds(filter *structures.SubscriptionFilter) (minPrice, maxPrice *structures.Money, err error) {
+	parse := func(field string, value structures.Decimal) (*structures.Money, error) {
+		if value == "" {
+			return nil, nil
+		}
+
+		bound, err := structures.PriceBound(value)
+		if err != nil {
+			return nil, structures.NewFieldError(field, err.Error())
+		}
+
+		return &bound, nil
+	}
+
+	if minPrice, err = parse("min_price", filter.MinPrice); err != nil {
+		return nil, nil, err
+	}
+
+	maxPrice, err = parse("max_price", filter.MaxPrice)
+	return minPrice, maxPrice, err
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
 type SubscriptionRepo struct {
-	db  *sql.DB
-	log *slog.Logger
+	db      *sql.DB
+	timeout time.Duration
+	log     *slog.Logger
 }
 
 func NewSubsriptionRepo(
 	db *sql.DB,
+	timeout time.Duration,
 	log *slog.Logger,
 ) *SubscriptionRepo {
 	return &SubscriptionRepo{
-		db:  db,
-		log: log,
+		db:      db,
+		timeout: timeout,
+		log:     log,
 	}
 }
 
-func (r *SubscriptionRepo) InsertSub(subscription *structures.Subscription) (int, error) {
+func (r *SubscriptionRepo) InsertSub(ctx context.Context, subscription *structures.Subscription) (int, error) {
 	const op = "repository.subscriptionRepo.InsertSub"
 	log := r.log.With("op", op)
 
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
 	log.Info("Inserting subscription", slog.Any("subscription", subscription))
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING ID
-	`
+		INSERT INTO subscriptions (
+			service_name, price, currency, billing_period, billing_months, user_id, start_date, end_date,
+			trial_end_date, trial_price, status, service_id, category, tags
+		)
+		VALUES (
+			$1, $2, $3, $4, NULLIF($5, 0), $6, to_date($7, 'MM-YYYY'), to_date(NULLIF($8, ''), 'MM-YYYY'),
+			to_date(NULLIF($9, ''), 'MM-YYYY'), $10, $11, NULLIF($12, 0), $13, $14
+		)
+		RETURNING ` + subscriptionColumns
 
-	var id int
+	var inserted structures.Subscription
 
-	err := r.db.QueryRow(
+	err = scanSubscription(tx.QueryRowContext(
+		ctx,
 		query,
 		subscription.ServiceName,
 		subscription.Price,
+		subscription.Currency,
+		subscription.BillingPeriod,
+		subscription.BillingMonths,
 		subscription.UserID,
 		subscription.StartDate,
 		subscription.EndDate,
-	).Scan(&id)
+		subscription.TrialEndDate,
+		subscription.TrialPrice,
+		subscription.Status,
+		subscription.ServiceID,
+		subscription.Category,
+		pq.Array(subscription.Tags),
+	), &inserted)
 
 	if err != nil {
 		log.Error("Failed to insert sub", sl.Err(err))
-		return 0, err
+		return 0, wrapError(op, err)
 	}
 
-	log.Debug("Inserted successfully", slog.Int("id", id))
-	return id, nil
+	if err := insertAudit(ctx, tx, structures.AuditCreate, nil, &inserted); err != nil {
+		log.Error("Failed to audit insert", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit insert", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	subscription.ID = inserted.ID
+	subscription.Version = inserted.Version
+
+	log.Debug("Inserted successfully", slog.Int("id", subscription.ID))
+	return subscription.ID, nil
 }
 
-func (r *SubscriptionRepo) SelectAllSubs() ([]structures.Subscription, error) {
+func (r *SubscriptionRepo) SelectAllSubs(ctx context.Context, filter *structures.SubscriptionFilter) (structures.SubscriptionPage, error) {
 	const op = "repository.subscriptionRepo.SelectAllSubs"
 	log := r.log.With("op", op)
 
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	page := structures.SubscriptionPage{Subscriptions: []structures.Subscription{}}
+
+	sortField, desc := filter.SortField()
+	sort, ok := sortColumns[sortField]
+	if !ok {
+		return page, fmt.Errorf("%s: %w", op, structures.NewFieldError("sort", "unknown sort field"))
+	}
+
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var conditions []string
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if filter.UserID != "" {
+		conditions = append(conditions, "user_id = "+arg(filter.UserID)+"::uuid")
+	}
+	if filter.ServiceName != "" {
+		conditions = append(conditions, "service_name = "+arg(filter.ServiceName))
+	}
+	if filter.ServiceID != 0 {
+		conditions = append(conditions, "service_id = "+arg(filter.ServiceID))
+	}
+	if filter.Category != "" {
+		conditions = append(conditions, "category = "+arg(filter.Category))
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions, arg(filter.Tag)+" = ANY(tags)")
+	}
+	if filter.ActiveAt != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			"start_date <= to_date(%[1]s, 'MM-YYYY') AND (end_date IS NULL OR end_date >= to_date(%[1]s, 'MM-YYYY'))",
+			arg(filter.ActiveAt),
+		))
+	}
+	minPrice, maxPrice, err := priceBounds(filter)
+	if err != nil {
+		return page, fmt.Errorf("%s: %w", op, err)
+	}
+	if minPrice != nil {
+		conditions = append(conditions, scaledPrice+" >= "+arg(*minPrice))
+	}
+	if maxPrice != nil {
+		conditions = append(conditions, scaledPrice+" <= "+arg(*maxPrice))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+arg(filter.Status))
+	}
+
+	countQuery := `
+		SELECT COUNT(*)
+		FROM subscriptions
+	` + whereClause(conditions)
+
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&page.Total); err != nil {
+		log.Error("Failed to count subscriptions", sl.Err(err))
+		return page, wrapError(op, err)
+	}
+
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := structures.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return page, fmt.Errorf("%s: %w", op, structures.NewFieldError("cursor", "is malformed"))
+		}
+
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s, id) %s (%s, %s::integer)",
+			sort.column, comparison, fmt.Sprintf(sort.cast, arg(cursor.Value)), arg(cursor.ID),
+		))
+	}
+
+	// One extra row tells whether there is a next page.
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
-		ORDER BY id DESC
-	`
+	` + whereClause(conditions) + fmt.Sprintf(`
+		ORDER BY %[1]s %[2]s, id %[2]s
+		LIMIT %[3]s OFFSET %[4]s
+	`, sort.column, direction, arg(filter.Limit+1), arg(filter.Offset))
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		log.Error("Failed to execute query", sl.Err(err))
-		return nil, fmt.Errorf("%s:%w", op, err)
+		return page, wrapError(op, err)
 	}
 
 	defer rows.Close()
 
-	var subscriptions []structures.Subscription
-
 	for rows.Next() {
 		var subscription structures.Subscription
 
-		err := rows.Scan(
-			&subscription.ID,
-			&subscription.ServiceName,
-			&subscription.Price,
-			&subscription.UserID,
-			&subscription.StartDate,
-			&subscription.EndDate,
-		)
-		if err != nil {
-			log.Error("Failed to fetch subscriptions", sl.Err(err))
-			continue
+		if err := scanSubscription(rows, &subscription); err != nil {
+			log.Error("Failed to scan sub", sl.Err(err))
+			return page, wrapError(op, err)
 		}
 
-		subscriptions = append(subscriptions, subscription)
+		page.Subscriptions = append(page.Subscriptions, subscription)
 	}
 
 	if err = rows.Err(); err != nil {
 		log.Error("Rows iteration error", sl.Err(err))
-		return nil, fmt.Errorf("%s: %v", op, err)
+		return page, wrapError(op, err)
 	}
 
-	return subscriptions, nil
+	if len(page.Subscriptions) > filter.Limit {
+		page.Subscriptions = page.Subscriptions[:filter.Limit]
+		last := page.Subscriptions[len(page.Subscriptions)-1]
+		page.NextCursor = structures.EncodeCursor(structures.PageCursor{
+			Sort:  filter.Sort,
+			Value: sort.value(&last),
+			ID:    last.ID,
+		})
+	}
+
+	return page, nil
 }
 
-func (r *SubscriptionRepo) SelectSubById(id int) (structures.Subscription, error) {
+func (r *SubscriptionRepo) SelectSubById(ctx context.Context, id int, includeDeleted bool) (structures.Subscription, error) {
 	const op = "repository.subscriptionRepo.SelectSubById"
 	log := r.log.With("op", op)
 
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var subscription structures.Subscription
 
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
 		WHERE id = $1
+		  AND ($2 OR deleted_at IS NULL)
 	`
 
-	err := r.db.QueryRow(query, id).Scan(
-		&subscription.ID,
-		&subscription.ServiceName,
-		&subscription.Price,
-		&subscription.UserID,
-		&subscription.StartDate,
-		&subscription.EndDate,
-	)
-
+	err := scanSubscription(r.db.QueryRowContext(ctx, query, id, includeDeleted), &subscription)
 	if err != nil {
 		log.Error("Failed to select sub", sl.Err(err))
-		return subscription, fmt.Errorf("%s:%v", op, err)
+		return subscription, wrapError(op, err)
 	}
 
 	return subscription, nil
 }
 
-func (r *SubscriptionRepo) UpdateSub(subscription *structures.Subscription, id int) error {
+// UpdateSub overwrites the subscription with id and stores its new version in
+// subscription.Version. A non-empty versions list makes the update conditional:
+// it fails with ErrPreconditionFailed unless the current version is one of them.
+func (r *SubscriptionRepo) UpdateSub(ctx context.Context, subscription *structures.Subscription, id int, versions []int) error {
 	const op = "repository.subscriptionsRepo.UpdateSub"
 	log := r.log.With("op", op)
 
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockSub(ctx, tx, id)
+	if err != nil {
+		log.Error("Failed to lock sub", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := checkWritable(op, &before, versions); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE subscriptions
 		SET service_name = $1,
 			price = $2,
-			user_id = $3,
-			start_date = $4,
-			end_date = $5
-		WHERE id = $6
-	`
-
-	result, err := r.db.Exec(query,
+			currency = $3,
+			billing_period = $4,
+			billing_months = NULLIF($5, 0),
+			user_id = $6,
+			start_date = to_date($7, 'MM-YYYY'),
+			end_date = to_date(NULLIF($8, ''), 'MM-YYYY'),
+			trial_end_date = to_date(NULLIF($9, ''), 'MM-YYYY'),
+			trial_price = $10,
+			service_id = NULLIF($11, 0),
+			category = $12,
+			tags = $13,
+			version = version + 1
+		WHERE id = $14
+		RETURNING ` + subscriptionColumns
+
+	var after structures.Subscription
+
+	err = scanSubscription(tx.QueryRowContext(
+		ctx,
+		query,
 		subscription.ServiceName,
 		subscription.Price,
+		subscription.Currency,
+		subscription.BillingPeriod,
+		subscription.BillingMonths,
 		subscription.UserID,
 		subscription.StartDate,
 		subscription.EndDate,
+		subscription.TrialEndDate,
+		subscription.TrialPrice,
+		subscription.ServiceID,
+		subscription.Category,
+		pq.Array(subscription.Tags),
 		id,
-	)
+	), &after)
 
 	if err != nil {
 		log.Error("Failed to update sub", sl.Err(err))
-		return fmt.Errorf("%s:%v", op, err)
+		return wrapError(op, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Error("Failed to get affected rows", sl.Err(err))
-		return fmt.Errorf("%s:%v", op, err)
+	if err := insertAudit(ctx, tx, structures.AuditUpdate, &before, &after); err != nil {
+		log.Error("Failed to audit update", sl.Err(err))
+		return wrapError(op, err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("%s: no subs with id:%d", op, subscription.ID)
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit update", sl.Err(err))
+		return wrapError(op, err)
 	}
 
-	log.Info("Subscription updated", slog.Int("id", subscription.ID))
+	subscription.Version = after.Version
+
+	log.Info("Subscription updated", slog.Int("id", id), slog.Int("version", subscription.Version))
 	return nil
 }
 
-func (r *SubscriptionRepo) DeleteSub(id int) error {
+// DeleteSub soft-deletes the subscription with id, versions works like in UpdateSub.
+func (r *SubscriptionRepo) DeleteSub(ctx context.Context, id int, versions []int) error {
 	const op = "repository.subscriptionsRepo.DeleteSub"
 	log := r.log.With("op", op)
 
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockSub(ctx, tx, id)
+	if err != nil {
+		log.Error("Failed to lock sub", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := checkWritable(op, &before, versions); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE subscriptions
+		SET deleted_at = now(),
+			version = version + 1
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	var after structures.Subscription
+
+	if err := scanSubscription(tx.QueryRowContext(ctx, query, id), &after); err != nil {
+		log.Error("Failed to delete sub", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := insertAudit(ctx, tx, structures.AuditDelete, &before, &after); err != nil {
+		log.Error("Failed to audit delete", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit delete", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	log.Info("Subscription deleted", slog.Int("id", id))
+	return nil
+}
+
+// RestoreSub brings back a soft-deleted subscription, versions works like in UpdateSub.
+func (r *SubscriptionRepo) RestoreSub(ctx context.Context, id int, versions []int) error {
+	const op = "repository.subscriptionsRepo.RestoreSub"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockSub(ctx, tx, id)
+	if err != nil {
+		log.Error("Failed to lock sub", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	switch {
+	case before.DeletedAt == nil:
+		return fmt.Errorf("%s: %w: sub %d is not deleted", op, structures.ErrConflict, id)
+	case !versionMatches(versions, before.Version):
+		return fmt.Errorf("%s: %w: sub %d was modified", op, structures.ErrPreconditionFailed, id)
+	}
+
+	query := `
+		UPDATE subscriptions
+		SET deleted_at = NULL,
+			version = version + 1
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	var after structures.Subscription
+
+	if err := scanSubscription(tx.QueryRowContext(ctx, query, id), &after); err != nil {
+		log.Error("Failed to restore sub", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := insertAudit(ctx, tx, structures.AuditRestore, &before, &after); err != nil {
+		log.Error("Failed to audit restore", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit restore", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	log.Info("Subscription restored", slog.Int("id", id))
+	return nil
+}
+
+// PurgeDeleted permanently removes subscriptions soft-deleted before the given time.
+func (r *SubscriptionRepo) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	const op = "repository.subscriptionsRepo.PurgeDeleted"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		DELETE FROM subscriptions
+		WHERE deleted_at IS NOT NULL
+		  AND deleted_at < $1
+		RETURNING ` + subscriptionColumns
+
+	rows, err := tx.QueryContext(ctx, query, before)
+	if err != nil {
+		log.Error("Failed to purge subs", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	var purged []structures.Subscription
+
+	for rows.Next() {
+		var subscription structures.Subscription
+
+		if err := scanSubscription(rows, &subscription); err != nil {
+			rows.Close()
+			log.Error("Failed to scan purged sub", sl.Err(err))
+			return 0, wrapError(op, err)
+		}
+
+		purged = append(purged, subscription)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	for i := range purged {
+		if err := insertAudit(ctx, tx, structures.AuditPurge, &purged[i], nil); err != nil {
+			log.Error("Failed to audit purge", sl.Err(err))
+			return 0, wrapError(op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit purge", sl.Err(err))
+		return 0, wrapError(op, err)
+	}
+
+	return len(purged), nil
+}
+
+// SchedulePrice sets the price of the subscription with id from
+// change.EffectiveFrom on, replacing a change already scheduled for that month.
+// versions works like in UpdateSub.
+func (r *SubscriptionRepo) SchedulePrice(ctx context.Context, id int, change *structures.PriceChange, versions []int) error {
+	const op = "repository.subscriptionsRepo.SchedulePrice"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockSub(ctx, tx, id)
+	if err != nil {
+		log.Error("Failed to lock sub", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := checkWritable(op, &before, versions); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO subscription_prices (subscription_id, effective_from, price)
+		VALUES ($1, to_date($2, 'MM-YYYY'), $3)
+		ON CONFLICT (subscription_id, effective_from) DO UPDATE
+		SET price = EXCLUDED.price
+	`
+
+	if _, err := tx.ExecContext(ctx, query, id, change.EffectiveFrom, change.Price); err != nil {
+		log.Error("Failed to schedule price", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	query = `
+		UPDATE subscriptions
+		SET version = version + 1
 		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	var after structures.Subscription
+
+	if err := scanSubscription(tx.QueryRowContext(ctx, query, id), &after); err != nil {
+		log.Error("Failed to bump sub version", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := insertAudit(ctx, tx, structures.AuditUpdate, &before, &after); err != nil {
+		log.Error("Failed to audit price change", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit price change", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	log.Info("Price change scheduled", slog.Int("id", id), slog.String("effective_from", change.EffectiveFrom))
+	return nil
+}
+
+// DeletePrice removes the price change of the subscription with id scheduled
+// for effectiveFrom (MM-YYYY), versions work like in UpdateSub.
+func (r *SubscriptionRepo) DeletePrice(ctx context.Context, id int, effectiveFrom string, versions []int) error {
+	const op = "repository.subscriptionsRepo.DeletePrice"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Failed to begin transaction", sl.Err(err))
+		return wrapError(op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockSub(ctx, tx, id)
+	if err != nil {
+		log.Error("Failed to lock sub", slog.Int("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := checkWritable(op, &before, versions); err != nil {
+		return err
+	}
+
+	query := `
+		DELETE FROM subscription_prices
+		WHERE subscription_id = $1 AND effective_from = to_date($2, 'MM-YYYY')
 	`
 
-	result, err := r.db.Exec(query, id)
+	result, err := tx.ExecContext(ctx, query, id, effectiveFrom)
 	if err != nil {
-		log.Error("Failed to delete sub", sl.Err(err))
-		return fmt.Errorf("%s:%v", op, err)
+		log.Error("Failed to delete price change", sl.Err(err))
+		return wrapError(op, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	affected, err := result.RowsAffected()
 	if err != nil {
 		log.Error("Failed to get affected rows", sl.Err(err))
-		return fmt.Errorf("%s:%v", op, err)
+		return wrapError(op, err)
 	}
 
-	if rowsAffected == 0 {
-		log.Info("No subscription found with ID", slog.Int("id", id))
-	} else {
-		log.Info("Subscription deleted", slog.Int("id", id))
+	if affected == 0 {
+		return fmt.Errorf("%s: %w: no price change for %s", op, structures.ErrNotFound, effectiveFrom)
 	}
 
+	query = `
+		UPDATE subscriptions
+		SET version = version + 1
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	var after structures.Subscription
+
+	if err := scanSubscription(tx.QueryRowContext(ctx, query, id), &after); err != nil {
+		log.Error("Failed to bump sub version", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := insertAudit(ctx, tx, structures.AuditUpdate, &before, &after); err != nil {
+		log.Error("Failed to audit price change", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit price change", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	log.Info("Price change deleted", slog.Int("id", id), slog.String("effective_from", effectiveFrom))
 	return nil
 }
 
-func (r *SubscriptionRepo) SelectSum(data *structures.Counting) (int, error) {
-	const op = "repository.subscriptionRepo.SelectSum"
+// lockSub selects the subscription with id for update within tx.
+func lockSub(ctx context.Context, tx *sql.Tx, id int) (structures.Subscription, error) {
+	var subscription structures.Subscription
+
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	err := scanSubscription(tx.QueryRowContext(ctx, query, id), &subscription)
+	return subscription, err
+}
+
+// SelectCountedSubs returns the subscriptions matching the Counting filters
+// that are active in at least one month of its period. A user matches the
+// subscriptions it owns or is a member of.
+func (r *SubscriptionRepo) SelectCountedSubs(ctx context.Context, data *structures.Counting) ([]structures.Subscription, error) {
+	const op = "repository.subscriptionRepo.SelectCountedSubs"
 	log := r.log.With("op", op)
 
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		SELECT COALESCE(SUM(price), 0)
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
-		WHERE to_date(start_date, 'MM-YYYY') 
-			BETWEEN to_date($1, 'MM-YYYY') AND to_date($2, 'MM-YYYY')
-		  AND ($3 = '' OR user_id = $3::uuid)
+		WHERE start_date <= to_date($2, 'MM-YYYY')
+		  AND (end_date IS NULL OR end_date >= to_date($1, 'MM-YYYY'))
+		  AND ($3 = '' OR user_id = $3::uuid OR EXISTS (
+			SELECT 1
+			FROM subscription_members m
+			WHERE m.subscription_id = subscriptions.id AND m.user_id = $3::uuid
+		  ))
 		  AND ($4 = '' OR service_name = $4)
+		  AND ($5 OR deleted_at IS NULL)
+		  AND ($6 = 0 OR service_id = $6)
+		  AND ($7 = '' OR category = $7)
+		  AND ($8 = '' OR $8 = ANY(tags))
+		ORDER BY id
 	`
 
-	var total int
-
-	err := r.db.QueryRow(query, data.StartDate, data.EndDate, data.UserID, data.ServiceName).Scan(&total)
+	rows, err := r.db.QueryContext(
+		ctx,
+		query,
+		data.StartDate,
+		data.EndDate,
+		data.UserID,
+		data.ServiceName,
+		data.IncludeDeleted,
+		data.ServiceID,
+		data.Category,
+		data.Tag,
+	)
 	if err != nil {
-		log.Error("Failed to select sum", sl.Err(err))
-		return 0, fmt.Errorf("%s: %v", op, err)
+		log.Error("Failed to select counted subs", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	subscriptions := []structures.Subscription{}
+
+	for rows.Next() {
+		var subscription structures.Subscription
+
+		if err := scanSubscription(rows, &subscription); err != nil {
+			log.Error("Failed to scan sub", sl.Err(err))
+			return nil, wrapError(op, err)
+		}
+
+		subscriptions = append(subscriptions, subscription)
 	}
 
-	return total, nil
+	if err = rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return nil, wrapError(op, err)
+	}
+
+	return subscriptions, nil
 }
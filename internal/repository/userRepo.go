@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+const userColumns = `id, name, COALESCE(email, ''), created_at`
+
+func scanUser(row rowScanner, user *structures.User) error {
+	return row.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt)
+}
+
+// InsertUser stores user with its ID, a generated one if it is empty, and
+// sets the ID and creation time of user.
+func (r *SubscriptionRepo) InsertUser(ctx context.Context, user *structures.User) (string, error) {
+	const op = "repository.subscriptionRepo.InsertUser"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO users (id, name, email)
+		VALUES (COALESCE(NULLIF($1, '')::uuid, uuid_generate_v4()), $2, NULLIF($3, ''))
+		RETURNING ` + userColumns
+
+	var inserted structures.User
+
+	if err := scanUser(r.db.QueryRowContext(ctx, query, user.ID, user.Name, user.Email), &inserted); err != nil {
+		log.Error("Failed to insert user", sl.Err(err))
+		return "", wrapError(op, err)
+	}
+
+	*user = inserted
+
+	log.Info("User created", slog.String("id", inserted.ID))
+	return inserted.ID, nil
+}
+
+// SelectUsers returns a page of users ordered by creation time.
+func (r *SubscriptionRepo) SelectUsers(ctx context.Context, filter *structures.UserFilter) (structures.UserPage, error) {
+	const op = "repository.subscriptionRepo.SelectUsers"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	page := structures.UserPage{Users: []structures.User{}}
+
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&page.Total); err != nil {
+		log.Error("Failed to count users", sl.Err(err))
+		return page, wrapError(op, err)
+	}
+
+	var limit any
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		ORDER BY created_at, id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, filter.Offset)
+	if err != nil {
+		log.Error("Failed to select users", sl.Err(err))
+		return page, wrapError(op, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var user structures.User
+
+		if err := scanUser(rows, &user); err != nil {
+			log.Error("Failed to scan user", sl.Err(err))
+			return page, wrapError(op, err)
+		}
+
+		page.Users = append(page.Users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("Rows iteration error", sl.Err(err))
+		return page, wrapError(op, err)
+	}
+
+	return page, nil
+}
+
+func (r *SubscriptionRepo) SelectUserById(ctx context.Context, id string) (structures.User, error) {
+	const op = "repository.subscriptionRepo.SelectUserById"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var user structures.User
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1::uuid`
+
+	if err := scanUser(r.db.QueryRowContext(ctx, query, id), &user); err != nil {
+		log.Error("Failed to select user", slog.String("id", id), sl.Err(err))
+		return user, wrapError(op, err)
+	}
+
+	return user, nil
+}
+
+// UpdateUser overwrites the name and email of the user with id and sets
+// the stored fields of user.
+func (r *SubscriptionRepo) UpdateUser(ctx context.Context, user *structures.User, id string) error {
+	const op = "repository.subscriptionRepo.UpdateUser"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET name = $1, email = NULLIF($2, '')
+		WHERE id = $3::uuid
+		RETURNING ` + userColumns
+
+	var updated structures.User
+
+	if err := scanUser(r.db.QueryRowContext(ctx, query, user.Name, user.Email, id), &updated); err != nil {
+		log.Error("Failed to update user", slog.String("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	*user = updated
+
+	log.Info("User updated", slog.String("id", id))
+	return nil
+}
+
+// DeleteUser removes the user with id. Users still referred to by a
+// subscription, soft-deleted ones included, are a conflict.
+func (r *SubscriptionRepo) DeleteUser(ctx context.Context, id string) error {
+	const op = "repository.subscriptionRepo.DeleteUser"
+	log := r.log.With("op", op)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1::uuid`, id)
+	if err != nil {
+		log.Error("Failed to delete user", slog.String("id", id), sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error("Failed to get affected rows", sl.Err(err))
+		return wrapError(op, err)
+	}
+
+	if affected == 0 {
+		return wrapError(op, sql.ErrNoRows)
+	}
+
+	log.Info("User deleted", slog.String("id", id))
+	return nil
+}
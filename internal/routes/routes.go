@@ -26,9 +26,62 @@ func InitRoutes(
 	subscriptionGroup.Get("/:id", subscriptionHandler.GetOneSubscription)
 	subscriptionGroup.Post("/", subscriptionHandler.CreateSubscription)
 	subscriptionGroup.Put("/:id", subscriptionHandler.UpdateSubscription)
+	subscriptionGroup.Patch("/:id", subscriptionHandler.PatchSubscription)
+	subscriptionGroup.Post("/:id/prices", subscriptionHandler.SchedulePrice)
+	subscriptionGroup.Delete("/:id/prices/:month", subscriptionHandler.DeletePrice)
+	subscriptionGroup.Put("/:id/members", subscriptionHandler.SetMembers)
 	subscriptionGroup.Delete("/:id", subscriptionHandler.DeleteSubscription)
+	subscriptionGroup.Post("/:id/restore", subscriptionHandler.RestoreSubscription)
+	subscriptionGroup.Post("/:id/pause", subscriptionHandler.PauseSubscription)
+	subscriptionGroup.Post("/:id/resume", subscriptionHandler.ResumeSubscription)
+	subscriptionGroup.Post("/:id/cancel", subscriptionHandler.CancelSubscription)
+	subscriptionGroup.Get("/:id/history", subscriptionHandler.GetSubscriptionHistory)
+	subscriptionGroup.Get("/:id/charges", subscriptionHandler.GetCharges)
+
+	v1.Get("/audit", subscriptionHandler.GetAudit)
+	v1.Get("/renewals", subscriptionHandler.GetRenewals)
+	v1.Get("/forecast", subscriptionHandler.GetForecast)
+
+	usersGroup := v1.Group("/users")
+
+	usersGroup.Get("/", subscriptionHandler.GetUsers)
+	usersGroup.Get("/:id", subscriptionHandler.GetOneUser)
+	usersGroup.Post("/", subscriptionHandler.CreateUser)
+	usersGroup.Put("/:id", subscriptionHandler.UpdateUser)
+	usersGroup.Delete("/:id", subscriptionHandler.DeleteUser)
+	usersGroup.Get("/:id/subscriptions", subscriptionHandler.GetUserSubscriptions)
+	usersGroup.Get("/:id/summary", subscriptionHandler.GetUserSummary)
+
+	servicesGroup := v1.Group("/services")
+
+	servicesGroup.Get("/", subscriptionHandler.GetServices)
+	servicesGroup.Post("/", subscriptionHandler.CreateService)
+	servicesGroup.Get("/lookup", subscriptionHandler.LookupServices)
+	servicesGroup.Get("/unmatched", subscriptionHandler.GetUnmatchedNames)
+	servicesGroup.Post("/match", subscriptionHandler.MatchServices)
+	servicesGroup.Get("/:id", subscriptionHandler.GetOneService)
+	servicesGroup.Put("/:id", subscriptionHandler.UpdateService)
+	servicesGroup.Delete("/:id", subscriptionHandler.DeleteService)
+
+	budgetsGroup := v1.Group("/budgets")
+
+	budgetsGroup.Get("/", subscriptionHandler.GetBudgets)
+	budgetsGroup.Post("/", subscriptionHandler.CreateBudget)
+	budgetsGroup.Get("/report", subscriptionHandler.GetBudgetReport)
+	budgetsGroup.Get("/:id", subscriptionHandler.GetOneBudget)
+	budgetsGroup.Put("/:id", subscriptionHandler.UpdateBudget)
+	budgetsGroup.Delete("/:id", subscriptionHandler.DeleteBudget)
+
+	ratesGroup := v1.Group("/rates")
+
+	ratesGroup.Get("/", subscriptionHandler.GetRates)
+	ratesGroup.Put("/", subscriptionHandler.SetRates)
+	ratesGroup.Post("/csv", subscriptionHandler.ImportRatesCSV)
 
 	sumGroup := v1.Group("/summ")
 
 	sumGroup.Get("/", subscriptionHandler.GetSumm)
+	sumGroup.Get("/monthly", subscriptionHandler.GetMonthlySumm)
+	sumGroup.Get("/grouped", subscriptionHandler.GetGroupedSumm)
+	sumGroup.Get("/settlement", subscriptionHandler.GetSettlement)
 }
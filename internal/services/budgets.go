@@ -0,0 +1,381 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+	"github.com/google/uuid"
+)
+
+// budgetAlertMonths is how many months from the current one a change of a
+// subscription is checked against the budgets in.
+const budgetAlertMonths = 12
+
+// normalizeBudget lower-cases the category like subscription categories and
+// upper-cases the currency, DefaultCurrency if empty.
+func normalizeBudget(budget *structures.Budget) {
+	if userID, err := uuid.Parse(budget.UserID); err == nil {
+		budget.UserID = userID.String()
+	}
+
+	budget.Category = normalizeLabel(budget.Category)
+
+	budget.Currency = strings.ToUpper(strings.TrimSpace(budget.Currency))
+	if budget.Currency == "" {
+		budget.Currency = structures.DefaultCurrency
+	}
+}
+
+func (s *SubscriptionService) CreateBudget(ctx context.Context, budget *structures.Budget) (int, error) {
+	const op = "services.subscriptionService.CreateBudget"
+	log := s.log.With("op", op)
+
+	if err := s.checkBudget(ctx, budget); err != nil {
+		log.Warn("Invalid budget", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := s.subscriptionRepo.InsertBudget(ctx, budget)
+	if err != nil {
+		log.Error("Failed to create budget", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Budget created", slog.Int("id", id))
+
+	return id, nil
+}
+
+func (s *SubscriptionService) GetBudgets(ctx context.Context, filter *structures.BudgetFilter) ([]structures.Budget, error) {
+	const op = "services.subscriptionService.GetBudgets"
+	log := s.log.With("op", op)
+
+	var v validator
+	v.uuid("user_id", filter.UserID, false)
+	if err := v.err(); err != nil {
+		log.Warn("Invalid budget filter", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	budgets, err := s.subscriptionRepo.SelectBudgets(ctx, filter.UserID)
+	if err != nil {
+		log.Error("Failed to get budgets", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return budgets, nil
+}
+
+func (s *SubscriptionService) GetBudgetById(ctx context.Context, id int) (structures.Budget, error) {
+	const op = "services.subscriptionService.GetBudgetById"
+	log := s.log.With("op", op)
+
+	budget, err := s.subscriptionRepo.SelectBudgetById(ctx, id)
+	if err != nil {
+		log.Error("Failed to get budget by id", sl.Err(err))
+		return budget, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return budget, nil
+}
+
+func (s *SubscriptionService) UpdateBudget(ctx context.Context, budget *structures.Budget, id int) error {
+	const op = "services.subscriptionService.UpdateBudget"
+	log := s.log.With("op", op)
+
+	if budget.ID != 0 && budget.ID != id {
+		return fmt.Errorf("%s: %w", op, structures.NewFieldError("id", "cannot be changed"))
+	}
+
+	if err := s.checkBudget(ctx, budget); err != nil {
+		log.Warn("Invalid budget", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.subscriptionRepo.UpdateBudget(ctx, budget, id); err != nil {
+		log.Error("Failed to update budget", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *SubscriptionService) DeleteBudget(ctx context.Context, id int) error {
+	const op = "services.subscriptionService.DeleteBudget"
+	log := s.log.With("op", op)
+
+	if err := s.subscriptionRepo.DeleteBudget(ctx, id); err != nil {
+		log.Error("Failed to delete budget", slog.Int("id", id), sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// checkBudget normalizes and validates budget, its user and service have
+// to exist.
+func (s *SubscriptionService) checkBudget(ctx context.Context, budget *structures.Budget) error {
+	normalizeBudget(budget)
+
+	if err := ValidateBudget(budget); err != nil {
+		return err
+	}
+
+	if err := s.checkUserExists(ctx, budget.UserID); err != nil {
+		return err
+	}
+
+	if budget.ServiceID != 0 {
+		_, err := s.subscriptionRepo.SelectServiceById(ctx, budget.ServiceID)
+		if errors.Is(err, structures.ErrNotFound) {
+			return structures.NewFieldError("service_id", "must refer to a service of the catalog")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetBudgetReport compares the spend in every month of the filter with the
+// budgets of filter.UserID, of every user if it is empty.
+func (s *SubscriptionService) GetBudgetReport(ctx context.Context, filter *structures.BudgetReportFilter) (structures.BudgetReport, error) {
+	const op = "services.subscriptionService.GetBudgetReport"
+	log := s.log.With("op", op)
+
+	filter.Mode = strings.ToLower(filter.Mode)
+	if filter.Mode == "" {
+		filter.Mode = structures.CountAmortized
+	}
+
+	if filter.From == "" {
+		filter.From = structures.FormatMonth(monthOf(time.Now()))
+	}
+	if filter.To == "" {
+		filter.To = filter.From
+	}
+
+	if err := ValidateBudgetReportFilter(filter); err != nil {
+		log.Warn("Invalid budget report filter", sl.Err(err))
+		return structures.BudgetReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	budgets, err := s.subscriptionRepo.SelectBudgets(ctx, filter.UserID)
+	if err != nil {
+		log.Error("Failed to get budgets", sl.Err(err))
+		return structures.BudgetReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	start, _ := structures.ParseMonth(filter.From)
+	end, _ := structures.ParseMonth(filter.To)
+
+	report := structures.BudgetReport{
+		From:     filter.From,
+		To:       filter.To,
+		Budgets:  []structures.BudgetUsage{},
+		Rounding: structures.SpendRounding,
+		Mode:     filter.Mode,
+	}
+
+	for _, budget := range budgets {
+		spent, rates, err := s.budgetSpend(ctx, &budget, start, end, filter.Mode)
+		if err != nil {
+			log.Error("Failed to count budget spend", slog.Int("budget_id", budget.ID), sl.Err(err))
+			return structures.BudgetReport{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		usage := structures.BudgetUsage{Budget: budget, Months: []structures.BudgetMonth{}, Rates: rates}
+		for i, month := 0, start; !month.After(end); i, month = i+1, month.AddDate(0, 1, 0) {
+			usage.Months = append(usage.Months, structures.BudgetMonth{
+				Month:     structures.FormatMonth(month),
+				Spent:     spent[i],
+				Remaining: budget.Amount - spent[i],
+				Over:      spent[i] > budget.Amount,
+			})
+		}
+
+		report.Budgets = append(report.Budgets, usage)
+	}
+
+	return report, nil
+}
+
+// budgetSpend returns the share budget.UserID pays of the subscriptions
+// budget covers in every month from start to end, counted like /summ in
+// mode and converted to the currency of the budget, and the rates used.
+func (s *SubscriptionService) budgetSpend(
+	ctx context.Context,
+	budget *structures.Budget,
+	start, end time.Time,
+	mode string,
+) ([]structures.Money, []structures.AppliedRate, error) {
+	data := &structures.Counting{
+		StartDate:      structures.FormatMonth(start),
+		EndDate:        structures.FormatMonth(end),
+		UserID:         budget.UserID,
+		ServiceID:      budget.ServiceID,
+		Category:       budget.Category,
+		TargetCurrency: budget.Currency,
+		Mode:           mode,
+	}
+
+	spent := make([]structures.Money, monthsBetween(start, end)+1)
+
+	spend, err := s.countSpend(ctx, data, &structures.SpendQuery{ByMonth: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, group := range spend.Groups {
+		month, _ := structures.ParseMonth(group.Month)
+		spent[monthsBetween(start, month)] = group.Total
+	}
+
+	return spent, spend.Rates, nil
+}
+
+// monthsBetween returns how many months to is after from.
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// budgetCheck holds the budgets covering a change of a subscription and
+// what was spent against them in the coming budgetAlertMonths from start
+// before the change, see watchBudgets.
+type budgetCheck struct {
+	start   time.Time
+	budgets []structures.Budget
+	spent   [][]structures.Money
+}
+
+// watchBudgets counts the budgets of the users paying for before or after,
+// the versions of a subscription before and after a change, that cover
+// either of them. It is called before the change is written and
+// alertBudgets after. before is nil for a subscription that was not counted
+// before, after for one that is not counted any more. Failures are logged
+// and only skip the alerts, the check is nil then.
+func (s *SubscriptionService) watchBudgets(ctx context.Context, before, after *structures.Subscription) *budgetCheck {
+	const op = "services.subscriptionService.watchBudgets"
+	log := s.log.With("op", op)
+
+	check := &budgetCheck{start: monthOf(time.Now())}
+	end := check.start.AddDate(0, budgetAlertMonths-1, 0)
+
+	seen := make(map[int]bool)
+	for _, subscription := range []*structures.Subscription{before, after} {
+		if subscription == nil {
+			continue
+		}
+
+		payers := []string{subscription.UserID}
+		for _, member := range subscription.Members {
+			payers = append(payers, member.UserID)
+		}
+
+		for _, payer := range payers {
+			payerBudgets, err := s.subscriptionRepo.SelectBudgets(ctx, payer)
+			if err != nil {
+				log.Error("Failed to get budgets", sl.Err(err))
+				return nil
+			}
+
+			for _, budget := range payerBudgets {
+				if !seen[budget.ID] && budget.Covers(subscription) {
+					seen[budget.ID] = true
+					check.budgets = append(check.budgets, budget)
+				}
+			}
+		}
+	}
+
+	if len(check.budgets) == 0 {
+		return nil
+	}
+
+	for i := range check.budgets {
+		spent, _, err := s.budgetSpend(ctx, &check.budgets[i], check.start, end, structures.CountAmortized)
+		if err != nil {
+			log.Error("Failed to count budget spend", slog.Int("budget_id", check.budgets[i].ID), sl.Err(err))
+			return nil
+		}
+		check.spent = append(check.spent, spent)
+	}
+
+	return check
+}
+
+// alertBudgets counts the budgets of check again after the change of the
+// subscription with id was written and queues an alert for RunAlertJob for
+// every month the change pushed a budget over, so notifying does not hold up
+// the change. While the queue is full it waits until there is room or ctx is
+// done.
+func (s *SubscriptionService) alertBudgets(ctx context.Context, check *budgetCheck, id int) {
+	const op = "services.subscriptionService.alertBudgets"
+	log := s.log.With("op", op, slog.Int("id", id))
+
+	if check == nil {
+		return
+	}
+
+	end := check.start.AddDate(0, budgetAlertMonths-1, 0)
+	info := structures.RequestInfoFrom(ctx)
+
+	for i := range check.budgets {
+		budget := &check.budgets[i]
+
+		spent, _, err := s.budgetSpend(ctx, budget, check.start, end, structures.CountAmortized)
+		if err != nil {
+			log.Error("Failed to count budget spend", slog.Int("budget_id", budget.ID), sl.Err(err))
+			continue
+		}
+
+		for j, month := 0, check.start; !month.After(end); j, month = j+1, month.AddDate(0, 1, 0) {
+			previous := check.spent[i][j]
+			if spent[j] <= previous || previous > budget.Amount || spent[j] <= budget.Amount {
+				continue
+			}
+
+			alert := structures.BudgetAlert{
+				Budget:         *budget,
+				Month:          structures.FormatMonth(month),
+				Spent:          spent[j],
+				Previous:       previous,
+				SubscriptionID: id,
+				Actor:          info.Actor,
+				RequestID:      info.RequestID,
+				RaisedAt:       time.Now(),
+			}
+
+			select {
+			case s.budgetAlerts <- alert:
+			case <-ctx.Done():
+				log.Warn("Budget alert is not sent", slog.Int("budget_id", budget.ID), sl.Err(ctx.Err()))
+				return
+			}
+		}
+	}
+}
+
+// RunAlertJob sends the alerts queued by alertBudgets until ctx is cancelled.
+func (s *SubscriptionService) RunAlertJob(ctx context.Context) {
+	const op = "services.subscriptionService.RunAlertJob"
+	log := s.log.With("op", op)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alert := <-s.budgetAlerts:
+			if err := s.notifier.Notify(ctx, alert); err != nil {
+				log.Error("Failed to notify about budget", slog.Int("budget_id", alert.Budget.ID), sl.Err(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+const (
+	// minMatchScore is the lowest structures.NameSimilarity a lookup or a
+	// suggestion accepts.
+	minMatchScore      = 0.5
+	defaultLookupLimit = 5
+	maxLookupLimit     = 50
+)
+
+// normalizeService trims the names, drops aliases repeating the name or
+// each other and upper-cases the currency, DefaultCurrency if empty.
+func normalizeService(service *structures.Service) {
+	service.Name = strings.Join(strings.Fields(service.Name), " ")
+	service.Category = strings.ToLower(strings.TrimSpace(service.Category))
+	service.Website = strings.TrimSpace(service.Website)
+
+	service.Currency = strings.ToUpper(strings.TrimSpace(service.Currency))
+	if service.Currency == "" {
+		service.Currency = structures.DefaultCurrency
+	}
+
+	keys := []string{structures.ServiceKey(service.Name)}
+	aliases := []string{}
+	for _, alias := range service.Aliases {
+		alias = strings.Join(strings.Fields(alias), " ")
+		key := structures.ServiceKey(alias)
+		if key == "" || slices.Contains(keys, key) {
+			continue
+		}
+		keys = append(keys, key)
+		aliases = append(aliases, alias)
+	}
+	service.Aliases = aliases
+}
+
+func (s *SubscriptionService) CreateService(ctx context.Context, service *structures.Service) (int, error) {
+	const op = "services.subscriptionService.CreateService"
+	log := s.log.With("op", op)
+
+	normalizeService(service)
+
+	if err := ValidateService(service); err != nil {
+		log.Warn("Invalid service", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := s.subscriptionRepo.InsertService(ctx, service)
+	if err != nil {
+		log.Error("Failed to create service", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Service created", slog.Int("id", id))
+
+	return id, nil
+}
+
+func (s *SubscriptionService) GetServices(ctx context.Context) ([]structures.Service, error) {
+	const op = "services.subscriptionService.GetServices"
+	log := s.log.With("op", op)
+
+	services, err := s.subscriptionRepo.SelectServices(ctx)
+	if err != nil {
+		log.Error("Failed to get services", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return services, nil
+}
+
+func (s *SubscriptionService) GetServiceById(ctx context.Context, id int) (structures.Service, error) {
+	const op = "services.subscriptionService.GetServiceById"
+	log := s.log.With("op", op)
+
+	service, err := s.subscriptionRepo.SelectServiceById(ctx, id)
+	if err != nil {
+		log.Error("Failed to get service by id", sl.Err(err))
+		return service, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return service, nil
+}
+
+// UpdateService overwrites the service with id and its aliases, the
+// subscriptions of the service take its new name.
+func (s *SubscriptionService) UpdateService(ctx context.Context, service *structures.Service, id int) error {
+	const op = "services.subscriptionService.UpdateService"
+	log := s.log.With("op", op)
+
+	normalizeService(service)
+
+	if service.ID != 0 && service.ID != id {
+		return fmt.Errorf("%s: %w", op, structures.NewFieldError("id", "cannot be changed"))
+	}
+
+	if err := ValidateService(service); err != nil {
+		log.Warn("Invalid service", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.subscriptionRepo.UpdateService(ctx, service, id); err != nil {
+		log.Error("Failed to update service", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteService removes the service with id. Services with subscriptions,
+// even soft-deleted ones, cannot be deleted until they are purged.
+func (s *SubscriptionService) DeleteService(ctx context.Context, id int) error {
+	const op = "services.subscriptionService.DeleteService"
+	log := s.log.With("op", op)
+
+	if err := s.subscriptionRepo.DeleteService(ctx, id); err != nil {
+		log.Error("Failed to delete service", slog.Int("id", id), sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// LookupServices returns the catalog entries whose name or alias resembles
+// lookup.Query, best first.
+func (s *SubscriptionService) LookupServices(ctx context.Context, lookup *structures.ServiceLookup) ([]structures.ServiceMatch, error) {
+	const op = "services.subscriptionService.LookupServices"
+	log := s.log.With("op", op)
+
+	if err := ValidateServiceLookup(lookup); err != nil {
+		log.Warn("Invalid service lookup", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if lookup.Limit == 0 {
+		lookup.Limit = defaultLookupLimit
+	}
+
+	services, err := s.subscriptionRepo.SelectServices(ctx)
+	if err != nil {
+		log.Error("Failed to get services", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	matches := matchServices(services, lookup.Query)
+	if len(matches) > lookup.Limit {
+		matches = matches[:lookup.Limit]
+	}
+
+	return matches, nil
+}
+
+// GetUnmatchedNames returns the names of the subscriptions without a
+// service, most used first, each with the closest catalog entry if any.
+func (s *SubscriptionService) GetUnmatchedNames(ctx context.Context) ([]structures.UnmatchedName, error) {
+	const op = "services.subscriptionService.GetUnmatchedNames"
+	log := s.log.With("op", op)
+
+	names, err := s.subscriptionRepo.SelectUnmatchedNames(ctx)
+	if err != nil {
+		log.Error("Failed to get unmatched names", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(names) == 0 {
+		return names, nil
+	}
+
+	services, err := s.subscriptionRepo.SelectServices(ctx)
+	if err != nil {
+		log.Error("Failed to get services", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range names {
+		if matches := matchServices(services, names[i].ServiceName); len(matches) > 0 {
+			names[i].Suggestion = &matches[0]
+		}
+	}
+
+	return names, nil
+}
+
+// MatchServices links the subscriptions without a service whose name is a
+// name or alias in the catalog to that service, and reports how many were
+// linked and the names still unmatched.
+func (s *SubscriptionService) MatchServices(ctx context.Context) (structures.ServiceMatchReport, error) {
+	const op = "services.subscriptionService.MatchServices"
+	log := s.log.With("op", op)
+
+	var report structures.ServiceMatchReport
+
+	names, err := s.subscriptionRepo.SelectUnmatchedNames(ctx)
+	if err != nil {
+		log.Error("Failed to get unmatched names", sl.Err(err))
+		return report, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, name := range names {
+		service, err := s.subscriptionRepo.SelectServiceByKey(ctx, structures.ServiceKey(name.ServiceName))
+		if errors.Is(err, structures.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Error("Failed to look up service", sl.Err(err))
+			return report, fmt.Errorf("%s: %w", op, err)
+		}
+
+		assigned, err := s.subscriptionRepo.AssignService(ctx, name.ServiceName, &service)
+		if err != nil {
+			log.Error("Failed to assign service", sl.Err(err))
+			return report, fmt.Errorf("%s: %w", op, err)
+		}
+
+		report.Matched += assigned
+	}
+
+	report.Unmatched, err = s.GetUnmatchedNames(ctx)
+	if err != nil {
+		return report, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if report.Matched > 0 {
+		log.Info("Subscriptions matched to services", slog.Int("count", report.Matched))
+	}
+
+	return report, nil
+}
+
+// matchServices scores services by their name or alias most similar to
+// name and returns those scoring at least minMatchScore, best first.
+func matchServices(services []structures.Service, name string) []structures.ServiceMatch {
+	matches := []structures.ServiceMatch{}
+
+	for _, service := range services {
+		score := structures.NameSimilarity(name, service.Name)
+		for _, alias := range service.Aliases {
+			score = max(score, structures.NameSimilarity(name, alias))
+		}
+
+		if score >= minMatchScore {
+			matches = append(matches, structures.ServiceMatch{Service: service, Score: score})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b structures.ServiceMatch) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	return matches
+}
+
+// resolveService links subscription to the catalog. A ServiceID has to
+// exist and sets ServiceName to the name of the service, otherwise a
+// ServiceName found in the catalog sets ServiceID. Names not found are kept
+// as they are. A subscription without a category takes the one of its service.
+func (s *SubscriptionService) resolveService(ctx context.Context, subscription *structures.Subscription) error {
+	if subscription.ServiceID != 0 {
+		service, err := s.subscriptionRepo.SelectServiceById(ctx, subscription.ServiceID)
+		if errors.Is(err, structures.ErrNotFound) {
+			return structures.NewFieldError("service_id", "must refer to a service of the catalog")
+		}
+		if err != nil {
+			return err
+		}
+
+		setService(subscription, &service)
+		return nil
+	}
+
+	service, err := s.findService(ctx, subscription.ServiceName)
+	if err != nil || service == nil {
+		return err
+	}
+
+	setService(subscription, service)
+	return nil
+}
+
+func setService(subscription *structures.Subscription, service *structures.Service) {
+	subscription.ServiceID = service.ID
+	subscription.ServiceName = service.Name
+	if subscription.Category == "" {
+		subscription.Category = service.Category
+	}
+}
+
+// resolveServiceFilter replaces a service name filter found in the catalog
+// with its service ID, so that every alias of the service matches.
+func (s *SubscriptionService) resolveServiceFilter(ctx context.Context, serviceName *string, serviceID *int) error {
+	if *serviceID != 0 || *serviceName == "" {
+		return nil
+	}
+
+	service, err := s.findService(ctx, *serviceName)
+	if err != nil || service == nil {
+		return err
+	}
+
+	*serviceID = service.ID
+	*serviceName = ""
+	return nil
+}
+
+// findService returns the service with name as its name or alias, nil if
+// there is none.
+func (s *SubscriptionService) findService(ctx context.Context, name string) (*structures.Service, error) {
+	key := structures.ServiceKey(name)
+	if key == "" {
+		return nil, nil
+	}
+
+	service, err := s.subscriptionRepo.SelectServiceByKey(ctx, key)
+	if errors.Is(err, structures.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &service, nil
+}
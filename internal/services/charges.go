@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+const (
+	// defaultChargeMonths is how far charges of a subscription without
+	// end_date are listed by default, counting from the current month.
+	defaultChargeMonths = 12
+	defaultRenewalSpan  = "30d"
+)
+
+// GetCharges returns the charges of the subscription with id in the months
+// of filter, by default from start_date to end_date or to a year from now.
+func (s *SubscriptionService) GetCharges(ctx context.Context, id int, filter *structures.ChargeFilter) ([]structures.Charge, error) {
+	const op = "services.subscriptionService.GetCharges"
+	log := s.log.With("op", op)
+
+	subscription, err := s.subscriptionRepo.SelectSubById(ctx, id, false)
+	if err != nil {
+		log.Error("Failed to get subscription", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if filter.From == "" {
+		filter.From = subscription.StartDate
+	}
+	if filter.To == "" {
+		filter.To = subscription.EndDate
+	}
+	if filter.To == "" {
+		filter.To = structures.FormatMonth(monthOf(time.Now()).AddDate(0, defaultChargeMonths, 0))
+	}
+
+	if err := ValidateChargeFilter(filter); err != nil {
+		log.Warn("Invalid charge filter", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	from, _ := structures.ParseMonth(filter.From)
+	to, _ := structures.ParseMonth(filter.To)
+
+	return subscription.Charges(from, to), nil
+}
+
+// GetRenewals returns a page of the charges from today until filter.Within
+// from now of the subscriptions that are not deleted, ordered by date and
+// subscription ID. NextCursor is set when there are more.
+func (s *SubscriptionService) GetRenewals(ctx context.Context, filter *structures.RenewalFilter) (structures.RenewalPage, error) {
+	const op = "services.subscriptionService.GetRenewals"
+	log := s.log.With("op", op)
+
+	if filter.Within == "" {
+		filter.Within = defaultRenewalSpan
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
+
+	if err := ValidateRenewalFilter(filter); err != nil {
+		log.Warn("Invalid renewal filter", sl.Err(err))
+		return structures.RenewalPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	days, _ := parseWithin(filter.Within)
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	until := today.AddDate(0, 0, days)
+
+	query := &structures.RenewalQuery{
+		From:   today.Format(structures.DateLayout),
+		Until:  until.Format(structures.DateLayout),
+		UserID: filter.UserID,
+		// One more tells whether there is a next page.
+		Limit: filter.Limit + 1,
+	}
+	if filter.Cursor != "" {
+		cursor, _ := structures.DecodeCursor(filter.Cursor)
+		query.AfterDate, query.AfterID = cursor.Value, cursor.ID
+	}
+
+	renewals, err := s.subscriptionRepo.SelectRenewals(ctx, query)
+	if err != nil {
+		log.Error("Failed to get renewals", sl.Err(err))
+		return structures.RenewalPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := structures.RenewalPage{Renewals: renewals}
+	if len(renewals) > filter.Limit {
+		page.Renewals = renewals[:filter.Limit]
+		last := page.Renewals[len(page.Renewals)-1]
+		page.NextCursor = structures.EncodeCursor(structures.PageCursor{
+			Sort:  structures.RenewalCursorSort,
+			Value: last.Date,
+			ID:    last.SubscriptionID,
+		})
+	}
+
+	return page, nil
+}
+
+// parseWithin parses a span of days like 30d or of weeks like 2w into days,
+// from 1 to maxRenewalDays.
+func parseWithin(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return 0, errors.New("too short")
+	}
+
+	// Atoi would take a sign too.
+	number := value[:len(value)-1]
+	if strings.Trim(number, "0123456789") != "" {
+		return 0, fmt.Errorf("%q is not a number", number)
+	}
+
+	days, err := strconv.Atoi(number)
+	if err != nil || days > maxRenewalDays {
+		return 0, fmt.Errorf("%q is too long", value)
+	}
+
+	switch value[len(value)-1] {
+	case 'd':
+	case 'w':
+		days *= 7
+	default:
+		return 0, fmt.Errorf("unknown unit in %q", value)
+	}
+
+	if days < 1 || days > maxRenewalDays {
+		return 0, fmt.Errorf("%q is not from 1 to %d days", value, maxRenewalDays)
+	}
+
+	return days, nil
+}
+
+// monthOf returns the first day of the month of t.
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,43 @@
+package services
+
+import "testing"
+
+func TestParseWithin(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"30d", 30, false},
+		{"2w", 14, false},
+		{" 7d ", 7, false},
+		{"1d", 1, false},
+		{"366d", 366, false},
+		{"52w", 364, false},
+		{"007d", 7, false},
+		{"0d", 0, true},
+		{"0w", 0, true},
+		{"-2w", 0, true},
+		{"+2w", 0, true},
+		{"367d", 0, true},
+		{"53w", 0, true},
+		{"99999999999999999999w", 0, true},
+		{"1.5w", 0, true},
+		{"2x", 0, true},
+		{"30", 0, true},
+		{"d", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseWithin(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWithin(%q) error = %v, want error %t", tt.value, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Fatalf("parseWithin(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+// rateCSVColumns are the columns of an exchange rate CSV, in any order.
+var rateCSVColumns = []string{"month", "from", "to", "rate"}
+
+// SetRates stores rates, replacing the rates already stored for the same
+// month and pair.
+func (s *SubscriptionService) SetRates(ctx context.Context, rates []structures.ExchangeRate) error {
+	const op = "services.subscriptionService.SetRates"
+	log := s.log.With("op", op)
+
+	for i := range rates {
+		rates[i].From = strings.ToUpper(strings.TrimSpace(rates[i].From))
+		rates[i].To = strings.ToUpper(strings.TrimSpace(rates[i].To))
+		if rate, err := structures.ParseRate(string(rates[i].Rate)); err == nil {
+			rates[i].Rate = rate
+		}
+	}
+
+	if err := ValidateExchangeRates(rates); err != nil {
+		log.Warn("Invalid exchange rates", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.subscriptionRepo.UpsertRates(ctx, rates); err != nil {
+		log.Error("Failed to store exchange rates", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ImportRatesCSV stores the rates of a CSV document with a month, from, to,
+// rate header and returns how many were stored.
+func (s *SubscriptionService) ImportRatesCSV(ctx context.Context, document io.Reader) (int, error) {
+	const op = "services.subscriptionService.ImportRatesCSV"
+	log := s.log.With("op", op)
+
+	rates, err := parseRatesCSV(document)
+	if err != nil {
+		log.Warn("Invalid exchange rate CSV", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.SetRates(ctx, rates); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Exchange rates imported", slog.Int("count", len(rates)))
+	return len(rates), nil
+}
+
+func (s *SubscriptionService) GetRates(ctx context.Context, filter *structures.ExchangeRateFilter) ([]structures.ExchangeRate, error) {
+	const op = "services.subscriptionService.GetRates"
+	log := s.log.With("op", op)
+
+	filter.Currency = strings.ToUpper(filter.Currency)
+
+	if err := ValidateExchangeRateFilter(filter); err != nil {
+		log.Warn("Invalid exchange rate filter", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rates, err := s.subscriptionRepo.SelectRates(ctx, filter)
+	if err != nil {
+		log.Error("Failed to get exchange rates", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rates, nil
+}
+
+func parseRatesCSV(document io.Reader) ([]structures.ExchangeRate, error) {
+	reader := csv.NewReader(document)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, structures.NewFieldError("csv", "is empty")
+	}
+	if err != nil {
+		return nil, structures.NewFieldError("csv", err.Error())
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var v validator
+	for _, name := range rateCSVColumns {
+		if _, ok := columns[name]; !ok {
+			v.add("csv", "header must have a "+name+" column")
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	var rates []structures.ExchangeRate
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, structures.NewFieldError("csv", err.Error())
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		rate := structures.Rate(strings.TrimSpace(record[columns["rate"]]))
+		if err := checkRate(rate); err != nil {
+			v.add(fmt.Sprintf("csv line %d", line), "rate "+err.Error())
+			continue
+		}
+
+		rates = append(rates, structures.ExchangeRate{
+			Month: strings.TrimSpace(record[columns["month"]]),
+			From:  record[columns["from"]],
+			To:    record[columns["to"]],
+			Rate:  rate,
+		})
+	}
+
+	return rates, v.err()
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+const defaultForecastMonths = 12
+
+// Forecast projects the spend of the coming filter.Months months, the
+// current one first. It counts with countSpend like MonthlyCounting, so
+// billing periods, scheduled prices, pauses, end dates and shares apply
+// the same way, and lists what every subscription adds to each month.
+func (s *SubscriptionService) Forecast(ctx context.Context, filter *structures.ForecastFilter) (structures.Forecast, error) {
+	const op = "services.subscriptionService.Forecast"
+	log := s.log.With("op", op)
+
+	if filter.Months == 0 {
+		filter.Months = defaultForecastMonths
+	}
+
+	if err := ValidateForecastFilter(filter); err != nil {
+		log.Warn("Invalid forecast filter", sl.Err(err))
+		return structures.Forecast{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	start := monthOf(time.Now())
+
+	data := &structures.Counting{
+		StartDate:      structures.FormatMonth(start),
+		EndDate:        structures.FormatMonth(start.AddDate(0, filter.Months-1, 0)),
+		UserID:         filter.UserID,
+		TargetCurrency: filter.TargetCurrency,
+		Mode:           filter.Mode,
+	}
+	normalizeCounting(data)
+
+	if err := ValidateCounting(data); err != nil {
+		log.Warn("Invalid forecast filter", sl.Err(err))
+		return structures.Forecast{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	spend, err := s.countSpend(ctx, data, &structures.SpendQuery{ByMonth: true, GroupBy: structures.GroupBySubscription})
+	if err != nil {
+		log.Error("Failed to count forecast", sl.Err(err))
+		return structures.Forecast{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	months := make([]structures.ForecastMonth, filter.Months)
+	for i := range months {
+		months[i] = structures.ForecastMonth{
+			Month:         structures.FormatMonth(start.AddDate(0, i, 0)),
+			Subscriptions: []structures.ForecastContribution{},
+		}
+	}
+
+	// The groups come ordered by month and then by amount. Without a user
+	// filter every share of a shared subscription is in the same group.
+	var total structures.Money
+	for _, group := range spend.Groups {
+		month, _ := structures.ParseMonth(group.Month)
+		bucket := &months[monthsBetween(start, month)]
+		bucket.Total += group.Total
+		bucket.Subscriptions = append(bucket.Subscriptions, structures.ForecastContribution{
+			SubscriptionID: group.SubscriptionID,
+			ServiceName:    group.ServiceName,
+			UserID:         group.Owner,
+			Amount:         group.Total,
+		})
+		total += group.Total
+	}
+
+	return structures.Forecast{
+		From:     data.StartDate,
+		To:       data.EndDate,
+		Months:   months,
+		Total:    total,
+		Currency: data.TargetCurrency,
+		Rates:    spend.Rates,
+		Rounding: structures.SpendRounding,
+		Mode:     data.Mode,
+	}, nil
+}
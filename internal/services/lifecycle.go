@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+// PauseSub stops billing the subscription with id from request.From on,
+// the current month by default, and returns the updated subscription. Only
+// active subscriptions can be paused. Non-empty versions work like in UpdateSub.
+func (s *SubscriptionService) PauseSub(
+	ctx context.Context,
+	id int,
+	versions []int,
+	request *structures.PauseRequest,
+) (structures.Subscription, error) {
+	const op = "services.subscriptionService.PauseSub"
+
+	return s.changeLifecycle(ctx, op, id, versions, func(current *structures.Subscription, now time.Time) (structures.Lifecycle, error) {
+		lifecycle := structures.LifecycleOf(current)
+
+		if err := checkTransition(current, now, structures.StatusPaused, "pause"); err != nil {
+			return lifecycle, err
+		}
+
+		if request.From == "" {
+			request.From = structures.FormatMonth(now)
+		}
+
+		if err := ValidatePause(request, current, now); err != nil {
+			return lifecycle, err
+		}
+
+		lifecycle.Status = structures.StatusPaused
+		lifecycle.Pauses = append(lifecycle.Pauses, structures.Pause{From: request.From})
+
+		return lifecycle, nil
+	})
+}
+
+// ResumeSub bills the paused subscription with id again from request.From
+// on, the current month by default, and returns the updated subscription.
+// A pause that has not begun by then is dropped. Non-empty versions work
+// like in UpdateSub.
+func (s *SubscriptionService) ResumeSub(
+	ctx context.Context,
+	id int,
+	versions []int,
+	request *structures.PauseRequest,
+) (structures.Subscription, error) {
+	const op = "services.subscriptionService.ResumeSub"
+
+	return s.changeLifecycle(ctx, op, id, versions, func(current *structures.Subscription, now time.Time) (structures.Lifecycle, error) {
+		lifecycle := structures.LifecycleOf(current)
+
+		if err := checkTransition(current, now, structures.StatusActive, "resume"); err != nil {
+			return lifecycle, err
+		}
+
+		open := slices.IndexFunc(lifecycle.Pauses, func(pause structures.Pause) bool { return pause.To == "" })
+		if open < 0 {
+			return lifecycle, fmt.Errorf("%w: sub %d has no open pause", structures.ErrConflict, current.ID)
+		}
+
+		if request.From == "" {
+			request.From = structures.FormatMonth(now)
+		}
+
+		if err := ValidatePause(request, current, now); err != nil {
+			return lifecycle, err
+		}
+
+		from, _ := structures.ParseMonth(request.From)
+		pausedFrom, _ := structures.ParseMonth(lifecycle.Pauses[open].From)
+
+		if from.After(pausedFrom) {
+			lifecycle.Pauses[open].To = structures.FormatMonth(from.AddDate(0, -1, 0))
+		} else {
+			lifecycle.Pauses = slices.Delete(lifecycle.Pauses, open, open+1)
+		}
+		lifecycle.Status = structures.StatusActive
+
+		return lifecycle, nil
+	})
+}
+
+// CancelSub cancels the subscription with id and returns the updated
+// subscription. Its end_date becomes the current month or, by default, the
+// last month of the billing period already paid for, an earlier end_date
+// is kept. Subscriptions that have not started yet should be deleted
+// instead. Non-empty versions work like in UpdateSub.
+func (s *SubscriptionService) CancelSub(
+	ctx context.Context,
+	id int,
+	versions []int,
+	request *structures.CancelRequest,
+) (structures.Subscription, error) {
+	const op = "services.subscriptionService.CancelSub"
+
+	return s.changeLifecycle(ctx, op, id, versions, func(current *structures.Subscription, now time.Time) (structures.Lifecycle, error) {
+		lifecycle := structures.LifecycleOf(current)
+
+		if err := ValidateCancel(request); err != nil {
+			return lifecycle, err
+		}
+
+		if err := checkTransition(current, now, structures.StatusCancelled, "cancel"); err != nil {
+			return lifecycle, err
+		}
+
+		if start, _ := structures.ParseMonth(current.StartDate); start.After(now) {
+			return lifecycle, fmt.Errorf("%w: sub %d has not started yet, delete it instead", structures.ErrConflict, current.ID)
+		}
+
+		end := now
+		if request.CancelOption() == structures.CancelAtPeriodEnd {
+			end = current.PeriodEnd(now)
+		}
+
+		if current.EndDate != "" {
+			if currentEnd, _ := structures.ParseMonth(current.EndDate); currentEnd.Before(end) {
+				end = currentEnd
+			}
+		}
+
+		cancelledAt := time.Now()
+
+		lifecycle.Status = structures.StatusCancelled
+		lifecycle.EndDate = structures.FormatMonth(end)
+		lifecycle.CancelledAt = &cancelledAt
+
+		return lifecycle, nil
+	})
+}
+
+// checkTransition returns ErrConflict unless the subscription can change
+// from its status in month now to status to.
+func checkTransition(current *structures.Subscription, now time.Time, to, action string) error {
+	from := current.StatusIn(now)
+	if !structures.CanTransition(from, to) {
+		return fmt.Errorf("%w: cannot %s a %s subscription", structures.ErrConflict, action, from)
+	}
+
+	return nil
+}
+
+// changeLifecycle stores the lifecycle change computed from the current
+// subscription with id and the current month, and returns the updated
+// subscription.
+func (s *SubscriptionService) changeLifecycle(
+	ctx context.Context,
+	op string,
+	id int,
+	versions []int,
+	change func(current *structures.Subscription, now time.Time) (structures.Lifecycle, error),
+) (structures.Subscription, error) {
+	log := s.log.With("op", op)
+
+	return s.modifySub(ctx, op, id, versions, func(current *structures.Subscription, versions []int) error {
+		lifecycle, err := change(current, monthOf(time.Now()))
+		if err != nil {
+			log.Warn("Invalid status change", sl.Err(err))
+			return err
+		}
+
+		updated := *current
+		updated.Status = lifecycle.Status
+		updated.EndDate = lifecycle.EndDate
+		updated.Pauses = lifecycle.Pauses
+		updated.CancelledAt = lifecycle.CancelledAt
+		check := s.watchBudgets(ctx, current, &updated)
+
+		if err := s.subscriptionRepo.SetLifecycle(ctx, id, &lifecycle, versions); err != nil {
+			log.Error("Failed to change status", sl.Err(err))
+			return err
+		}
+
+		s.alertBudgets(ctx, check, id)
+
+		return nil
+	})
+}
+
+// AdvanceStatuses ends the trials and expires the subscriptions that are
+// over in the current month and returns how many changed.
+func (s *SubscriptionService) AdvanceStatuses(ctx context.Context) (int, error) {
+	const op = "services.subscriptionService.AdvanceStatuses"
+	log := s.log.With("op", op)
+
+	advanced, err := s.subscriptionRepo.AdvanceStatuses(ctx, monthOf(time.Now()))
+	if err != nil {
+		log.Error("Failed to advance statuses", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if advanced > 0 {
+		log.Info("Subscription statuses advanced", slog.Int("count", advanced))
+	}
+
+	return advanced, nil
+}
+
+// RunLifecycleJob calls AdvanceStatuses every interval until ctx is cancelled.
+func (s *SubscriptionService) RunLifecycleJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Errors are logged by AdvanceStatuses, the next tick retries.
+		_, _ = s.AdvanceStatuses(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+// Notifier delivers budget alerts. Notify is called after the change that
+// raised the alert is stored, so its error is only logged.
+type Notifier interface {
+	Notify(ctx context.Context, alert structures.BudgetAlert) error
+}
+
+// LogNotifier writes alerts to the log, it is the default Notifier.
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, alert structures.BudgetAlert) error {
+	n.log.Warn("Budget exceeded",
+		slog.Int("budget_id", alert.Budget.ID),
+		slog.String("user_id", alert.Budget.UserID),
+		slog.String("scope", alert.Budget.Scope()),
+		slog.String("month", alert.Month),
+		slog.String("amount", alert.Budget.Amount.Format(alert.Budget.Currency)),
+		slog.String("spent", alert.Spent.Format(alert.Budget.Currency)),
+		slog.String("currency", alert.Budget.Currency),
+		slog.Int("subscription_id", alert.SubscriptionID),
+	)
+
+	return nil
+}
+
+// WebhookNotifier posts every alert as JSON to url.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: client}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert structures.BudgetAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
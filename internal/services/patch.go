@@ -0,0 +1,216 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// applyPatch applies a JSON Merge Patch (RFC 7386) or a JSON Patch (RFC 6902)
+// document to subscription and returns the patched copy.
+func applyPatch(subscription structures.Subscription, patch []byte, contentType string) (structures.Subscription, error) {
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return subscription, err
+	}
+
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return subscription, err
+	}
+
+	switch contentType {
+	case MergePatchContentType:
+		var mergePatch any
+		if err := json.Unmarshal(patch, &mergePatch); err != nil {
+			return subscription, structures.NewFieldError("patch", "is not valid JSON")
+		}
+
+		patched, ok := mergePatchValue(document, mergePatch).(map[string]any)
+		if !ok {
+			return subscription, structures.NewFieldError("patch", "must be a JSON object")
+		}
+		document = patched
+	case JSONPatchContentType:
+		if err := jsonPatch(document, patch); err != nil {
+			return subscription, err
+		}
+	default:
+		return subscription, fmt.Errorf("unsupported patch content type: %s", contentType)
+	}
+
+	data, err = json.Marshal(document)
+	if err != nil {
+		return subscription, err
+	}
+
+	// Amounts are read in the patched currency.
+	patched, err := structures.DecodeSubscription(data, true)
+	if err != nil {
+		var typeErr *json.UnmarshalTypeError
+		var validationErr *structures.ValidationError
+		switch {
+		case errors.As(err, &typeErr):
+			return subscription, structures.NewFieldError(typeErr.Field, "has a wrong type")
+		case errors.As(err, &validationErr):
+			return subscription, err
+		}
+		return subscription, structures.NewFieldError("patch", err.Error())
+	}
+
+	if patched.ID != subscription.ID {
+		return subscription, structures.NewFieldError("id", "cannot be changed")
+	}
+
+	if patched.Version != subscription.Version {
+		return subscription, structures.NewFieldError("version", "cannot be changed, use If-Match")
+	}
+
+	if !reflect.DeepEqual(patched.Prices, subscription.Prices) {
+		return subscription, structures.NewFieldError("prices", "cannot be changed, schedule a price change instead")
+	}
+
+	if !reflect.DeepEqual(patched.Members, subscription.Members) {
+		return subscription, structures.NewFieldError("members", "cannot be changed, use the members endpoint instead")
+	}
+
+	if patched.Status != subscription.Status ||
+		!reflect.DeepEqual(patched.Pauses, subscription.Pauses) ||
+		!sameTime(patched.CancelledAt, subscription.CancelledAt) {
+		return subscription, structures.NewFieldError("status", "cannot be changed, use pause, resume or cancel instead")
+	}
+
+	if !sameTime(patched.DeletedAt, subscription.DeletedAt) {
+		return subscription, structures.NewFieldError("deleted_at", "cannot be changed, use DELETE or restore")
+	}
+
+	return patched, nil
+}
+
+// sameTime reports whether a and b are both nil or the same instant.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// mergePatchValue implements the MergePatch algorithm of RFC 7386.
+func mergePatchValue(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatchValue(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatch applies RFC 6902 operations to document. Subscriptions are flat
+// objects, so only pointers to top-level members are supported.
+func jsonPatch(document map[string]any, patch []byte) error {
+	var operations []patchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return structures.NewFieldError("patch", "must be an array of operations")
+	}
+
+	for i, operation := range operations {
+		field := fmt.Sprintf("patch[%d]", i)
+
+		path, err := memberName(operation.Path)
+		if err != nil {
+			return structures.NewFieldError(field+".path", err.Error())
+		}
+
+		var value any
+		if operation.Op == "add" || operation.Op == "replace" || operation.Op == "test" {
+			if operation.Value == nil {
+				return structures.NewFieldError(field+".value", "is required")
+			}
+			if err := json.Unmarshal(operation.Value, &value); err != nil {
+				return structures.NewFieldError(field+".value", "is not valid JSON")
+			}
+		}
+
+		switch operation.Op {
+		case "add":
+			document[path] = value
+		case "remove", "replace":
+			if _, ok := document[path]; !ok {
+				return structures.NewFieldError(field+".path", "does not exist")
+			}
+			if operation.Op == "remove" {
+				delete(document, path)
+			} else {
+				document[path] = value
+			}
+		case "move", "copy":
+			from, err := memberName(operation.From)
+			if err != nil {
+				return structures.NewFieldError(field+".from", err.Error())
+			}
+			fromValue, ok := document[from]
+			if !ok {
+				return structures.NewFieldError(field+".from", "does not exist")
+			}
+			if operation.Op == "move" {
+				delete(document, from)
+			}
+			document[path] = fromValue
+		case "test":
+			if current, ok := document[path]; !ok || !reflect.DeepEqual(current, value) {
+				return fmt.Errorf("%w: test of %s failed", structures.ErrConflict, operation.Path)
+			}
+		default:
+			return structures.NewFieldError(field+".op", "must be one of add, remove, replace, move, copy, test")
+		}
+	}
+
+	return nil
+}
+
+// memberName decodes a JSON Pointer that refers to a top-level member.
+func memberName(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return "", errors.New("must be a JSON Pointer starting with /")
+	}
+
+	name := pointer[1:]
+	if strings.Contains(name, "/") {
+		return "", errors.New("must point to a top-level field")
+	}
+
+	name = strings.ReplaceAll(name, "~1", "/")
+	name = strings.ReplaceAll(name, "~0", "~")
+
+	return name, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+func TestApplyPatch(t *testing.T) {
+	base := structures.Subscription{
+		ID:            7,
+		ServiceName:   "Netflix",
+		Price:         59900,
+		Currency:      "RUB",
+		Category:      "video",
+		Tags:          []string{"tv"},
+		BillingPeriod: structures.BillingMonthly,
+		UserID:        "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		StartDate:     "01-2025",
+		EndDate:       "12-2025",
+		Version:       3,
+		Status:        structures.StatusActive,
+		Prices:        []structures.PriceChange{{EffectiveFrom: "03-2025", Price: 69900}},
+		Members:       []structures.Member{{UserID: "70601fee-2bf1-4721-ae6f-7636e79a0cba", Weight: 2}},
+	}
+
+	const (
+		mergeType = MergePatchContentType
+		patchType = JSONPatchContentType
+	)
+
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		want        func(s *structures.Subscription)
+		wantField   string
+		wantErr     error
+	}{
+		{
+			name: "merge replaces a field", contentType: mergeType,
+			patch: `{"price": "649.00"}`,
+			want:  func(s *structures.Subscription) { s.Price = 64900 },
+		},
+		{
+			name: "merge null removes a field", contentType: mergeType,
+			patch: `{"end_date": null, "tags": ["tv", "family"]}`,
+			want: func(s *structures.Subscription) {
+				s.EndDate = ""
+				s.Tags = []string{"tv", "family"}
+			},
+		},
+		{
+			name: "merge reads amounts in the new currency", contentType: mergeType,
+			patch:     `{"currency": "JPY", "price": "1500.5"}`,
+			wantField: "price",
+		},
+		{name: "merge of a non-object", contentType: mergeType, patch: `[1]`, wantField: "patch"},
+		{name: "merge of invalid JSON", contentType: mergeType, patch: `{`, wantField: "patch"},
+		{name: "merge of an unknown field", contentType: mergeType, patch: `{"colour": "red"}`, wantField: "patch"},
+		{name: "merge of a wrong type", contentType: mergeType, patch: `{"billing_months": "two"}`, wantField: "billing_months"},
+		{name: "merge cannot change the id", contentType: mergeType, patch: `{"id": 8}`, wantField: "id"},
+		{name: "merge cannot change the version", contentType: mergeType, patch: `{"version": 4}`, wantField: "version"},
+		{name: "merge cannot change prices", contentType: mergeType, patch: `{"prices": null}`, wantField: "prices"},
+		{name: "merge cannot change members", contentType: mergeType, patch: `{"members": []}`, wantField: "members"},
+		{name: "merge cannot change the status", contentType: mergeType, patch: `{"status": "paused"}`, wantField: "status"},
+		{name: "merge cannot delete", contentType: mergeType, patch: `{"deleted_at": "2025-06-01T00:00:00Z"}`, wantField: "deleted_at"},
+		{
+			name: "json patch test then replace", contentType: patchType,
+			patch: `[{"op": "test", "path": "/price", "value": "599.00"}, {"op": "replace", "path": "/price", "value": "649.00"}]`,
+			want:  func(s *structures.Subscription) { s.Price = 64900 },
+		},
+		{
+			name: "json patch failed test", contentType: patchType,
+			patch:   `[{"op": "test", "path": "/price", "value": "1.00"}, {"op": "replace", "path": "/price", "value": "649.00"}]`,
+			wantErr: structures.ErrConflict,
+		},
+		{
+			name: "json patch move", contentType: patchType,
+			patch: `[{"op": "move", "from": "/category", "path": "/service_name"}]`,
+			want: func(s *structures.Subscription) {
+				s.ServiceName = "video"
+				s.Category = ""
+			},
+		},
+		{
+			name: "json patch copy and remove", contentType: patchType,
+			patch: `[{"op": "copy", "from": "/start_date", "path": "/trial_end_date"}, {"op": "remove", "path": "/end_date"}]`,
+			want: func(s *structures.Subscription) {
+				s.TrialEndDate = "01-2025"
+				s.EndDate = ""
+			},
+		},
+		{
+			name: "json patch add then test", contentType: patchType,
+			patch: `[{"op": "add", "path": "/category", "value": "film"}, {"op": "test", "path": "/category", "value": "film"}]`,
+			want:  func(s *structures.Subscription) { s.Category = "film" },
+		},
+		{name: "json patch move from a missing field", contentType: patchType, patch: `[{"op": "move", "from": "/trial_end_date", "path": "/end_date"}]`, wantField: "patch[0].from"},
+		{name: "json patch remove of a missing field", contentType: patchType, patch: `[{"op": "remove", "path": "/trial_end_date"}]`, wantField: "patch[0].path"},
+		{name: "json patch nested pointer", contentType: patchType, patch: `[{"op": "replace", "path": "/prices/0/price", "value": "1.00"}]`, wantField: "patch[0].path"},
+		{name: "json patch without a value", contentType: patchType, patch: `[{"op": "replace", "path": "/price"}]`, wantField: "patch[0].value"},
+		{name: "json patch unknown op", contentType: patchType, patch: `[{"op": "swap", "path": "/price"}]`, wantField: "patch[0].op"},
+		{name: "json patch of an object", contentType: patchType, patch: `{"op": "remove"}`, wantField: "patch"},
+		{name: "json patch cannot change members", contentType: patchType, patch: `[{"op": "remove", "path": "/members"}]`, wantField: "members"},
+		{name: "json patch cannot delete", contentType: patchType, patch: `[{"op": "add", "path": "/deleted_at", "value": "2025-06-01T00:00:00Z"}]`, wantField: "deleted_at"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, err := applyPatch(base, []byte(tt.patch), tt.contentType)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.wantField != "":
+				var validationErr *structures.ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != tt.wantField {
+					t.Fatalf("got error %v, want a field error for %s", err, tt.wantField)
+				}
+				return
+			case err != nil:
+				t.Fatalf("applyPatch: %v", err)
+			}
+
+			want := base
+			want.Tags = append([]string(nil), base.Tags...)
+			tt.want(&want)
+			if !reflect.DeepEqual(patched, want) {
+				t.Fatalf("got %+v\nwant %+v", patched, want)
+			}
+		})
+	}
+
+	if _, err := applyPatch(base, []byte(`{}`), "application/json"); err == nil {
+		t.Fatal("applyPatch accepted an unsupported content type")
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+	"github.com/google/uuid"
+)
+
+// SetMembers replaces the users sharing the subscription with id and
+// returns the updated subscription. Non-empty versions work like in UpdateSub.
+func (s *SubscriptionService) SetMembers(
+	ctx context.Context,
+	id int,
+	versions []int,
+	request []structures.MemberJSON,
+) (structures.Subscription, error) {
+	const op = "services.subscriptionService.SetMembers"
+	log := s.log.With("op", op)
+
+	return s.modifySub(ctx, op, id, versions, func(current *structures.Subscription, versions []int) error {
+		members, err := structures.ParseMembers(request, current.Currency)
+		if err != nil {
+			log.Warn("Invalid members", sl.Err(err))
+			return err
+		}
+
+		for i := range members {
+			if userID, err := uuid.Parse(members[i].UserID); err == nil {
+				members[i].UserID = userID.String()
+			}
+		}
+
+		if err := ValidateMembers(members, current); err != nil {
+			log.Warn("Invalid members", sl.Err(err))
+			return err
+		}
+
+		for i, member := range members {
+			_, err := s.subscriptionRepo.SelectUserById(ctx, member.UserID)
+			if errors.Is(err, structures.ErrNotFound) {
+				err = structures.NewFieldError(fmt.Sprintf("members[%d].user_id", i), "must refer to an existing user")
+			}
+			if err != nil {
+				log.Warn("Failed to check member", sl.Err(err))
+				return err
+			}
+		}
+
+		shared := *current
+		shared.Members = members
+		check := s.watchBudgets(ctx, current, &shared)
+
+		if err := s.subscriptionRepo.SetMembers(ctx, id, members, versions); err != nil {
+			log.Error("Failed to set members", sl.Err(err))
+			return err
+		}
+
+		s.alertBudgets(ctx, check, id)
+
+		log.Info("Subscription members set", slog.Int("id", id), slog.Int("members", len(members)))
+
+		return nil
+	})
+}
+
+// Settlement returns what the members of shared subscriptions owe to their
+// owners for the period, netted between every two users. With data.UserID
+// only the debts of that user and to that user are listed.
+func (s *SubscriptionService) Settlement(ctx context.Context, data *structures.Counting) (structures.Settlement, error) {
+	const op = "services.subscriptionService.Settlement"
+	log := s.log.With("op", op)
+
+	normalizeCounting(data)
+
+	if err := ValidateCounting(data); err != nil {
+		log.Warn("Invalid counting filters", sl.Err(err))
+		return structures.Settlement{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	type pair struct {
+		from, to string
+	}
+
+	spend, err := s.countSpend(ctx, data, &structures.SpendQuery{GroupBy: structures.GroupByDebt})
+	if err != nil {
+		log.Error("Failed to count shares", sl.Err(err))
+		return structures.Settlement{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	owed := make(map[pair]structures.Money)
+	shared := make(map[pair][]int)
+	for _, group := range spend.Groups {
+		key := pair{from: group.Key, to: group.Owner}
+		owed[key] = group.Total
+		shared[key] = group.SubscriptionIDs
+	}
+
+	debts := []structures.Debt{}
+	for key, amount := range owed {
+		reverse := pair{from: key.to, to: key.from}
+
+		net := amount - owed[reverse]
+		if net <= 0 {
+			continue
+		}
+
+		ids := slices.Concat(shared[key], shared[reverse])
+		slices.Sort(ids)
+		ids = slices.Compact(ids)
+
+		debts = append(debts, structures.Debt{From: key.from, To: key.to, Amount: net, Subscriptions: ids})
+	}
+
+	sort.Slice(debts, func(i, j int) bool {
+		if debts[i].Amount != debts[j].Amount {
+			return debts[i].Amount > debts[j].Amount
+		}
+		if debts[i].From != debts[j].From {
+			return debts[i].From < debts[j].From
+		}
+		return debts[i].To < debts[j].To
+	})
+
+	return structures.Settlement{
+		StartDate: data.StartDate,
+		EndDate:   data.EndDate,
+		Debts:     debts,
+		Currency:  data.TargetCurrency,
+		Rates:     spend.Rates,
+		Rounding:  structures.SpendRounding,
+		Mode:      data.Mode,
+	}, nil
+}
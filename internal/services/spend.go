@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+)
+
+// countSpend returns the spend of the subscriptions matching data grouped as
+// query asks, counted by the repository in data.Mode and converted to
+// data.TargetCurrency. Shared subscriptions count with the share of every
+// payer, or of data.UserID if it is set. A service name filter matches the
+// aliases of its catalog service too. Amounts needing a rate that is not
+// stored are reported as field errors.
+func (s *SubscriptionService) countSpend(
+	ctx context.Context,
+	data *structures.Counting,
+	query *structures.SpendQuery,
+) (structures.Spend, error) {
+	if data.TargetCurrency == "" {
+		data.TargetCurrency = structures.DefaultCurrency
+	}
+
+	if err := s.resolveServiceFilter(ctx, &data.ServiceName, &data.ServiceID); err != nil {
+		return structures.Spend{}, err
+	}
+
+	spend, err := s.subscriptionRepo.SelectSpend(ctx, data, query)
+	if err != nil {
+		return structures.Spend{}, err
+	}
+
+	if err := missingRatesError(data.TargetCurrency, spend.MissingRates); err != nil {
+		return structures.Spend{}, err
+	}
+
+	return spend, nil
+}
+
+// missingRatesError returns a field error for every rate to target that is
+// missing, nil if there are none.
+func missingRatesError(target string, missing []structures.MissingRate) error {
+	var v validator
+	for _, rate := range missing {
+		v.add("target_currency", fmt.Sprintf(
+			"no %s to %s rate for %s or earlier",
+			rate.Currency, target, rate.Month,
+		))
+	}
+
+	return v.err()
+}
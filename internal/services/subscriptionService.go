@@ -1,8 +1,12 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/QwaQ-dev/servicesSubscription/internal/repository"
 	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
@@ -10,95 +14,658 @@ import (
 )
 
 type SubscriptionService struct {
-	subscriptionRepo *repository.SubscriptionRepo
+	subscriptionRepo repository.SubscriptionRepository
+	notifier         Notifier
+	budgetAlerts     chan structures.BudgetAlert
 	log              *slog.Logger
 }
 
+// budgetAlertQueue is how many alerts can wait for RunAlertJob.
+const budgetAlertQueue = 256
+
+// NewSubsriptionService returns the service, budget alerts go to notifier or
+// to the log if it is nil. They are only sent while RunAlertJob runs.
 func NewSubsriptionService(
-	subscriptionRepo *repository.SubscriptionRepo,
+	subscriptionRepo repository.SubscriptionRepository,
+	notifier Notifier,
 	log *slog.Logger,
 ) *SubscriptionService {
+	if notifier == nil {
+		notifier = NewLogNotifier(log)
+	}
+
 	return &SubscriptionService{
 		subscriptionRepo: subscriptionRepo,
+		notifier:         notifier,
+		budgetAlerts:     make(chan structures.BudgetAlert, budgetAlertQueue),
 		log:              log,
 	}
 }
 
-func (s *SubscriptionService) CreateSub(subscription *structures.Subscription) (int, error) {
+// normalizeSubscription upper-cases the currency code and lower-cases the
+// billing period, empty ones become DefaultCurrency and BillingMonthly. The
+// category and tags are lower-cased, tags are sorted without repeats.
+func normalizeSubscription(subscription *structures.Subscription) {
+	subscription.Currency = strings.ToUpper(strings.TrimSpace(subscription.Currency))
+	if subscription.Currency == "" {
+		subscription.Currency = structures.DefaultCurrency
+	}
+
+	subscription.BillingPeriod = strings.ToLower(strings.TrimSpace(subscription.BillingPeriod))
+	if subscription.BillingPeriod == "" {
+		subscription.BillingPeriod = structures.BillingMonthly
+	}
+
+	subscription.Category = normalizeLabel(subscription.Category)
+
+	tags := []string{}
+	for _, tag := range subscription.Tags {
+		if tag = normalizeLabel(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	subscription.Tags = slices.Compact(tags)
+	if len(subscription.Tags) == 0 {
+		subscription.Tags = nil
+	}
+}
+
+// normalizeLabel is the form categories and tags are stored and filtered in:
+// lower case with single spaces between words.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// normalizeCounting upper-cases the target currency, an empty one becomes
+// DefaultCurrency and an empty mode CountAmortized.
+func normalizeCounting(data *structures.Counting) {
+	data.TargetCurrency = strings.ToUpper(data.TargetCurrency)
+	if data.TargetCurrency == "" {
+		data.TargetCurrency = structures.DefaultCurrency
+	}
+	data.Category = normalizeLabel(data.Category)
+	data.Tag = normalizeLabel(data.Tag)
+
+	data.Mode = strings.ToLower(data.Mode)
+	if data.Mode == "" {
+		data.Mode = structures.CountAmortized
+	}
+}
+
+func (s *SubscriptionService) CreateSub(ctx context.Context, subscription *structures.Subscription) (int, error) {
 	const op = "services.subscriptionService.CreateSub"
 	log := s.log.With("op", op)
 
-	id, err := s.subscriptionRepo.InsertSub(subscription)
+	normalizeSubscription(subscription)
+
+	// Price changes are scheduled once the subscription exists.
+	subscription.Prices = nil
+
+	if err := s.resolveService(ctx, subscription); err != nil {
+		log.Warn("Failed to resolve service", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := ValidateSubscription(subscription); err != nil {
+		log.Warn("Invalid subscription", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.checkUserExists(ctx, subscription.UserID); err != nil {
+		log.Warn("Unknown user", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	subscription.Status = subscription.StatusIn(monthOf(time.Now()))
+	subscription.Pauses = nil
+	subscription.CancelledAt = nil
+
+	check := s.watchBudgets(ctx, nil, subscription)
+
+	id, err := s.subscriptionRepo.InsertSub(ctx, subscription)
 	if err != nil {
 		log.Error("Failed to create subscription", sl.Err(err))
-		return 0, fmt.Errorf("%s:%v", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
 	log.Info("Subscription created", slog.Int("id", id))
 
+	s.alertBudgets(ctx, check, id)
+
 	return id, nil
 }
 
-func (s *SubscriptionService) GetAllSubs() ([]structures.Subscription, error) {
+func (s *SubscriptionService) GetAllSubs(ctx context.Context, filter *structures.SubscriptionFilter) (structures.SubscriptionPage, error) {
 	const op = "services.subscriptionService.GetAllSubs"
 	log := s.log.With("op", op)
 
-	subscriptions, err := s.subscriptionRepo.SelectAllSubs()
+	filter.Category = normalizeLabel(filter.Category)
+	filter.Tag = normalizeLabel(filter.Tag)
+
+	if err := ValidateSubscriptionFilter(filter); err != nil {
+		log.Warn("Invalid subscription filter", sl.Err(err))
+		return structures.SubscriptionPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
+
+	if err := s.resolveServiceFilter(ctx, &filter.ServiceName, &filter.ServiceID); err != nil {
+		log.Error("Failed to resolve service", sl.Err(err))
+		return structures.SubscriptionPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page, err := s.subscriptionRepo.SelectAllSubs(ctx, filter)
 	if err != nil {
 		log.Error("Failed to get all subscriptions", sl.Err(err))
-		return nil, fmt.Errorf("%s:%v", op, err)
+		return page, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return subscriptions, nil
+	return page, nil
 }
 
-func (s *SubscriptionService) GetSubById(id int) (structures.Subscription, error) {
+// GetSubById returns the subscription with id. Soft-deleted subscriptions are
+// only returned when includeDeleted is set.
+func (s *SubscriptionService) GetSubById(ctx context.Context, id int, includeDeleted bool) (structures.Subscription, error) {
 	const op = "services.subscriptionService.GetSubById"
 	log := s.log.With("op", op)
 
-	subscription, err := s.subscriptionRepo.SelectSubById(id)
+	subscription, err := s.subscriptionRepo.SelectSubById(ctx, id, includeDeleted)
 	if err != nil {
 		log.Error("Failed to get sub by id", sl.Err(err))
-		return subscription, fmt.Errorf("%s:%v", op, err)
+		return subscription, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return subscription, nil
 }
 
-func (s *SubscriptionService) UpdateSub(subscription *structures.Subscription, id int) error {
+// UpdateSub overwrites the subscription with id. Non-empty versions make the
+// update conditional on the current version, see repository.SubscriptionRepository.
+func (s *SubscriptionService) UpdateSub(ctx context.Context, subscription *structures.Subscription, id int, versions []int) error {
 	const op = "services.subscriptionService.UpdateSub"
 	log := s.log.With("op", op)
 
-	err := s.subscriptionRepo.UpdateSub(subscription, id)
+	normalizeSubscription(subscription)
+
+	if err := s.resolveService(ctx, subscription); err != nil {
+		log.Warn("Failed to resolve service", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := s.subscriptionRepo.SelectSubById(ctx, id, false)
+	if err != nil {
+		log.Error("Failed to get sub by id", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The scheduled price changes are kept, the new dates must leave room for them.
+	subscription.Prices = current.Prices
+
+	if err := ValidateSubscription(subscription); err != nil {
+		log.Warn("Invalid subscription", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.checkUserExists(ctx, subscription.UserID); err != nil {
+		log.Warn("Unknown user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The members and the lifecycle are kept, they apply to the updated
+	// subscription too.
+	updated := *subscription
+	updated.ID = id
+	updated.Members = current.Members
+	updated.Status = current.Status
+	updated.Pauses = current.Pauses
+	updated.CancelledAt = current.CancelledAt
+
+	if err := ValidateFixedAmounts("price", &updated); err != nil {
+		log.Warn("Invalid subscription", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	check := s.watchBudgets(ctx, &current, &updated)
+
+	err = s.subscriptionRepo.UpdateSub(ctx, subscription, id, versions)
 	if err != nil {
-		log.Error("Failed to update sub", slog.Any("err", err))
+		log.Error("Failed to update sub", sl.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
+
+	s.alertBudgets(ctx, check, id)
+
 	return nil
 }
 
-func (s *SubscriptionService) DeleteSub(id int) error {
+// modifySub reads the subscription with id, checks its version against
+// non-empty versions like UpdateSub, calls write with it and returns the
+// subscription as written. write must store its change conditional on the
+// version passed to it, the one current was read at, so concurrent writes
+// between the read and the write are not lost. Errors returned by write
+// are logged by it.
+func (s *SubscriptionService) modifySub(
+	ctx context.Context,
+	op string,
+	id int,
+	versions []int,
+	write func(current *structures.Subscription, versions []int) error,
+) (structures.Subscription, error) {
+	log := s.log.With("op", op)
+
+	current, err := s.subscriptionRepo.SelectSubById(ctx, id, false)
+	if err != nil {
+		log.Error("Failed to get sub by id", sl.Err(err))
+		return current, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(versions) > 0 && !slices.Contains(versions, current.Version) {
+		return current, fmt.Errorf("%s: %w: sub %d was modified", op, structures.ErrPreconditionFailed, id)
+	}
+
+	if err := write(&current, []int{current.Version}); err != nil {
+		return current, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := s.subscriptionRepo.SelectSubById(ctx, id, false)
+	if err != nil {
+		log.Error("Failed to get updated sub", sl.Err(err))
+		return current, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// PatchSub applies a merge patch or a JSON patch to the subscription with id
+// and returns the updated subscription. Non-empty versions work like in UpdateSub.
+func (s *SubscriptionService) PatchSub(
+	ctx context.Context,
+	id int,
+	versions []int,
+	patch []byte,
+	contentType string,
+) (structures.Subscription, error) {
+	const op = "services.subscriptionService.PatchSub"
+	log := s.log.With("op", op)
+
+	return s.modifySub(ctx, op, id, versions, func(current *structures.Subscription, versions []int) error {
+		patched, err := applyPatch(*current, patch, contentType)
+		if err != nil {
+			log.Warn("Failed to apply patch", sl.Err(err))
+			return err
+		}
+
+		normalizeSubscription(&patched)
+		patched.Prices = current.Prices
+
+		// A new name without a new service_id is looked up again.
+		if patched.ServiceName != current.ServiceName && patched.ServiceID == current.ServiceID {
+			patched.ServiceID = 0
+		}
+
+		if err := s.resolveService(ctx, &patched); err != nil {
+			log.Warn("Failed to resolve service", sl.Err(err))
+			return err
+		}
+
+		if err := ValidateSubscription(&patched); err != nil {
+			log.Warn("Invalid subscription", sl.Err(err))
+			return err
+		}
+
+		if err := ValidateFixedAmounts("price", &patched); err != nil {
+			log.Warn("Invalid subscription", sl.Err(err))
+			return err
+		}
+
+		if err := s.checkUserExists(ctx, patched.UserID); err != nil {
+			log.Warn("Unknown user", sl.Err(err))
+			return err
+		}
+
+		check := s.watchBudgets(ctx, current, &patched)
+
+		if err := s.subscriptionRepo.UpdateSub(ctx, &patched, id, versions); err != nil {
+			log.Error("Failed to update sub", sl.Err(err))
+			return err
+		}
+
+		s.alertBudgets(ctx, check, id)
+
+		return nil
+	})
+}
+
+// SchedulePrice changes the price of the subscription with id from
+// change.EffectiveFrom on and returns the updated subscription. Spend in the
+// months before keeps the old price. Non-empty versions work like in UpdateSub.
+func (s *SubscriptionService) SchedulePrice(
+	ctx context.Context,
+	id int,
+	versions []int,
+	request *structures.PriceChangeJSON,
+) (structures.Subscription, error) {
+	const op = "services.subscriptionService.SchedulePrice"
+	log := s.log.With("op", op)
+
+	return s.modifySub(ctx, op, id, versions, func(current *structures.Subscription, versions []int) error {
+		change, err := request.PriceChange(current.Currency)
+		if err == nil {
+			err = ValidatePriceChange(&change, current)
+		}
+		if err != nil {
+			log.Warn("Invalid price change", sl.Err(err))
+			return err
+		}
+
+		updated := *current
+		updated.Prices = append(withoutPrice(current.Prices, change.EffectiveFrom), change)
+		slices.SortFunc(updated.Prices, func(a, b structures.PriceChange) int {
+			x, _ := structures.ParseMonth(a.EffectiveFrom)
+			y, _ := structures.ParseMonth(b.EffectiveFrom)
+			return x.Compare(y)
+		})
+
+		if err := ValidateFixedAmounts("price", &updated); err != nil {
+			log.Warn("Invalid price change", sl.Err(err))
+			return err
+		}
+
+		check := s.watchBudgets(ctx, current, &updated)
+
+		if err := s.subscriptionRepo.SchedulePrice(ctx, id, &change, versions); err != nil {
+			log.Error("Failed to schedule price", sl.Err(err))
+			return err
+		}
+
+		s.alertBudgets(ctx, check, id)
+
+		return nil
+	})
+}
+
+// DeletePrice removes the price change of the subscription with id scheduled
+// for month and returns the updated subscription. Non-empty versions work like
+// in UpdateSub.
+func (s *SubscriptionService) DeletePrice(
+	ctx context.Context,
+	id int,
+	versions []int,
+	month string,
+) (structures.Subscription, error) {
+	const op = "services.subscriptionService.DeletePrice"
+	log := s.log.With("op", op)
+
+	var v validator
+	v.month("month", month, true)
+	if err := v.err(); err != nil {
+		return structures.Subscription{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.modifySub(ctx, op, id, versions, func(current *structures.Subscription, versions []int) error {
+		updated := *current
+		updated.Prices = withoutPrice(current.Prices, month)
+		check := s.watchBudgets(ctx, current, &updated)
+
+		if err := s.subscriptionRepo.DeletePrice(ctx, id, month, versions); err != nil {
+			log.Error("Failed to delete price change", sl.Err(err))
+			return err
+		}
+
+		s.alertBudgets(ctx, check, id)
+
+		return nil
+	})
+}
+
+// withoutPrice returns a copy of prices without the change scheduled for month.
+func withoutPrice(prices []structures.PriceChange, month string) []structures.PriceChange {
+	effective, _ := structures.ParseMonth(month)
+
+	return slices.DeleteFunc(slices.Clone(prices), func(change structures.PriceChange) bool {
+		changeMonth, _ := structures.ParseMonth(change.EffectiveFrom)
+		return changeMonth.Equal(effective)
+	})
+}
+
+// DeleteSub soft-deletes the subscription with id, it can be restored with
+// RestoreSub until it is purged.
+func (s *SubscriptionService) DeleteSub(ctx context.Context, id int, versions []int) error {
 	const op = "services.subscriptionService.DeleteSub"
 	log := s.log.With("op", op)
 
-	err := s.subscriptionRepo.DeleteSub(id)
+	err := s.subscriptionRepo.DeleteSub(ctx, id, versions)
 	if err != nil {
-		log.Error("Failed to delete sub", slog.Int("id", id), slog.Any("err", err))
+		log.Error("Failed to delete sub", slog.Int("id", id), sl.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
 
-func (s *SubscriptionService) Counting(data *structures.Counting) (int, error) {
+// RestoreSub undoes the soft delete of the subscription with id and returns
+// the restored subscription. Non-empty versions work like in UpdateSub.
+func (s *SubscriptionService) RestoreSub(ctx context.Context, id int, versions []int) (structures.Subscription, error) {
+	const op = "services.subscriptionService.RestoreSub"
+	log := s.log.With("op", op)
+
+	// Deleted subscriptions are not counted, all of it is new spend.
+	deleted, err := s.subscriptionRepo.SelectSubById(ctx, id, true)
+	if err != nil {
+		log.Error("Failed to get deleted sub", slog.Int("id", id), sl.Err(err))
+		return structures.Subscription{}, fmt.Errorf("%s: %w", op, err)
+	}
+	check := s.watchBudgets(ctx, nil, &deleted)
+
+	if err := s.subscriptionRepo.RestoreSub(ctx, id, versions); err != nil {
+		log.Error("Failed to restore sub", slog.Int("id", id), sl.Err(err))
+		return structures.Subscription{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	restored, err := s.subscriptionRepo.SelectSubById(ctx, id, false)
+	if err != nil {
+		log.Error("Failed to get restored sub", sl.Err(err))
+		return restored, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.alertBudgets(ctx, check, id)
+
+	return restored, nil
+}
+
+// PurgeDeleted permanently removes subscriptions soft-deleted more than
+// retention ago and returns how many were removed.
+func (s *SubscriptionService) PurgeDeleted(ctx context.Context, retention time.Duration) (int, error) {
+	const op = "services.subscriptionService.PurgeDeleted"
+	log := s.log.With("op", op)
+
+	purged, err := s.subscriptionRepo.PurgeDeleted(ctx, time.Now().Add(-retention))
+	if err != nil {
+		log.Error("Failed to purge deleted subs", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if purged > 0 {
+		log.Info("Deleted subscriptions purged", slog.Int("count", purged))
+	}
+
+	return purged, nil
+}
+
+// RunPurgeJob calls PurgeDeleted every interval until ctx is cancelled.
+func (s *SubscriptionService) RunPurgeJob(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Errors are logged by PurgeDeleted, the next tick retries.
+		_, _ = s.PurgeDeleted(ctx, retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetHistory returns the changes of the subscription with id, oldest first.
+// Subscriptions without any recorded change are checked to exist.
+func (s *SubscriptionService) GetHistory(ctx context.Context, id int) ([]structures.AuditEntry, error) {
+	const op = "services.subscriptionService.GetHistory"
+	log := s.log.With("op", op)
+
+	entries, err := s.subscriptionRepo.SelectHistory(ctx, id)
+	if err != nil {
+		log.Error("Failed to get history", slog.Int("id", id), sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(entries) == 0 {
+		if _, err := s.subscriptionRepo.SelectSubById(ctx, id, true); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return entries, nil
+}
+
+func (s *SubscriptionService) GetAudit(ctx context.Context, filter *structures.AuditFilter) (structures.AuditPage, error) {
+	const op = "services.subscriptionService.GetAudit"
+	log := s.log.With("op", op)
+
+	if err := ValidateAuditFilter(filter); err != nil {
+		log.Warn("Invalid audit filter", sl.Err(err))
+		return structures.AuditPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
+
+	page, err := s.subscriptionRepo.SelectAudit(ctx, filter)
+	if err != nil {
+		log.Error("Failed to get audit", sl.Err(err))
+		return page, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
+
+// Counting returns the spend of the period in data.TargetCurrency.
+func (s *SubscriptionService) Counting(ctx context.Context, data *structures.Counting) (structures.SpendTotal, error) {
 	const op = "services.subscriptionService.Counting"
 	log := s.log.With("op", op)
 
-	total, err := s.subscriptionRepo.SelectSum(data)
+	normalizeCounting(data)
+
+	if err := ValidateCounting(data); err != nil {
+		log.Warn("Invalid counting filters", sl.Err(err))
+		return structures.SpendTotal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	spend, err := s.countSpend(ctx, data, &structures.SpendQuery{})
 	if err != nil {
 		log.Error("Failed to count sum", sl.Err(err))
-		return 0, fmt.Errorf("%s: %v", op, err)
+		return structures.SpendTotal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var total structures.Money
+	for _, group := range spend.Groups {
+		total += group.Total
+	}
+
+	return structures.SpendTotal{
+		Total:    total,
+		Currency: data.TargetCurrency,
+		Rates:    spend.Rates,
+		Rounding: structures.SpendRounding,
+		Mode:     data.Mode,
+	}, nil
+}
+
+func (s *SubscriptionService) MonthlyCounting(ctx context.Context, data *structures.Counting) (structures.MonthlySpendReport, error) {
+	const op = "services.subscriptionService.MonthlyCounting"
+	log := s.log.With("op", op)
+
+	normalizeCounting(data)
+
+	if err := ValidateCounting(data); err != nil {
+		log.Warn("Invalid counting filters", sl.Err(err))
+		return structures.MonthlySpendReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	start, _ := structures.ParseMonth(data.StartDate)
+	end, _ := structures.ParseMonth(data.EndDate)
+
+	months := []structures.MonthlySpend{}
+	index := make(map[string]int)
+	for month := start; !month.After(end); month = month.AddDate(0, 1, 0) {
+		index[structures.FormatMonth(month)] = len(months)
+		months = append(months, structures.MonthlySpend{Month: structures.FormatMonth(month)})
+	}
+
+	spend, err := s.countSpend(ctx, data, &structures.SpendQuery{ByMonth: true})
+	if err != nil {
+		log.Error("Failed to count monthly sum", sl.Err(err))
+		return structures.MonthlySpendReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// A shared subscription is counted once per month, not once per payer.
+	for _, group := range spend.Groups {
+		i := index[group.Month]
+		months[i].Total = group.Total
+		months[i].Subscriptions = group.Subscriptions
+	}
+
+	return structures.MonthlySpendReport{
+		Months:   months,
+		Currency: data.TargetCurrency,
+		Rates:    spend.Rates,
+		Rounding: structures.SpendRounding,
+		Mode:     data.Mode,
+	}, nil
+}
+
+// GroupedCounting returns the spend per data.GroupBy ordered by amount, the
+// first data.Top groups if it is set. Shared subscriptions count for every
+// user with the share of that user.
+func (s *SubscriptionService) GroupedCounting(ctx context.Context, data *structures.Counting) (structures.GroupSpendReport, error) {
+	const op = "services.subscriptionService.GroupedCounting"
+	log := s.log.With("op", op)
+
+	normalizeCounting(data)
+
+	if err := ValidateCounting(data); err != nil {
+		log.Warn("Invalid counting filters", sl.Err(err))
+		return structures.GroupSpendReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch data.GroupBy {
+	case structures.GroupByService, structures.GroupByUser, structures.GroupByCategory:
+	default:
+		return structures.GroupSpendReport{}, fmt.Errorf("%s: %w", op, structures.NewFieldError("group_by", "must be service_name, user_id or category"))
+	}
+
+	spend, err := s.countSpend(ctx, data, &structures.SpendQuery{GroupBy: data.GroupBy, Top: data.Top})
+	if err != nil {
+		log.Error("Failed to count grouped sum", sl.Err(err))
+		return structures.GroupSpendReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	groups := make([]structures.GroupSpend, 0, len(spend.Groups))
+	for _, group := range spend.Groups {
+		groups = append(groups, structures.GroupSpend{Key: group.Key, Total: group.Total, Subscriptions: group.Subscriptions})
 	}
 
-	return total, nil
+	return structures.GroupSpendReport{
+		GroupBy:  data.GroupBy,
+		Groups:   groups,
+		Currency: data.TargetCurrency,
+		Rates:    spend.Rates,
+		Rounding: structures.SpendRounding,
+		Mode:     data.Mode,
+	}, nil
 }
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/QwaQ-dev/servicesSubscription/pkg/sl"
+)
+
+// normalizeUser trims the name and lower-cases the email.
+func normalizeUser(user *structures.User) {
+	user.ID = strings.ToLower(strings.TrimSpace(user.ID))
+	user.Name = strings.TrimSpace(user.Name)
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+}
+
+// CreateUser stores user with the given ID or a generated one and returns it.
+func (s *SubscriptionService) CreateUser(ctx context.Context, user *structures.User) (string, error) {
+	const op = "services.subscriptionService.CreateUser"
+	log := s.log.With("op", op)
+
+	normalizeUser(user)
+
+	if err := ValidateUser(user); err != nil {
+		log.Warn("Invalid user", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := s.subscriptionRepo.InsertUser(ctx, user)
+	if err != nil {
+		log.Error("Failed to create user", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("User created", slog.String("id", id))
+
+	return id, nil
+}
+
+func (s *SubscriptionService) GetUsers(ctx context.Context, filter *structures.UserFilter) (structures.UserPage, error) {
+	const op = "services.subscriptionService.GetUsers"
+	log := s.log.With("op", op)
+
+	if err := ValidateUserFilter(filter); err != nil {
+		log.Warn("Invalid user filter", sl.Err(err))
+		return structures.UserPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
+
+	page, err := s.subscriptionRepo.SelectUsers(ctx, filter)
+	if err != nil {
+		log.Error("Failed to get users", sl.Err(err))
+		return page, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
+
+func (s *SubscriptionService) GetUserById(ctx context.Context, id string) (structures.User, error) {
+	const op = "services.subscriptionService.GetUserById"
+	log := s.log.With("op", op)
+
+	user, err := s.subscriptionRepo.SelectUserById(ctx, id)
+	if err != nil {
+		log.Error("Failed to get user by id", sl.Err(err))
+		return user, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// UpdateUser overwrites the name and email of the user with id, its ID and
+// creation time cannot change.
+func (s *SubscriptionService) UpdateUser(ctx context.Context, user *structures.User, id string) error {
+	const op = "services.subscriptionService.UpdateUser"
+	log := s.log.With("op", op)
+
+	normalizeUser(user)
+
+	if user.ID != "" && user.ID != id {
+		return fmt.Errorf("%s: %w", op, structures.NewFieldError("id", "cannot be changed"))
+	}
+	user.ID = ""
+
+	if err := ValidateUser(user); err != nil {
+		log.Warn("Invalid user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.subscriptionRepo.UpdateUser(ctx, user, id); err != nil {
+		log.Error("Failed to update user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteUser removes the user with id. Users with subscriptions, even
+// soft-deleted ones, cannot be deleted until they are purged.
+func (s *SubscriptionService) DeleteUser(ctx context.Context, id string) error {
+	const op = "services.subscriptionService.DeleteUser"
+	log := s.log.With("op", op)
+
+	if err := s.subscriptionRepo.DeleteUser(ctx, id); err != nil {
+		log.Error("Failed to delete user", slog.String("id", id), sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetUserSubs returns the subscriptions of the user with id matching filter,
+// whose UserID is ignored.
+func (s *SubscriptionService) GetUserSubs(
+	ctx context.Context,
+	id string,
+	filter *structures.SubscriptionFilter,
+) (structures.SubscriptionPage, error) {
+	const op = "services.subscriptionService.GetUserSubs"
+
+	if _, err := s.GetUserById(ctx, id); err != nil {
+		return structures.SubscriptionPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter.UserID = id
+
+	page, err := s.GetAllSubs(ctx, filter)
+	if err != nil {
+		return page, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
+
+// GetUserSummary counts the subscriptions of the user with id that are
+// active or in trial this month, shared ones included, the share of their
+// amortized spend this month the user pays in targetCurrency and finds the
+// next charge of any of them.
+func (s *SubscriptionService) GetUserSummary(ctx context.Context, id, targetCurrency string) (structures.UserSummary, error) {
+	const op = "services.subscriptionService.GetUserSummary"
+	log := s.log.With("op", op)
+
+	if _, err := s.GetUserById(ctx, id); err != nil {
+		return structures.UserSummary{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := monthOf(time.Now())
+
+	data := &structures.Counting{
+		StartDate:      structures.FormatMonth(now),
+		EndDate:        structures.FormatMonth(now),
+		UserID:         id,
+		TargetCurrency: targetCurrency,
+	}
+	normalizeCounting(data)
+
+	if err := ValidateCounting(data); err != nil {
+		log.Warn("Invalid summary parameters", sl.Err(err))
+		return structures.UserSummary{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	subscriptions, err := s.subscriptionRepo.SelectCountedSubs(ctx, data)
+	if err != nil {
+		log.Error("Failed to get subscriptions", sl.Err(err))
+		return structures.UserSummary{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	summary := structures.UserSummary{
+		UserID:   id,
+		Month:    data.StartDate,
+		Rounding: structures.SpendRounding,
+	}
+
+	for i := range subscriptions {
+		switch subscriptions[i].StatusIn(now) {
+		case structures.StatusTrial, structures.StatusActive:
+			if !subscriptions[i].IsPausedIn(now) {
+				summary.ActiveSubscriptions++
+			}
+		}
+	}
+
+	spend, err := s.countSpend(ctx, data, &structures.SpendQuery{})
+	if err != nil {
+		log.Error("Failed to count monthly spend", sl.Err(err))
+		return structures.UserSummary{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, group := range spend.Groups {
+		summary.MonthlySpend += group.Total
+	}
+	summary.Currency, summary.Rates = data.TargetCurrency, spend.Rates
+
+	renewals, err := s.GetRenewals(ctx, &structures.RenewalFilter{
+		Within: fmt.Sprintf("%dd", maxRenewalDays),
+		UserID: id,
+		Limit:  1,
+	})
+	if err != nil {
+		return structures.UserSummary{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(renewals.Renewals) > 0 {
+		summary.NextRenewal = &renewals.Renewals[0]
+	}
+
+	return summary, nil
+}
+
+// checkUserExists returns a field error for user_id unless it refers to a
+// user, subscriptions cannot be stored for unknown users.
+func (s *SubscriptionService) checkUserExists(ctx context.Context, userID string) error {
+	_, err := s.subscriptionRepo.SelectUserById(ctx, userID)
+	if errors.Is(err, structures.ErrNotFound) {
+		return structures.NewFieldError("user_id", "must refer to an existing user, create it with POST /users/ first")
+	}
+
+	return err
+}
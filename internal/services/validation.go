@@ -0,0 +1,737 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/servicesSubscription/internal/structures"
+	"github.com/google/uuid"
+)
+
+const (
+	maxServiceNameLength = 255
+	maxUserNameLength    = 255
+	maxCategoryLength    = 255
+	maxTags              = 20
+	maxTagLength         = 50
+	maxMembers           = 50
+	maxBudgetMonths      = 120
+	defaultPageLimit     = 50
+	maxPageLimit         = 500
+	maxBillingMonths     = 120
+	maxChargeMonths      = 120
+	maxRenewalDays       = 366
+)
+
+type validator struct {
+	fields []structures.FieldError
+}
+
+func (v *validator) add(field, message string) {
+	v.fields = append(v.fields, structures.FieldError{Field: field, Message: message})
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	return &structures.ValidationError{Fields: v.fields}
+}
+
+// month checks an MM-YYYY field and reports whether it holds a valid month.
+func (v *validator) month(field, value string, required bool) bool {
+	if value == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return false
+	}
+
+	if _, err := structures.ParseMonth(value); err != nil {
+		v.add(field, "must be a month in MM-YYYY format")
+		return false
+	}
+
+	return true
+}
+
+// period checks that end is not before start, both being valid MM-YYYY months.
+func (v *validator) period(startField, start, endField, end string) {
+	startMonth, _ := structures.ParseMonth(start)
+	endMonth, _ := structures.ParseMonth(end)
+
+	if endMonth.Before(startMonth) {
+		v.add(endField, "must not be before "+startField)
+	}
+}
+
+func (v *validator) uuid(field, value string, required bool) {
+	if value == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+
+	if _, err := uuid.Parse(value); err != nil {
+		v.add(field, "must be a valid UUID")
+	}
+}
+
+func ValidateSubscription(subscription *structures.Subscription) error {
+	var v validator
+
+	serviceName := strings.TrimSpace(subscription.ServiceName)
+	switch {
+	case serviceName == "":
+		v.add("service_name", "is required")
+	case len(serviceName) > maxServiceNameLength:
+		v.add("service_name", "must be at most 255 characters")
+	}
+
+	if subscription.Price < 0 {
+		v.add("price", "must not be negative")
+	}
+
+	if subscription.ServiceID < 0 {
+		v.add("service_id", "must not be negative")
+	}
+
+	if len(subscription.Category) > maxCategoryLength {
+		v.add("category", "must be at most 255 characters")
+	}
+
+	if len(subscription.Tags) > maxTags {
+		v.add("tags", "must be at most 20 tags")
+	}
+	for _, tag := range subscription.Tags {
+		if len(tag) > maxTagLength {
+			v.add("tags", "must be at most 50 characters each")
+			break
+		}
+	}
+
+	if !structures.IsCurrencyCode(subscription.Currency) {
+		v.add("currency", "must be an ISO 4217 code like RUB, USD or EUR")
+	}
+
+	switch subscription.BillingPeriod {
+	case structures.BillingWeekly, structures.BillingMonthly, structures.BillingQuarterly, structures.BillingYearly:
+		if subscription.BillingMonths != 0 {
+			v.add("billing_months", "is only allowed with custom billing_period")
+		}
+	case structures.BillingCustom:
+		if subscription.BillingMonths < 1 || subscription.BillingMonths > maxBillingMonths {
+			v.add("billing_months", "must be between 1 and 120 for custom billing_period")
+		}
+	default:
+		v.add("billing_period", "must be weekly, monthly, quarterly, yearly or custom")
+	}
+
+	v.uuid("user_id", subscription.UserID, true)
+
+	startOK := v.month("start_date", subscription.StartDate, true)
+	endOK := v.month("end_date", subscription.EndDate, false)
+	if startOK && endOK {
+		v.period("start_date", subscription.StartDate, "end_date", subscription.EndDate)
+	}
+
+	// The scheduled price changes of an existing subscription, ordered by
+	// month, must stay between its dates, see ValidatePriceChange.
+	if prices := subscription.Prices; startOK && endOK && len(prices) > 0 {
+		start, _ := structures.ParseMonth(subscription.StartDate)
+		first, _ := structures.ParseMonth(prices[0].EffectiveFrom)
+		if !first.After(start) {
+			v.add("start_date", fmt.Sprintf("must be before the price change scheduled for %s, delete it first", prices[0].EffectiveFrom))
+		}
+
+		if subscription.EndDate != "" {
+			end, _ := structures.ParseMonth(subscription.EndDate)
+			last, _ := structures.ParseMonth(prices[len(prices)-1].EffectiveFrom)
+			if last.After(end) {
+				v.add("end_date", fmt.Sprintf("must not be before the price change scheduled for %s, delete it first", prices[len(prices)-1].EffectiveFrom))
+			}
+		}
+	}
+
+	trialOK := v.month("trial_end_date", subscription.TrialEndDate, false)
+	if startOK && trialOK {
+		v.period("start_date", subscription.StartDate, "trial_end_date", subscription.TrialEndDate)
+	}
+	if endOK && trialOK {
+		trialEnd, _ := structures.ParseMonth(subscription.TrialEndDate)
+		end, _ := structures.ParseMonth(subscription.EndDate)
+		if trialEnd.After(end) {
+			v.add("trial_end_date", "must not be after end_date")
+		}
+	}
+
+	switch {
+	case subscription.TrialPrice < 0:
+		v.add("trial_price", "must not be negative")
+	case subscription.TrialPrice > subscription.Price:
+		v.add("trial_price", "must not exceed price")
+	case subscription.TrialPrice != 0 && subscription.TrialEndDate == "":
+		v.add("trial_price", "requires trial_end_date")
+	}
+
+	return v.err()
+}
+
+func ValidateUser(user *structures.User) error {
+	var v validator
+
+	v.uuid("id", user.ID, false)
+
+	switch {
+	case user.Name == "":
+		v.add("name", "is required")
+	case len(user.Name) > maxUserNameLength:
+		v.add("name", "must be at most 255 characters")
+	}
+
+	if user.Email != "" {
+		if address, err := mail.ParseAddress(user.Email); err != nil || address.Address != user.Email {
+			v.add("email", "must be an email address like user@example.com")
+		}
+	}
+
+	return v.err()
+}
+
+func ValidateUserFilter(filter *structures.UserFilter) error {
+	var v validator
+
+	if filter.Limit < 0 || filter.Limit > maxPageLimit {
+		v.add("limit", "must be between 0 and 500")
+	}
+
+	if filter.Offset < 0 {
+		v.add("offset", "must not be negative")
+	}
+
+	return v.err()
+}
+
+func ValidateService(service *structures.Service) error {
+	var v validator
+
+	switch {
+	case service.Name == "":
+		v.add("name", "is required")
+	case len(service.Name) > maxServiceNameLength:
+		v.add("name", "must be at most 255 characters")
+	}
+
+	for i, alias := range service.Aliases {
+		if len(alias) > maxServiceNameLength {
+			v.add(fmt.Sprintf("aliases[%d]", i), "must be at most 255 characters")
+		}
+	}
+
+	if service.Website != "" {
+		if website, err := url.Parse(service.Website); err != nil ||
+			(website.Scheme != "http" && website.Scheme != "https") || website.Host == "" {
+			v.add("website", "must be an http or https URL")
+		}
+	}
+
+	if service.DefaultPrice < 0 {
+		v.add("default_price", "must not be negative")
+	}
+
+	if !structures.IsCurrencyCode(service.Currency) {
+		v.add("currency", "must be an ISO 4217 code like RUB, USD or EUR")
+	}
+
+	return v.err()
+}
+
+func ValidateServiceLookup(lookup *structures.ServiceLookup) error {
+	var v validator
+
+	if strings.TrimSpace(lookup.Query) == "" {
+		v.add("q", "is required")
+	}
+
+	if lookup.Limit < 0 || lookup.Limit > maxLookupLimit {
+		v.add("limit", "must be between 0 and 50")
+	}
+
+	return v.err()
+}
+
+// ValidatePause checks the first month of a pause or of billing after it,
+// which must not be before the current month or outside the subscription.
+func ValidatePause(request *structures.PauseRequest, subscription *structures.Subscription, now time.Time) error {
+	var v validator
+
+	if v.month("from", request.From, true) {
+		from, _ := structures.ParseMonth(request.From)
+
+		if from.Before(now) {
+			v.add("from", "must not be before the current month")
+		}
+
+		if start, _ := structures.ParseMonth(subscription.StartDate); from.Before(start) {
+			v.add("from", "must not be before start_date")
+		}
+
+		if subscription.EndDate != "" {
+			end, _ := structures.ParseMonth(subscription.EndDate)
+			if from.After(end) {
+				v.add("from", "must not be after end_date")
+			}
+		}
+	}
+
+	return v.err()
+}
+
+func ValidateCancel(request *structures.CancelRequest) error {
+	switch request.CancelOption() {
+	case structures.CancelAtPeriodEnd, structures.CancelImmediately:
+		return nil
+	}
+
+	return structures.NewFieldError("at", "must be period_end or immediately")
+}
+
+func ValidateCounting(data *structures.Counting) error {
+	var v validator
+
+	startOK := v.month("start_date", data.StartDate, true)
+	endOK := v.month("end_date", data.EndDate, true)
+	if startOK && endOK {
+		v.period("start_date", data.StartDate, "end_date", data.EndDate)
+
+		start, _ := structures.ParseMonth(data.StartDate)
+		end, _ := structures.ParseMonth(data.EndDate)
+		if end.After(start.AddDate(0, maxChargeMonths-1, 0)) {
+			v.add("end_date", "must be at most 120 months after start_date")
+		}
+	}
+
+	v.uuid("user_id", data.UserID, false)
+
+	if data.ServiceID < 0 {
+		v.add("service_id", "must not be negative")
+	}
+
+	switch data.GroupBy {
+	case "", structures.GroupByService, structures.GroupByUser, structures.GroupByCategory:
+	default:
+		v.add("group_by", "must be service_name, user_id or category")
+	}
+
+	if data.Top < 0 {
+		v.add("top", "must not be negative")
+	}
+
+	if data.TargetCurrency != "" && !structures.IsCurrencyCode(data.TargetCurrency) {
+		v.add("target_currency", "must be an ISO 4217 code like RUB, USD or EUR")
+	}
+
+	if data.Mode != structures.CountAmortized && data.Mode != structures.CountCharges {
+		v.add("mode", "must be amortized or charges")
+	}
+
+	return v.err()
+}
+
+func ValidateSubscriptionFilter(filter *structures.SubscriptionFilter) error {
+	var v validator
+
+	if filter.Limit < 0 || filter.Limit > maxPageLimit {
+		v.add("limit", "must be between 0 and 500")
+	}
+
+	if filter.Offset < 0 {
+		v.add("offset", "must not be negative")
+	}
+
+	sortField, _ := filter.SortField()
+	switch sortField {
+	case structures.SortByID, structures.SortByPrice, structures.SortByStartDate, structures.SortByServiceName:
+	default:
+		v.add("sort", "must be one of id, price, start_date, service_name, optionally prefixed with -")
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := structures.DecodeCursor(filter.Cursor)
+		switch {
+		case err != nil:
+			v.add("cursor", "is malformed")
+		case cursor.Sort != filter.Sort:
+			v.add("cursor", "was issued for a different sort")
+		case filter.Offset != 0:
+			v.add("cursor", "cannot be combined with offset")
+		}
+	}
+
+	v.uuid("user_id", filter.UserID, false)
+	v.month("active_at", filter.ActiveAt, false)
+
+	if filter.ServiceID < 0 {
+		v.add("service_id", "must not be negative")
+	}
+
+	minPrice, minOK := v.priceBound("min_price", filter.MinPrice)
+	maxPrice, maxOK := v.priceBound("max_price", filter.MaxPrice)
+	if minOK && maxOK && filter.MinPrice != "" && filter.MaxPrice != "" && maxPrice < minPrice {
+		v.add("max_price", "must not be less than min_price")
+	}
+
+	switch filter.Status {
+	case "", structures.StatusTrial, structures.StatusActive, structures.StatusPaused,
+		structures.StatusCancelled, structures.StatusExpired:
+	default:
+		v.add("status", "must be one of trial, active, paused, cancelled, expired")
+	}
+
+	return v.err()
+}
+
+// priceBound checks an optional min_price or max_price.
+func (v *validator) priceBound(field string, value structures.Decimal) (structures.Money, bool) {
+	if value == "" {
+		return 0, true
+	}
+
+	bound, err := structures.PriceBound(value)
+	switch {
+	case err != nil:
+		v.add(field, err.Error())
+		return 0, false
+	case bound < 0:
+		v.add(field, "must not be negative")
+		return 0, false
+	}
+
+	return bound, true
+}
+
+// timestamp checks an optional RFC 3339 field.
+func (v *validator) timestamp(field, value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		v.add(field, "must be an RFC 3339 timestamp")
+		return time.Time{}, false
+	}
+
+	return parsed, true
+}
+
+func ValidateAuditFilter(filter *structures.AuditFilter) error {
+	var v validator
+
+	if filter.Limit < 0 || filter.Limit > maxPageLimit {
+		v.add("limit", "must be between 0 and 500")
+	}
+
+	if filter.Offset < 0 {
+		v.add("offset", "must not be negative")
+	}
+
+	switch filter.Action {
+	case "", structures.AuditCreate, structures.AuditUpdate, structures.AuditDelete,
+		structures.AuditRestore, structures.AuditPurge:
+	default:
+		v.add("action", "must be one of create, update, delete, restore, purge")
+	}
+
+	if filter.SubscriptionID < 0 {
+		v.add("subscription_id", "must not be negative")
+	}
+
+	from, hasFrom := v.timestamp("from", filter.From)
+	to, hasTo := v.timestamp("to", filter.To)
+
+	if hasFrom && hasTo && to.Before(from) {
+		v.add("to", "must not be before from")
+	}
+
+	return v.err()
+}
+
+// ValidatePriceChange checks a price change scheduled for subscription: it has
+// to take effect after start_date and not after end_date.
+func ValidatePriceChange(change *structures.PriceChange, subscription *structures.Subscription) error {
+	var v validator
+
+	if change.Price < 0 {
+		v.add("price", "must not be negative")
+	}
+
+	if v.month("effective_from", change.EffectiveFrom, true) {
+		effective, _ := structures.ParseMonth(change.EffectiveFrom)
+		start, _ := structures.ParseMonth(subscription.StartDate)
+
+		if !effective.After(start) {
+			v.add("effective_from", "must be after start_date, change price instead")
+		}
+
+		if subscription.EndDate != "" {
+			end, _ := structures.ParseMonth(subscription.EndDate)
+			if effective.After(end) {
+				v.add("effective_from", "must not be after end_date")
+			}
+		}
+	}
+
+	return v.err()
+}
+
+// ValidateMembers checks the members of subscription: each user at most
+// once with either a weight or an amount, the amounts at most the price.
+func ValidateMembers(members []structures.Member, subscription *structures.Subscription) error {
+	var v validator
+
+	if len(members) > maxMembers {
+		v.add("members", "must be at most 50 members")
+	}
+
+	seen := make(map[string]bool)
+	for i, member := range members {
+		field := fmt.Sprintf("members[%d]", i)
+
+		v.uuid(field+".user_id", member.UserID, true)
+		if seen[member.UserID] {
+			v.add(field+".user_id", "is listed twice")
+		}
+		seen[member.UserID] = true
+
+		if member.Weight < 0 {
+			v.add(field+".weight", "must not be negative")
+		}
+		if member.Amount < 0 {
+			v.add(field+".amount", "must not be negative")
+		}
+		if member.Weight > 0 && member.Amount > 0 {
+			v.add(field+".amount", "cannot be combined with weight")
+		}
+	}
+
+	if fixedAmounts(members) > lowestPrice(subscription) {
+		v.add("members", "fixed amounts must not exceed the price or a scheduled price")
+	}
+
+	return v.err()
+}
+
+// ValidateFixedAmounts checks that the fixed amounts of the members of
+// subscription still fit in its price and every scheduled price after a
+// change of field.
+func ValidateFixedAmounts(field string, subscription *structures.Subscription) error {
+	var v validator
+
+	if fixedAmounts(subscription.Members) > lowestPrice(subscription) {
+		v.add(field, "must not be less than the fixed amounts of the members")
+	}
+
+	return v.err()
+}
+
+// fixedAmounts returns what the members paying a fixed amount pay together.
+func fixedAmounts(members []structures.Member) structures.Money {
+	var fixed structures.Money
+	for _, member := range members {
+		fixed += member.Amount
+	}
+	return fixed
+}
+
+// lowestPrice returns the lowest of the price of subscription and the
+// prices scheduled for it.
+func lowestPrice(subscription *structures.Subscription) structures.Money {
+	lowest := subscription.Price
+	for _, change := range subscription.Prices {
+		lowest = min(lowest, change.Price)
+	}
+	return lowest
+}
+
+// ValidateBudget checks that budget has a user, a positive amount and at
+// most one of category and service_id.
+func ValidateBudget(budget *structures.Budget) error {
+	var v validator
+
+	v.uuid("user_id", budget.UserID, true)
+
+	if budget.Amount <= 0 {
+		v.add("amount", "must be positive")
+	}
+
+	if !structures.IsCurrencyCode(budget.Currency) {
+		v.add("currency", "must be an ISO 4217 code like RUB, USD or EUR")
+	}
+
+	if len(budget.Category) > maxCategoryLength {
+		v.add("category", "must be at most 255 characters")
+	}
+
+	switch {
+	case budget.ServiceID < 0:
+		v.add("service_id", "must not be negative")
+	case budget.ServiceID != 0 && budget.Category != "":
+		v.add("service_id", "cannot be combined with category")
+	}
+
+	return v.err()
+}
+
+// ValidateBudgetReportFilter checks the months of the report, at most
+// maxBudgetMonths of them.
+func ValidateBudgetReportFilter(filter *structures.BudgetReportFilter) error {
+	var v validator
+
+	v.uuid("user_id", filter.UserID, false)
+
+	fromOK := v.month("from", filter.From, true)
+	toOK := v.month("to", filter.To, true)
+	if fromOK && toOK {
+		v.period("from", filter.From, "to", filter.To)
+
+		from, _ := structures.ParseMonth(filter.From)
+		to, _ := structures.ParseMonth(filter.To)
+		if to.After(from.AddDate(0, maxBudgetMonths-1, 0)) {
+			v.add("to", "must be at most 120 months after from")
+		}
+	}
+
+	if filter.Mode != structures.CountAmortized && filter.Mode != structures.CountCharges {
+		v.add("mode", "must be amortized or charges")
+	}
+
+	return v.err()
+}
+
+func ValidateExchangeRates(rates []structures.ExchangeRate) error {
+	var v validator
+
+	if len(rates) == 0 {
+		v.add("rates", "must not be empty")
+	}
+
+	for i, rate := range rates {
+		field := fmt.Sprintf("rates[%d]", i)
+
+		v.month(field+".month", rate.Month, true)
+
+		if !structures.IsCurrencyCode(rate.From) {
+			v.add(field+".from", "must be an ISO 4217 code like RUB, USD or EUR")
+		}
+		if !structures.IsCurrencyCode(rate.To) {
+			v.add(field+".to", "must be an ISO 4217 code like RUB, USD or EUR")
+		}
+		if rate.From == rate.To {
+			v.add(field+".to", "must differ from from")
+		}
+
+		if err := checkRate(rate.Rate); err != nil {
+			v.add(field+".rate", err.Error())
+		}
+	}
+
+	return v.err()
+}
+
+// checkRate checks that rate is a positive decimal numeric(20, 10) holds.
+func checkRate(rate structures.Rate) error {
+	parsed, err := structures.ParseRate(string(rate))
+	if err != nil {
+		return err
+	}
+
+	if value, _ := parsed.Rat(); value.Sign() <= 0 {
+		return errors.New("must be positive")
+	}
+
+	return nil
+}
+
+func ValidateExchangeRateFilter(filter *structures.ExchangeRateFilter) error {
+	var v validator
+
+	if filter.Currency != "" && !structures.IsCurrencyCode(filter.Currency) {
+		v.add("currency", "must be an ISO 4217 code like RUB, USD or EUR")
+	}
+
+	fromOK := v.month("from", filter.From, false)
+	toOK := v.month("to", filter.To, false)
+	if fromOK && toOK {
+		v.period("from", filter.From, "to", filter.To)
+	}
+
+	return v.err()
+}
+
+// ValidateChargeFilter checks the months charges are listed for, at most
+// maxChargeMonths of them.
+func ValidateChargeFilter(filter *structures.ChargeFilter) error {
+	var v validator
+
+	fromOK := v.month("from", filter.From, true)
+	toOK := v.month("to", filter.To, true)
+	if fromOK && toOK {
+		v.period("from", filter.From, "to", filter.To)
+
+		from, _ := structures.ParseMonth(filter.From)
+		to, _ := structures.ParseMonth(filter.To)
+		if to.After(from.AddDate(0, maxChargeMonths-1, 0)) {
+			v.add("to", "must be at most 120 months after from")
+		}
+	}
+
+	return v.err()
+}
+
+func ValidateRenewalFilter(filter *structures.RenewalFilter) error {
+	var v validator
+
+	if _, err := parseWithin(filter.Within); err != nil {
+		v.add("within", "must be a number of days or weeks like 30d or 2w, at most 366d")
+	}
+
+	v.uuid("user_id", filter.UserID, false)
+
+	if filter.Limit < 0 || filter.Limit > maxPageLimit {
+		v.add("limit", "must be between 0 and 500")
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := structures.DecodeCursor(filter.Cursor)
+		if err == nil {
+			_, err = time.Parse(structures.DateLayout, cursor.Value)
+		}
+		switch {
+		case err != nil:
+			v.add("cursor", "is malformed")
+		case cursor.Sort != structures.RenewalCursorSort:
+			v.add("cursor", "was issued for a different listing")
+		}
+	}
+
+	return v.err()
+}
+
+// ValidateForecastFilter checks the number of months, the other filters are
+// checked by ValidateCounting like for /summ.
+func ValidateForecastFilter(filter *structures.ForecastFilter) error {
+	var v validator
+
+	if filter.Months < 1 || filter.Months > maxChargeMonths {
+		v.add("months", "must be from 1 to 120")
+	}
+
+	return v.err()
+}
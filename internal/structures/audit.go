@@ -0,0 +1,70 @@
+package structures
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// SystemActor is recorded for changes made outside of a request, e.g. by the purge job.
+const SystemActor = "system"
+
+// AuditEntry is one change of a subscription. Before is empty for created
+// subscriptions, After for purged ones.
+type AuditEntry struct {
+	ID             int           `json:"id"`
+	SubscriptionID int           `json:"subscription_id"`
+	Action         string        `json:"action"`
+	Actor          string        `json:"actor"`
+	RequestID      string        `json:"request_id,omitempty"`
+	Before         *Subscription `json:"before,omitempty"`
+	After          *Subscription `json:"after,omitempty"`
+	ChangedAt      time.Time     `json:"changed_at"`
+}
+
+// AuditFilter selects audit entries, From and To are RFC 3339 timestamps
+// bounding ChangedAt inclusively.
+type AuditFilter struct {
+	Limit          int    `query:"limit"`
+	Offset         int    `query:"offset"`
+	Actor          string `query:"actor"`
+	Action         string `query:"action"`
+	SubscriptionID int    `query:"subscription_id"`
+	From           string `query:"from"`
+	To             string `query:"to"`
+}
+
+// AuditPage is a page of audit entries, newest first.
+type AuditPage struct {
+	Entries []AuditEntry `json:"entries"`
+	Total   int          `json:"total"`
+}
+
+// RequestInfo identifies who made a change and within which request.
+type RequestInfo struct {
+	Actor     string
+	RequestID string
+}
+
+type requestInfoKey struct{}
+
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the RequestInfo stored in ctx, the actor defaults to SystemActor.
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	if info.Actor == "" {
+		info.Actor = SystemActor
+	}
+
+	return info
+}
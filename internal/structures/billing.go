@@ -0,0 +1,249 @@
+package structures
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Billing periods. Price is charged once per period, a custom period lasts
+// BillingMonths months.
+const (
+	BillingWeekly    = "weekly"
+	BillingMonthly   = "monthly"
+	BillingQuarterly = "quarterly"
+	BillingYearly    = "yearly"
+	BillingCustom    = "custom"
+)
+
+// Counting modes. Amortized spreads every charge evenly over the months of
+// its period, charges puts it in the month it is charged in.
+const (
+	CountAmortized = "amortized"
+	CountCharges   = "charges"
+)
+
+// PeriodMonths returns the length of the billing period in months, 0 for
+// weekly billing and for unknown periods.
+func (s *Subscription) PeriodMonths() int {
+	switch s.BillingPeriod {
+	case BillingMonthly, "":
+		return 1
+	case BillingQuarterly:
+		return 3
+	case BillingYearly:
+		return 12
+	case BillingCustom:
+		return s.BillingMonths
+	}
+
+	return 0
+}
+
+// ChargeDatesIn returns the days of month the subscription is charged on.
+// Every trial month with a TrialPrice is charged on its first day. Billing
+// periods start on the first day of the month after the trial, StartDate if
+// there is none, and repeat while the subscription is active. Paused months
+// are not charged.
+func (s *Subscription) ChargeDatesIn(month time.Time) []time.Time {
+	if !s.IsBilledIn(month) {
+		return nil
+	}
+
+	if s.IsTrialIn(month) {
+		return []time.Time{month}
+	}
+
+	start := s.billingStart()
+
+	if s.BillingPeriod == BillingWeekly {
+		days := int(month.Sub(start).Hours() / 24)
+		first := month.AddDate(0, 0, (7-days%7)%7)
+
+		var dates []time.Time
+		for day := first; day.Month() == month.Month(); day = day.AddDate(0, 0, 7) {
+			dates = append(dates, day)
+		}
+		return dates
+	}
+
+	period := s.PeriodMonths()
+	if period <= 0 {
+		return nil
+	}
+
+	elapsed := (month.Year()-start.Year())*12 + int(month.Month()-start.Month())
+	if elapsed%period != 0 {
+		return nil
+	}
+
+	return []time.Time{month}
+}
+
+// billingStart returns the first month billed at Price.
+func (s *Subscription) billingStart() time.Time {
+	if s.TrialEndDate != "" {
+		if trialEnd, err := ParseMonth(s.TrialEndDate); err == nil {
+			return trialEnd.AddDate(0, 1, 0)
+		}
+	}
+
+	start, _ := ParseMonth(s.StartDate)
+	return start
+}
+
+// PeriodEnd returns the last month of the billing period month is in. That
+// is month itself for trial months, weekly and monthly billing and months
+// before billing starts.
+func (s *Subscription) PeriodEnd(month time.Time) time.Time {
+	start := s.billingStart()
+	period := s.PeriodMonths()
+
+	if s.IsTrialIn(month) || s.BillingPeriod == BillingWeekly || period <= 1 || month.Before(start) {
+		return month
+	}
+
+	elapsed := (month.Year()-start.Year())*12 + int(month.Month()-start.Month())
+	return start.AddDate(0, elapsed-elapsed%period+period-1, 0)
+}
+
+// chargeIn returns the amount of a single charge in month.
+func (s *Subscription) chargeIn(month time.Time) Money {
+	if s.IsTrialIn(month) {
+		return s.TrialPrice
+	}
+	return s.PriceIn(month)
+}
+
+// AmountIn returns what the subscription costs in month when counted in
+// mode. Trial months cost TrialPrice and paused months nothing. Amortized
+// amounts are prorated to minor units half away from zero: a period of N
+// months costs price/N a month, a weekly price costs price*days/7 in a
+// month of that many days.
+func (s *Subscription) AmountIn(month time.Time, mode string) Money {
+	if !s.IsBilledIn(month) {
+		return 0
+	}
+
+	price := s.chargeIn(month)
+
+	if mode == CountCharges {
+		return price * Money(len(s.ChargeDatesIn(month)))
+	}
+
+	if s.IsTrialIn(month) {
+		return price
+	}
+
+	if s.BillingPeriod == BillingWeekly {
+		days := month.AddDate(0, 1, 0).Sub(month).Hours() / 24
+		return price.Prorate(int64(days), 7)
+	}
+
+	period := s.PeriodMonths()
+	if period <= 0 {
+		return 0
+	}
+
+	return price.Prorate(1, int64(period))
+}
+
+// DateLayout is the YYYY-MM-DD format of charge dates.
+const DateLayout = "2006-01-02"
+
+// Charge is a payment of a subscription on Date.
+type Charge struct {
+	Date     string `json:"date" example:"2025-07-01"`
+	Amount   Money  `json:"amount" swaggertype:"string" example:"299.99"`
+	Currency string `json:"currency"`
+}
+
+// Renewal is an upcoming charge of a subscription.
+type Renewal struct {
+	SubscriptionID int    `json:"subscription_id"`
+	ServiceName    string `json:"service_name"`
+	UserID         string `json:"user_id"`
+	Charge
+}
+
+// chargeJSON writes the amount of a charge in its currency.
+type chargeJSON struct {
+	Date     string  `json:"date"`
+	Amount   Decimal `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+func (c Charge) toJSON() chargeJSON {
+	return chargeJSON{Date: c.Date, Amount: c.Amount.Decimal(c.Currency), Currency: c.Currency}
+}
+
+func (c Charge) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.toJSON())
+}
+
+// MarshalJSON is spelled out, the method promoted from Charge would write
+// the charge only.
+func (r Renewal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		SubscriptionID int    `json:"subscription_id"`
+		ServiceName    string `json:"service_name"`
+		UserID         string `json:"user_id"`
+		chargeJSON
+	}{r.SubscriptionID, r.ServiceName, r.UserID, r.Charge.toJSON()})
+}
+
+// ChargeFilter limits the charges of a subscription to the months From to To
+// (MM-YYYY) inclusive.
+type ChargeFilter struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+// RenewalFilter selects the renewals within a time span like 30d or 2w from
+// today, of one user if UserID is set. Limit and Cursor page them like
+// SubscriptionFilter.
+type RenewalFilter struct {
+	Within string `query:"within"`
+	UserID string `query:"user_id"`
+	Limit  int    `query:"limit"`
+	Cursor string `query:"cursor"`
+}
+
+// RenewalCursorSort is the Sort of the PageCursor of renewals, they are
+// ordered by date and subscription ID.
+const RenewalCursorSort = "date"
+
+// RenewalQuery selects at most Limit renewals dated From to Until, YYYY-MM-DD
+// inclusive, ordered by date and subscription ID. The ones up to AfterDate
+// and AfterID are skipped if AfterDate is set.
+type RenewalQuery struct {
+	From      string
+	Until     string
+	UserID    string
+	AfterDate string
+	AfterID   int
+	Limit     int
+}
+
+type RenewalPage struct {
+	Renewals   []Renewal `json:"renewals"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// Charges returns the charges of the months from to to inclusive, at the
+// price in effect in the month of each charge.
+func (s *Subscription) Charges(from, to time.Time) []Charge {
+	charges := []Charge{}
+
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		price := s.chargeIn(month)
+		for _, date := range s.ChargeDatesIn(month) {
+			charges = append(charges, Charge{
+				Date:     date.Format(DateLayout),
+				Amount:   price,
+				Currency: s.Currency,
+			})
+		}
+	}
+
+	return charges
+}
@@ -0,0 +1,91 @@
+package structures
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func mustMonth(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	month, err := ParseMonth(value)
+	if err != nil {
+		t.Fatalf("ParseMonth(%q): %v", value, err)
+	}
+
+	return month
+}
+
+func TestBilling(t *testing.T) {
+	weekly := Subscription{Price: 1000, BillingPeriod: BillingWeekly, StartDate: "01-2025"}
+	yearlyAfterTrial := Subscription{
+		Price:         12000,
+		BillingPeriod: BillingYearly,
+		StartDate:     "01-2025",
+		TrialEndDate:  "03-2025",
+		TrialPrice:    100,
+	}
+	freeTrial := Subscription{Price: 900, BillingPeriod: BillingQuarterly, StartDate: "01-2025", TrialEndDate: "02-2025"}
+	custom := Subscription{Price: 1000, BillingPeriod: BillingCustom, BillingMonths: 5, StartDate: "11-2024"}
+	paused := Subscription{Price: 500, StartDate: "01-2025", Pauses: []Pause{{From: "03-2025", To: "04-2025"}}}
+
+	tests := []struct {
+		name         string
+		subscription Subscription
+		month        string
+		dates        []string
+		periodEnd    string
+		amortized    Money
+		charges      Money
+	}{
+		// 1 January 2025 is a Wednesday, every charge is a Wednesday a week after the last.
+		{"weekly from the start day", weekly, "01-2025", []string{"2025-01-01", "2025-01-08", "2025-01-15", "2025-01-22", "2025-01-29"}, "01-2025", 4429, 5000},
+		{"weekly across a month boundary", weekly, "02-2025", []string{"2025-02-05", "2025-02-12", "2025-02-19", "2025-02-26"}, "02-2025", 4000, 4000},
+		{"weekly a year on", weekly, "01-2026", []string{"2026-01-07", "2026-01-14", "2026-01-21", "2026-01-28"}, "01-2026", 4429, 4000},
+		{"weekly before the start", weekly, "12-2024", nil, "12-2024", 0, 0},
+
+		{"trial month", yearlyAfterTrial, "01-2025", []string{"2025-01-01"}, "01-2025", 100, 100},
+		{"last trial month", yearlyAfterTrial, "03-2025", []string{"2025-03-01"}, "03-2025", 100, 100},
+		{"yearly period starts after the trial", yearlyAfterTrial, "04-2025", []string{"2025-04-01"}, "03-2026", 1000, 12000},
+		{"inside the yearly period", yearlyAfterTrial, "12-2025", nil, "03-2026", 1000, 0},
+		{"second yearly period", yearlyAfterTrial, "04-2026", []string{"2026-04-01"}, "03-2027", 1000, 12000},
+
+		{"free trial is not billed", freeTrial, "02-2025", nil, "02-2025", 0, 0},
+		{"quarter after a free trial", freeTrial, "03-2025", []string{"2025-03-01"}, "05-2025", 300, 900},
+		{"inside the quarter", freeTrial, "05-2025", nil, "05-2025", 300, 0},
+
+		{"custom period start", custom, "11-2024", []string{"2024-11-01"}, "03-2025", 200, 1000},
+		{"inside the custom period", custom, "02-2025", nil, "03-2025", 200, 0},
+		{"next custom period", custom, "04-2025", []string{"2025-04-01"}, "08-2025", 200, 1000},
+
+		{"paused month", paused, "04-2025", nil, "04-2025", 0, 0},
+		{"month after the pause", paused, "05-2025", []string{"2025-05-01"}, "05-2025", 500, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			month := mustMonth(t, tt.month)
+
+			var dates []string
+			for _, date := range tt.subscription.ChargeDatesIn(month) {
+				dates = append(dates, date.Format(DateLayout))
+			}
+			if !slices.Equal(dates, tt.dates) {
+				t.Errorf("ChargeDatesIn = %v, want %v", dates, tt.dates)
+			}
+
+			if end := FormatMonth(tt.subscription.PeriodEnd(month)); end != tt.periodEnd {
+				t.Errorf("PeriodEnd = %s, want %s", end, tt.periodEnd)
+			}
+
+			if amount := tt.subscription.AmountIn(month, CountAmortized); amount != tt.amortized {
+				t.Errorf("AmountIn amortized = %d, want %d", amount, tt.amortized)
+			}
+
+			if amount := tt.subscription.AmountIn(month, CountCharges); amount != tt.charges {
+				t.Errorf("AmountIn charges = %d, want %d", amount, tt.charges)
+			}
+		})
+	}
+}
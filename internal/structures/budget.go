@@ -0,0 +1,160 @@
+package structures
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Budget caps what a user spends a month on every subscription, on the
+// subscriptions of a Category or on those of a catalog service. Spend is
+// the share of the user counted like /summ and converted to Currency.
+type Budget struct {
+	ID        int    `json:"id"`
+	UserID    string `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Category  string `json:"category,omitempty" example:"entertainment"`
+	ServiceID int    `json:"service_id,omitempty"`
+	Amount    Money  `json:"amount" swaggertype:"string" example:"1500.00"`
+	Currency  string `json:"currency"`
+}
+
+// budgetJSON writes the amount of a budget in its currency.
+type budgetJSON struct {
+	budgetFields
+	Amount Decimal `json:"amount"`
+}
+
+type budgetFields Budget
+
+func (b Budget) MarshalJSON() ([]byte, error) {
+	return json.Marshal(budgetJSON{budgetFields(b), b.Amount.Decimal(b.Currency)})
+}
+
+// UnmarshalJSON reads amount in currency, DefaultCurrency if empty.
+func (b *Budget) UnmarshalJSON(data []byte) error {
+	var fields budgetJSON
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	amounts := newAmountParser(fields.Currency)
+	*b = Budget(fields.budgetFields)
+	b.Amount = amounts.parse("amount", fields.Amount)
+
+	return amounts.err()
+}
+
+// Budget scopes, a budget has at most one of Category and ServiceID.
+const (
+	BudgetGlobal   = "global"
+	BudgetCategory = "category"
+	BudgetService  = "service"
+)
+
+func (b *Budget) Scope() string {
+	switch {
+	case b.ServiceID != 0:
+		return BudgetService
+	case b.Category != "":
+		return BudgetCategory
+	}
+
+	return BudgetGlobal
+}
+
+// Covers reports whether the spend on subscription counts against b, it
+// does not check that the user of b pays for it.
+func (b *Budget) Covers(subscription *Subscription) bool {
+	switch b.Scope() {
+	case BudgetService:
+		return subscription.ServiceID == b.ServiceID
+	case BudgetCategory:
+		return subscription.Category == b.Category
+	}
+
+	return true
+}
+
+type BudgetFilter struct {
+	UserID string `query:"user_id"`
+}
+
+// BudgetReportFilter selects the budgets of UserID, all if empty, and the
+// months From to To (MM-YYYY) they are compared in, the current month by
+// default. Mode is CountAmortized or CountCharges.
+type BudgetReportFilter struct {
+	UserID string `query:"user_id"`
+	From   string `query:"from"`
+	To     string `query:"to"`
+	Mode   string `query:"mode"`
+}
+
+// BudgetMonth is the spend against a budget in Month, Remaining is
+// negative once it is over.
+type BudgetMonth struct {
+	Month     string `json:"month"`
+	Spent     Money  `json:"spent" swaggertype:"string" example:"1200.00"`
+	Remaining Money  `json:"remaining" swaggertype:"string" example:"300.00"`
+	Over      bool   `json:"over"`
+}
+
+// BudgetUsage compares the spend of every month with Budget, Rates lists
+// the rates used to convert it to the currency of the budget.
+type BudgetUsage struct {
+	Budget Budget        `json:"budget"`
+	Months []BudgetMonth `json:"months"`
+	Rates  []AppliedRate `json:"rates"`
+}
+
+type BudgetReport struct {
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Budgets  []BudgetUsage `json:"budgets"`
+	Rounding Rounding      `json:"rounding"`
+	Mode     string        `json:"mode"`
+}
+
+// BudgetAlert reports a month a change of a subscription pushed over a
+// budget: Spent is the spend after the change, Previous before it.
+type BudgetAlert struct {
+	Budget         Budget    `json:"budget"`
+	Month          string    `json:"month"`
+	Spent          Money     `json:"spent" swaggertype:"string" example:"1650.00"`
+	Previous       Money     `json:"previous" swaggertype:"string" example:"1200.00"`
+	SubscriptionID int       `json:"subscription_id"`
+	Actor          string    `json:"actor"`
+	RequestID      string    `json:"request_id,omitempty"`
+	RaisedAt       time.Time `json:"raised_at"`
+}
+
+func (u BudgetUsage) MarshalJSON() ([]byte, error) {
+	type monthJSON struct {
+		BudgetMonth
+		Spent     Decimal `json:"spent"`
+		Remaining Decimal `json:"remaining"`
+	}
+
+	type usageFields BudgetUsage
+	data := struct {
+		usageFields
+		Months []monthJSON `json:"months"`
+	}{usageFields: usageFields(u), Months: []monthJSON{}}
+
+	for _, month := range u.Months {
+		data.Months = append(data.Months, monthJSON{
+			BudgetMonth: month,
+			Spent:       month.Spent.Decimal(u.Budget.Currency),
+			Remaining:   month.Remaining.Decimal(u.Budget.Currency),
+		})
+	}
+
+	return json.Marshal(data)
+}
+
+func (a BudgetAlert) MarshalJSON() ([]byte, error) {
+	type alertFields BudgetAlert
+	return json.Marshal(struct {
+		alertFields
+		Spent    Decimal `json:"spent"`
+		Previous Decimal `json:"previous"`
+	}{alertFields(a), a.Spent.Decimal(a.Budget.Currency), a.Previous.Decimal(a.Budget.Currency)})
+}
@@ -0,0 +1,161 @@
+package structures
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// Service is an entry of the service catalog. Subscriptions with ServiceID
+// carry its Name as their service_name, whatever name they were created with.
+type Service struct {
+	ID   int    `json:"id"`
+	Name string `json:"name" example:"Yandex Plus"`
+
+	// Aliases are other names of the service, matched like Name by ServiceKey.
+	Aliases []string `json:"aliases" example:"Яндекс Плюс"`
+
+	Category     string `json:"category,omitempty" example:"video"`
+	Website      string `json:"website,omitempty" example:"https://plus.yandex.ru"`
+	DefaultPrice Money  `json:"default_price" swaggertype:"string" example:"399.00"`
+	Currency     string `json:"currency"`
+}
+
+// serviceJSON writes the default price of a service in its currency.
+type serviceJSON struct {
+	serviceFields
+	DefaultPrice Decimal `json:"default_price"`
+}
+
+type serviceFields Service
+
+func (s Service) MarshalJSON() ([]byte, error) {
+	return json.Marshal(serviceJSON{serviceFields(s), s.DefaultPrice.Decimal(s.Currency)})
+}
+
+// UnmarshalJSON reads default_price in currency, DefaultCurrency if empty.
+func (s *Service) UnmarshalJSON(data []byte) error {
+	var fields serviceJSON
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	amounts := newAmountParser(fields.Currency)
+	*s = Service(fields.serviceFields)
+	s.DefaultPrice = amounts.parse("default_price", fields.DefaultPrice)
+
+	return amounts.err()
+}
+
+// Keys returns the ServiceKey of Name and of every alias.
+func (s *Service) Keys() []string {
+	keys := []string{ServiceKey(s.Name)}
+	for _, alias := range s.Aliases {
+		keys = append(keys, ServiceKey(alias))
+	}
+
+	return keys
+}
+
+// ServiceKey is the form service names are compared in: lower case, ё as е
+// and single spaces between words.
+func ServiceKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.Join(strings.Fields(name), " ")), "ё", "е")
+}
+
+// ServiceMatch is a catalog entry found by a fuzzy lookup, Score is from 0
+// to 1, 1 meaning the name or an alias is the same as the query.
+type ServiceMatch struct {
+	Service Service `json:"service"`
+	Score   float64 `json:"score"`
+}
+
+type ServiceLookup struct {
+	Query string `query:"q"`
+	Limit int    `query:"limit"`
+}
+
+// UnmatchedName is a service_name not found in the catalog together with
+// the number of subscriptions using it and the closest catalog entry.
+type UnmatchedName struct {
+	ServiceName   string        `json:"service_name"`
+	Subscriptions int           `json:"subscriptions"`
+	Suggestion    *ServiceMatch `json:"suggestion,omitempty"`
+}
+
+// ServiceMatchReport is the result of matching subscription names to the
+// catalog, Matched counts the subscriptions given a ServiceID.
+type ServiceMatchReport struct {
+	Matched   int             `json:"matched"`
+	Unmatched []UnmatchedName `json:"unmatched"`
+}
+
+// NameSimilarity scores how alike two service names are from 0 to 1. Both
+// are compared as ServiceKey with Cyrillic transliterated, a name starting
+// with the other scores at least in proportion to their lengths.
+func NameSimilarity(a, b string) float64 {
+	a, b = transliterate(ServiceKey(a)), transliterate(ServiceKey(b))
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	score := 1 - float64(levenshtein(a, b))/float64(longest)
+
+	if strings.HasPrefix(a, b) || strings.HasPrefix(b, a) {
+		shortest := min(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+		score = max(score, 0.5+0.5*float64(shortest)/float64(longest))
+	}
+
+	return score
+}
+
+// translit spells Russian letters in Latin the way service names usually are.
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+	'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f",
+	'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y",
+	'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if latin, ok := translit[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// levenshtein returns the edit distance between a and b in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
@@ -0,0 +1,170 @@
+package structures
+
+import (
+	"math/big"
+	"sort"
+	"time"
+)
+
+type currencyPair struct {
+	from, to string
+}
+
+type appliedKey struct {
+	month time.Time
+	from  string
+}
+
+// appliedRate is a rate in use with the exact factor amounts are multiplied by.
+type appliedRate struct {
+	AppliedRate
+	factor *big.Rat
+}
+
+// Converter converts amounts to target with the rate in effect in each month:
+// the latest rate of the pair stored for that month or before. A rate of the
+// inverse pair is used when it is more recent. Converted amounts are rounded
+// to minor units as SpendRounding describes.
+type Converter struct {
+	target  string
+	rates   map[currencyPair][]ExchangeRate
+	applied map[appliedKey]appliedRate
+	missing map[string]time.Time
+}
+
+// NewConverter returns a Converter to target using rates.
+func NewConverter(target string, rates []ExchangeRate) *Converter {
+	c := &Converter{
+		target:  target,
+		rates:   make(map[currencyPair][]ExchangeRate),
+		applied: make(map[appliedKey]appliedRate),
+		missing: make(map[string]time.Time),
+	}
+
+	for _, rate := range rates {
+		pair := currencyPair{from: rate.From, to: rate.To}
+		c.rates[pair] = append(c.rates[pair], rate)
+	}
+
+	for _, pairRates := range c.rates {
+		sort.Slice(pairRates, func(i, j int) bool {
+			a, _ := ParseMonth(pairRates[i].Month)
+			b, _ := ParseMonth(pairRates[j].Month)
+			return a.Before(b)
+		})
+	}
+
+	return c
+}
+
+// Convert returns amount in the target currency. Amounts without a rate
+// convert to 0 and are reported by MissingRates.
+func (c *Converter) Convert(amount Money, currency string, month time.Time) Money {
+	if currency == c.target {
+		return amount
+	}
+
+	key := appliedKey{month: month, from: currency}
+	applied, ok := c.applied[key]
+	if !ok {
+		applied, ok = c.rateIn(currency, month)
+		if !ok {
+			if first, seen := c.missing[currency]; !seen || month.Before(first) {
+				c.missing[currency] = month
+			}
+			return 0
+		}
+		c.applied[key] = applied
+	}
+
+	return amount.Convert(applied.factor, currency, c.target)
+}
+
+func (c *Converter) rateIn(currency string, month time.Time) (appliedRate, bool) {
+	direct, directMonth, hasDirect := latestRate(c.rates[currencyPair{from: currency, to: c.target}], month)
+	inverse, inverseMonth, hasInverse := latestRate(c.rates[currencyPair{from: c.target, to: currency}], month)
+
+	applied := appliedRate{AppliedRate: AppliedRate{
+		Month: FormatMonth(month),
+		From:  currency,
+		To:    c.target,
+	}}
+
+	var stored ExchangeRate
+	switch {
+	case hasDirect && (!hasInverse || !inverseMonth.After(directMonth)):
+		stored = direct
+	case hasInverse:
+		stored = inverse
+		applied.Inverted = true
+	default:
+		return applied, false
+	}
+
+	factor, ok := stored.Rate.Rat()
+	if !ok || factor.Sign() <= 0 {
+		return applied, false
+	}
+	if applied.Inverted {
+		factor.Inv(factor)
+	}
+
+	applied.Rate = stored.Rate
+	applied.RateMonth = stored.Month
+	applied.factor = factor
+
+	return applied, true
+}
+
+// latestRate returns the last of rates, sorted by month, in effect in month.
+func latestRate(rates []ExchangeRate, month time.Time) (ExchangeRate, time.Time, bool) {
+	var (
+		latest      ExchangeRate
+		latestMonth time.Time
+		found       bool
+	)
+
+	for _, rate := range rates {
+		rateMonth, err := ParseMonth(rate.Month)
+		if err != nil || rateMonth.After(month) {
+			break
+		}
+		latest, latestMonth, found = rate, rateMonth, true
+	}
+
+	return latest, latestMonth, found
+}
+
+// MissingRates returns the currencies amounts could not be converted from
+// with the first month each has no rate in, ordered by currency.
+func (c *Converter) MissingRates() []MissingRate {
+	missing := []MissingRate{}
+	for currency, month := range c.missing {
+		missing = append(missing, MissingRate{Currency: currency, Month: FormatMonth(month)})
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Currency < missing[j].Currency
+	})
+
+	return missing
+}
+
+// AppliedRates returns the rates used so far ordered by month and currency.
+func (c *Converter) AppliedRates() []AppliedRate {
+	rates := make([]AppliedRate, 0, len(c.applied))
+	for _, rate := range c.applied {
+		rates = append(rates, rate.AppliedRate)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		a, _ := ParseMonth(rates[i].Month)
+		b, _ := ParseMonth(rates[j].Month)
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return rates[i].From < rates[j].From
+	})
+
+	return rates
+}
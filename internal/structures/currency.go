@@ -0,0 +1,147 @@
+package structures
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"strings"
+)
+
+// ExchangeRate is the price of one unit of From in To, valid from Month
+// (MM-YYYY) until the next rate of the pair.
+type ExchangeRate struct {
+	Month string `json:"month"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Rate  Rate   `json:"rate" swaggertype:"number" example:"92.5"`
+}
+
+// ExchangeRateFilter selects exchange rates, From and To are MM-YYYY months
+// bounding Month inclusively. Currency matches either side of the pair.
+type ExchangeRateFilter struct {
+	Currency string `query:"currency"`
+	From     string `query:"from"`
+	To       string `query:"to"`
+}
+
+// AppliedRate reports the rate a sum used to convert From to To in Month.
+// Rate is the rate stored for RateMonth. Inverted is set when it is the rate
+// of the To/From pair, amounts were then divided by it exactly.
+type AppliedRate struct {
+	Month     string `json:"month"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Rate      Rate   `json:"rate" swaggertype:"number" example:"92.5"`
+	RateMonth string `json:"rate_month"`
+	Inverted  bool   `json:"inverted,omitempty"`
+}
+
+// Rate is an exchange rate as an exact decimal like "92.5" or "0.0108". In
+// JSON it is a number, or a string holding one, and keeps all its digits.
+type Rate string
+
+// Rates are stored as numeric(20, 10).
+const (
+	maxRateScale  = 10
+	maxRateDigits = 20
+)
+
+// ParseRate reads an exact decimal rate and writes it without trailing zeros.
+func ParseRate(value string) (Rate, error) {
+	value = strings.TrimSpace(value)
+
+	// big.Rat also reads fractions and hexadecimal numbers.
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok || strings.ContainsAny(value, "/xX") {
+		return "", errors.New("must be a decimal number")
+	}
+
+	scaled := new(big.Rat).Mul(rat, new(big.Rat).SetInt64(pow10(maxRateScale)))
+	if !scaled.IsInt() {
+		return "", fmt.Errorf("must have at most %d fractional digits", maxRateScale)
+	}
+	if len(new(big.Int).Abs(scaled.Num()).String()) > maxRateDigits {
+		return "", fmt.Errorf("must have at most %d integer digits", maxRateDigits-maxRateScale)
+	}
+
+	text := strings.TrimRight(rat.FloatString(maxRateScale), "0")
+	return Rate(strings.TrimSuffix(text, ".")), nil
+}
+
+// Rat returns r as an exact fraction, false if r is not a number.
+func (r Rate) Rat() (*big.Rat, bool) {
+	return new(big.Rat).SetString(string(r))
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	normalized, err := ParseRate(string(r))
+	if err != nil {
+		return nil, fmt.Errorf("rate %q: %w", string(r), err)
+	}
+
+	return []byte(normalized), nil
+}
+
+// UnmarshalJSON keeps the text of the rate, ParseRate checks it.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return errors.New("rate must be a number")
+		}
+		value = number.String()
+	}
+
+	*r = Rate(value)
+	return nil
+}
+
+// IsCurrencyCode reports whether code looks like an ISO 4217 code: three
+// uppercase latin letters.
+func IsCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, letter := range code {
+		if letter < 'A' || letter > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MaxExponent is the largest CurrencyExponent.
+const MaxExponent = 4
+
+// defaultExponent is the exponent of the currencies not in currencyExponents:
+// their minor unit is a hundredth.
+const defaultExponent = 2
+
+// currencyExponents are the ISO 4217 currencies whose minor unit is not a hundredth.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent returns the number of fractional digits of code: the
+// minor unit of Money in code is 10^-CurrencyExponent(code) of a unit.
+func CurrencyExponent(code string) int {
+	if exponent, ok := currencyExponents[code]; ok {
+		return exponent
+	}
+
+	return defaultExponent
+}
+
+// CurrencyExponents returns the currencies whose exponent is not 2 with
+// their exponents, for storage that computes with amounts itself.
+func CurrencyExponents() map[string]int {
+	return maps.Clone(currencyExponents)
+}
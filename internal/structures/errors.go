@@ -0,0 +1,52 @@
+package structures
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotFound    = errors.New("resource not found")
+	ErrConflict    = errors.New("resource conflicts with the current state")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnavailable = errors.New("storage is unavailable")
+
+	ErrPreconditionFailed = errors.New("resource was modified")
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists invalid fields and matches ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func NewFieldError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Problem is an RFC 7807 problem details response.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
@@ -0,0 +1,81 @@
+package structures
+
+import "encoding/json"
+
+// ForecastFilter selects the Months coming months, the current one first,
+// of the subscriptions UserID pays for or shares, of all users if empty.
+// Amounts are converted to TargetCurrency, DefaultCurrency if empty, and
+// counted in Mode, CountAmortized or CountCharges.
+type ForecastFilter struct {
+	Months         int    `query:"months"`
+	UserID         string `query:"user_id"`
+	TargetCurrency string `query:"target_currency"`
+	Mode           string `query:"mode"`
+}
+
+// ForecastContribution is what a subscription adds to the total of a month,
+// the share of the user only with a user filter.
+type ForecastContribution struct {
+	SubscriptionID int    `json:"subscription_id"`
+	ServiceName    string `json:"service_name"`
+	UserID         string `json:"user_id"`
+	Amount         Money  `json:"amount" swaggertype:"string" example:"399.00"`
+}
+
+// ForecastMonth is the projected spend of Month, Subscriptions are ordered
+// by amount, largest first.
+type ForecastMonth struct {
+	Month         string                 `json:"month"`
+	Total         Money                  `json:"total" swaggertype:"string" example:"1499.50"`
+	Subscriptions []ForecastContribution `json:"subscriptions"`
+}
+
+// Forecast is the result of /forecast. Months are counted like /summ/monthly
+// over From to To, so the months already past line up with it.
+type Forecast struct {
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Months   []ForecastMonth `json:"months"`
+	Total    Money           `json:"total" swaggertype:"string" example:"17994.00"`
+	Currency string          `json:"currency"`
+	Rates    []AppliedRate   `json:"rates"`
+	Rounding Rounding        `json:"rounding"`
+	Mode     string          `json:"mode"`
+}
+
+func (f Forecast) MarshalJSON() ([]byte, error) {
+	type contributionJSON struct {
+		ForecastContribution
+		Amount Decimal `json:"amount"`
+	}
+
+	type monthJSON struct {
+		ForecastMonth
+		Total         Decimal            `json:"total"`
+		Subscriptions []contributionJSON `json:"subscriptions"`
+	}
+
+	type forecastFields Forecast
+	data := struct {
+		forecastFields
+		Months []monthJSON `json:"months"`
+		Total  Decimal     `json:"total"`
+	}{forecastFields: forecastFields(f), Months: []monthJSON{}, Total: f.Total.Decimal(f.Currency)}
+
+	for _, month := range f.Months {
+		written := monthJSON{
+			ForecastMonth: month,
+			Total:         month.Total.Decimal(f.Currency),
+			Subscriptions: []contributionJSON{},
+		}
+		for _, contribution := range month.Subscriptions {
+			written.Subscriptions = append(written.Subscriptions, contributionJSON{
+				ForecastContribution: contribution,
+				Amount:               contribution.Amount.Decimal(f.Currency),
+			})
+		}
+		data.Months = append(data.Months, written)
+	}
+
+	return json.Marshal(data)
+}
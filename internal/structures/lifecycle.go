@@ -0,0 +1,150 @@
+package structures
+
+import (
+	"slices"
+	"time"
+)
+
+// Subscription statuses. Trial and active subscriptions become expired after
+// EndDate, trial ones become active after TrialEndDate. Cancelled and
+// expired are final.
+const (
+	StatusTrial     = "trial"
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+// statusTransitions lists the statuses each status can change to.
+var statusTransitions = map[string][]string{
+	StatusTrial:  {StatusActive, StatusCancelled, StatusExpired},
+	StatusActive: {StatusPaused, StatusCancelled, StatusExpired},
+	StatusPaused: {StatusActive, StatusCancelled, StatusExpired},
+}
+
+// CanTransition reports whether a subscription in status from can change to status to.
+func CanTransition(from, to string) bool {
+	return slices.Contains(statusTransitions[from], to)
+}
+
+// Ways to cancel a subscription: at the end of the billing period already
+// paid for or in the current month.
+const (
+	CancelAtPeriodEnd   = "period_end"
+	CancelImmediately   = "immediately"
+	defaultCancelOption = CancelAtPeriodEnd
+)
+
+// Pause stops billing in the months From to To (MM-YYYY) inclusive. To is
+// empty while the subscription stays paused.
+type Pause struct {
+	From string `json:"from"`
+	To   string `json:"to,omitempty"`
+}
+
+// Lifecycle is the part of a subscription changed by status transitions.
+type Lifecycle struct {
+	Status      string
+	EndDate     string
+	Pauses      []Pause
+	CancelledAt *time.Time
+}
+
+// LifecycleOf returns the lifecycle part of subscription.
+func LifecycleOf(subscription *Subscription) Lifecycle {
+	return Lifecycle{
+		Status:      subscription.Status,
+		EndDate:     subscription.EndDate,
+		Pauses:      slices.Clone(subscription.Pauses),
+		CancelledAt: subscription.CancelledAt,
+	}
+}
+
+// PauseRequest is the body of pause and resume, From is the first month
+// paused or billed again, the current month if empty.
+type PauseRequest struct {
+	From string `json:"from,omitempty"`
+}
+
+// CancelRequest is the body of cancel, At is CancelAtPeriodEnd by default.
+type CancelRequest struct {
+	At string `json:"at,omitempty" enums:"period_end,immediately"`
+}
+
+// CancelOption returns At or its default.
+func (r *CancelRequest) CancelOption() string {
+	if r.At == "" {
+		return defaultCancelOption
+	}
+	return r.At
+}
+
+// StatusIn returns the status the subscription has in month once the
+// transitions that depend only on time took place. A new subscription
+// without a status starts on its trial if it has one.
+func (s *Subscription) StatusIn(month time.Time) string {
+	status := s.Status
+	switch status {
+	case StatusCancelled, StatusExpired:
+		return status
+	case "":
+		status = StatusActive
+		if s.TrialEndDate != "" {
+			status = StatusTrial
+		}
+	}
+
+	if s.EndDate != "" {
+		if end, err := ParseMonth(s.EndDate); err == nil && end.Before(month) {
+			return StatusExpired
+		}
+	}
+
+	if status == StatusTrial && !s.IsTrialIn(month) {
+		return StatusActive
+	}
+
+	return status
+}
+
+// IsTrialIn reports whether month is a trial month, from StartDate to
+// TrialEndDate inclusive.
+func (s *Subscription) IsTrialIn(month time.Time) bool {
+	if s.TrialEndDate == "" {
+		return false
+	}
+
+	trialEnd, err := ParseMonth(s.TrialEndDate)
+	return err == nil && !month.After(trialEnd)
+}
+
+// IsPausedIn reports whether one of Pauses covers month.
+func (s *Subscription) IsPausedIn(month time.Time) bool {
+	for _, pause := range s.Pauses {
+		from, err := ParseMonth(pause.From)
+		if err != nil || month.Before(from) {
+			continue
+		}
+
+		if pause.To == "" {
+			return true
+		}
+
+		if to, err := ParseMonth(pause.To); err == nil && !month.After(to) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsBilledIn reports whether the subscription costs something in month: it
+// is active, not paused and not in a free trial.
+func (s *Subscription) IsBilledIn(month time.Time) bool {
+	if !s.IsActiveIn(month) || s.IsPausedIn(month) {
+		return false
+	}
+
+	return !s.IsTrialIn(month) || s.TrialPrice > 0
+}
@@ -0,0 +1,29 @@
+package structures
+
+import "testing"
+
+func TestStatusIn(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription Subscription
+		month        string
+		want         string
+	}{
+		{"new", Subscription{StartDate: "01-2025"}, "03-2025", StatusActive},
+		{"new on trial", Subscription{StartDate: "01-2025", TrialEndDate: "03-2025"}, "03-2025", StatusTrial},
+		{"new after the trial", Subscription{StartDate: "01-2025", TrialEndDate: "02-2025"}, "03-2025", StatusActive},
+		{"new after the end", Subscription{StartDate: "01-2025", EndDate: "02-2025"}, "03-2025", StatusExpired},
+		{"trial ends", Subscription{Status: StatusTrial, StartDate: "01-2025", TrialEndDate: "02-2025"}, "03-2025", StatusActive},
+		{"paused", Subscription{Status: StatusPaused, StartDate: "01-2025"}, "03-2025", StatusPaused},
+		{"paused past the end", Subscription{Status: StatusPaused, StartDate: "01-2025", EndDate: "02-2025"}, "03-2025", StatusExpired},
+		{"cancelled", Subscription{Status: StatusCancelled, StartDate: "01-2025", TrialEndDate: "03-2025"}, "03-2025", StatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.subscription.StatusIn(mustMonth(t, tt.month)); got != tt.want {
+				t.Fatalf("StatusIn(%s) = %q, want %q", tt.month, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package structures
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+const (
+	SortByID          = "id"
+	SortByPrice       = "price"
+	SortByStartDate   = "start_date"
+	SortByServiceName = "service_name"
+)
+
+type SubscriptionFilter struct {
+	Limit       int    `query:"limit"`
+	Offset      int    `query:"offset"`
+	Cursor      string `query:"cursor"`
+	Sort        string `query:"sort"`
+	UserID      string `query:"user_id"`
+	ServiceName string `query:"service_name"`
+	ServiceID   int    `query:"service_id"`
+	Category    string `query:"category"`
+	Tag         string `query:"tag"`
+	ActiveAt    string `query:"active_at"`
+	Status      string `query:"status"`
+
+	// MinPrice and MaxPrice bound the price as a decimal amount whatever
+	// the currency, see ScaledPrice. Empty means unbounded.
+	MinPrice Decimal `query:"min_price" swaggertype:"string"`
+	MaxPrice Decimal `query:"max_price" swaggertype:"string"`
+
+	// IncludeDeleted is open to every caller on purpose: the API has no
+	// authentication, anyone may restore a deleted subscription and the
+	// audit trail shows its snapshots anyway.
+	IncludeDeleted bool `query:"include_deleted"`
+}
+
+// PriceBound parses MinPrice or MaxPrice in the units of ScaledPrice.
+func PriceBound(bound Decimal) (Money, error) {
+	return ParseDecimal(string(bound), MaxExponent)
+}
+
+// SortField returns the field to sort by and whether the order is descending.
+// A leading "-" in Sort means descending, an empty Sort means newest first.
+func (f *SubscriptionFilter) SortField() (string, bool) {
+	if f.Sort == "" {
+		return SortByID, true
+	}
+
+	if strings.HasPrefix(f.Sort, "-") {
+		return f.Sort[1:], true
+	}
+
+	return f.Sort, false
+}
+
+type SubscriptionPage struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	Total         int            `json:"total"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+// PageCursor points at the last subscription of a page for keyset pagination.
+type PageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func EncodeCursor(cursor PageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (PageCursor, error) {
+	var cursor PageCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
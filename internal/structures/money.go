@@ -0,0 +1,264 @@
+package structures
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor units of its currency: kopecks, cents, or
+// whole yen for a currency without minor units, see CurrencyExponent.
+// Money does not know its currency, so it is not written to JSON alone:
+// the types holding amounts write them as a Decimal in their currency.
+type Money int64
+
+// Decimal is an amount as clients send and receive it: a decimal string
+// like "299.99" with at most as many fractional digits as its currency has.
+// A JSON number is read as its decimal text, so 400 and "400.00" are the
+// same amount.
+type Decimal string
+
+var errMoneyJSON = errors.New("money has no currency, write it as a Decimal")
+
+// ParseDecimal parses a decimal amount like "299.99", "-5" or "0.5" exactly
+// into units of 10^-exponent. Fractional digits past exponent must be zeros.
+func ParseDecimal(value string, exponent int) (Money, error) {
+	formatErr := fmt.Errorf("must be a decimal amount with at most %d fractional digits", exponent)
+	if exponent == 0 {
+		formatErr = errors.New("must be a whole amount")
+	}
+
+	value = strings.TrimSpace(value)
+
+	// At most one sign, the digits below reject any other.
+	negative := strings.HasPrefix(value, "-")
+	if negative || strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(value, ".")
+	if whole == "" || (hasFraction && fraction == "") {
+		return 0, formatErr
+	}
+
+	for _, digit := range whole + fraction {
+		if digit < '0' || digit > '9' {
+			return 0, formatErr
+		}
+	}
+
+	if len(fraction) > exponent {
+		if strings.Trim(fraction[exponent:], "0") != "" {
+			return 0, formatErr
+		}
+		fraction = fraction[:exponent]
+	}
+
+	unit := pow10(exponent)
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || major > math.MaxInt64/unit-1 {
+		return 0, formatErr
+	}
+
+	fraction += strings.Repeat("0", exponent-len(fraction))
+	minor, _ := strconv.ParseInt("0"+fraction, 10, 64)
+
+	amount := Money(major*unit + minor)
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+// ParseMoney parses a decimal amount in minor units of currency, see ParseDecimal.
+func ParseMoney(value, currency string) (Money, error) {
+	return ParseDecimal(value, CurrencyExponent(currency))
+}
+
+// Format writes m with the fractional digits of currency, like "299.99" or
+// "1500" for yen.
+func (m Money) Format(currency string) string {
+	sign := ""
+	amount := int64(m)
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	exponent := CurrencyExponent(currency)
+	if exponent == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+
+	unit := pow10(exponent)
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, exponent, amount%unit)
+}
+
+// Decimal returns m written in currency, see Format.
+func (m Money) Decimal(currency string) Decimal {
+	return Decimal(m.Format(currency))
+}
+
+// MarshalJSON fails: an amount is written as a Decimal by the type holding
+// it, which knows the currency.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return nil, errMoneyJSON
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	return errMoneyJSON
+}
+
+// Money parses d in minor units of currency, see ParseMoney.
+func (d Decimal) Money(currency string) (Money, error) {
+	return ParseMoney(string(d), currency)
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return errors.New("must be a decimal amount")
+		}
+		value = number.String()
+	}
+
+	*d = Decimal(value)
+	return nil
+}
+
+// amountParser parses the amounts of a request in currency and collects
+// a FieldError for every malformed one.
+type amountParser struct {
+	currency string
+	fields   []FieldError
+}
+
+// newAmountParser parses amounts in currency as the services normalize it:
+// trimmed, upper case, DefaultCurrency if empty.
+func newAmountParser(currency string) *amountParser {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	return &amountParser{currency: currency}
+}
+
+// parse returns value in minor units, 0 if it is empty.
+func (p *amountParser) parse(field string, value Decimal) Money {
+	if value == "" {
+		return 0
+	}
+
+	amount, err := value.Money(p.currency)
+	if err != nil {
+		p.fields = append(p.fields, FieldError{Field: field, Message: err.Error()})
+	}
+
+	return amount
+}
+
+func (p *amountParser) err() error {
+	if len(p.fields) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Fields: p.fields}
+}
+
+// optionalDecimal writes m in currency, empty for 0 so omitempty drops it.
+func optionalDecimal(m Money, currency string) Decimal {
+	if m == 0 {
+		return ""
+	}
+
+	return m.Decimal(currency)
+}
+
+// Rescale converts m from units of 10^-from to units of 10^-to, rounded
+// half away from zero when to is the coarser unit, like Prorate.
+func (m Money) Rescale(from, to int) Money {
+	if to >= from {
+		return m.Prorate(pow10(to-from), 1)
+	}
+
+	return m.Prorate(1, pow10(from-to))
+}
+
+// Convert converts m in minor units of from to minor units of to at rate,
+// the exact price of one unit of from in to. The product is rounded once,
+// half away from zero, and clamped to the range of Money.
+func (m Money) Convert(rate *big.Rat, from, to string) Money {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(m)), rate)
+
+	shift := CurrencyExponent(to) - CurrencyExponent(from)
+	if shift >= 0 {
+		product.Mul(product, new(big.Rat).SetInt64(pow10(shift)))
+	} else {
+		product.Quo(product, new(big.Rat).SetInt64(pow10(-shift)))
+	}
+
+	return divRound(product.Num(), product.Denom())
+}
+
+func pow10(exponent int) int64 {
+	unit := int64(1)
+	for range exponent {
+		unit *= 10
+	}
+
+	return unit
+}
+
+// Prorate returns m*num/den rounded to minor units half away from zero and
+// clamped to the range of Money.
+func (m Money) Prorate(num, den int64) Money {
+	product := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(num))
+	return divRound(product, big.NewInt(den))
+}
+
+// divRound returns num/den rounded half away from zero, the closest bound of
+// Money if it does not fit.
+func divRound(num, den *big.Int) Money {
+	// QuoRem truncates, the remainder has the sign of num.
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Lsh(remainder.Abs(remainder), 1).CmpAbs(den) >= 0 {
+		if num.Sign() != den.Sign() {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	switch {
+	case quotient.IsInt64():
+		return Money(quotient.Int64())
+	case quotient.Sign() > 0:
+		return math.MaxInt64
+	default:
+		return math.MinInt64
+	}
+}
+
+// Rounding describes how sums round amounts that are not whole minor units.
+type Rounding struct {
+	Mode  string `json:"mode"`
+	Unit  string `json:"unit"`
+	Scope string `json:"scope"`
+}
+
+// SpendRounding is the rounding of /summ: every prorated or converted amount
+// is rounded half away from zero to minor units per subscription and month,
+// and the rounded amounts are summed exactly.
+var SpendRounding = Rounding{
+	Mode:  "half_away_from_zero",
+	Unit:  "minor",
+	Scope: "subscription_month",
+}
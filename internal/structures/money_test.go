@@ -0,0 +1,167 @@
+package structures
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{"299.99", "RUB", 29999, false},
+		{"0.5", "USD", 50, false},
+		{"400", "RUB", 40000, false},
+		{" 12.30 ", "EUR", 1230, false},
+		{"-5", "RUB", -500, false},
+		{"+5", "RUB", 500, false},
+		{"1500", "JPY", 1500, false},
+		{"1500.00", "JPY", 1500, false},
+		{"1500.5", "JPY", 0, true},
+		{"4.5", "KWD", 4500, false},
+		{"4.567", "BHD", 4567, false},
+		{"4.5678", "KWD", 0, true},
+		{"1.23", "RUB", 123, false},
+		{"1.230", "RUB", 123, false},
+		{"1.234", "RUB", 0, true},
+		{"--5", "RUB", 0, true},
+		{"+-5", "RUB", 0, true},
+		{"-+5", "RUB", 0, true},
+		{"", "RUB", 0, true},
+		{"-", "RUB", 0, true},
+		{".5", "RUB", 0, true},
+		{"5.", "RUB", 0, true},
+		{"1e3", "RUB", 0, true},
+		{"1,5", "RUB", 0, true},
+		{"92233720368547758.07", "RUB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency+" "+tt.value, func(t *testing.T) {
+			got, err := ParseMoney(tt.value, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMoney(%q, %s) error = %v, want error %t", tt.value, tt.currency, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Fatalf("ParseMoney(%q, %s) = %d, want %d", tt.value, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	tests := []struct {
+		amount   Money
+		currency string
+		want     string
+	}{
+		{29999, "RUB", "299.99"},
+		{5, "USD", "0.05"},
+		{-150, "EUR", "-1.50"},
+		{1500, "JPY", "1500"},
+		{-7, "JPY", "-7"},
+		{4500, "KWD", "4.500"},
+		{1, "CLF", "0.0001"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.Format(tt.currency); got != tt.want {
+			t.Errorf("Money(%d).Format(%s) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestProrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		num, den int64
+		want     Money
+	}{
+		{"exact", 1200, 1, 12, 100},
+		{"rounds down below half", 100, 1, 3, 33},
+		{"rounds up above half", 200, 1, 3, 67},
+		{"half rounds away from zero", 5, 1, 2, 3},
+		{"negative half rounds away from zero", -5, 1, 2, -3},
+		{"negative below half", -100, 1, 3, -33},
+		{"weekly price over 31 days", 1000, 31, 7, 4429},
+		{"weekly price over 28 days", 1000, 28, 7, 4000},
+		{"zero", 0, 1, 7, 0},
+		{"negative denominator", 100, 1, -3, -33},
+		{"product beyond int64", math.MaxInt64 / 2, 31, 7, math.MaxInt64},
+		{"negative product beyond int64", math.MinInt64 / 2, 31, 7, math.MinInt64},
+		{"product beyond int64 that fits after dividing", math.MaxInt64, 2, 2, math.MaxInt64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.Prorate(tt.num, tt.den); got != tt.want {
+				t.Fatalf("Money(%d).Prorate(%d, %d) = %d, want %d", tt.amount, tt.num, tt.den, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		rate     string
+		from, to string
+		want     Money
+	}{
+		{"same exponent", 10000, "0.0108", "RUB", "USD", 108},
+		// 100 kopecks at 1.005 is 100.5 cents exactly, a float64 gives 100.4999.
+		{"exact half rounds away from zero", 100, "1.005", "RUB", "USD", 101},
+		{"to a currency without minor units", 1000, "1.5", "USD", "JPY", 15},
+		{"from a currency without minor units", 1500, "0.5", "JPY", "RUB", 75000},
+		{"to three fractional digits", 10000, "0.0033", "RUB", "KWD", 330},
+		{"inverse rate", 10000, "1/90", "RUB", "USD", 111},
+		{"negative", -100, "1.005", "RUB", "USD", -101},
+		{"beyond int64", math.MaxInt64, "2", "RUB", "USD", math.MaxInt64},
+		{"negative beyond int64", math.MinInt64, "2", "RUB", "USD", math.MinInt64},
+		{"to more minor units beyond int64", math.MaxInt64 / 10, "1", "JPY", "RUB", math.MaxInt64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, ok := new(big.Rat).SetString(tt.rate)
+			if !ok {
+				t.Fatalf("bad rate %q", tt.rate)
+			}
+			if got := tt.amount.Convert(rate, tt.from, tt.to); got != tt.want {
+				t.Fatalf("Money(%d).Convert(%s, %s, %s) = %d, want %d", tt.amount, tt.rate, tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Rate
+		wantErr bool
+	}{
+		{"92.5", "92.5", false},
+		{"92.5000000000", "92.5", false},
+		{"150", "150", false},
+		{"0.0000000001", "0.0000000001", false},
+		{"0.00000000001", "", true},
+		{"12345678901", "", true},
+		{"1/3", "", true},
+		{"0x10", "", true},
+		{"abc", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRate(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRate(%q) = %q, %v, want %q and error %t", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
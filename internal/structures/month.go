@@ -0,0 +1,14 @@
+package structures
+
+import "time"
+
+// MonthLayout is the MM-YYYY format used for every date in the API.
+const MonthLayout = "01-2006"
+
+func ParseMonth(value string) (time.Time, error) {
+	return time.Parse(MonthLayout, value)
+}
+
+func FormatMonth(month time.Time) string {
+	return month.Format(MonthLayout)
+}
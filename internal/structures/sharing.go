@@ -0,0 +1,200 @@
+package structures
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// Member shares the cost of a subscription with its owner. Amount is a
+// fixed part of every charge of the price in the currency of the
+// subscription, otherwise the member pays Weight parts, 1 if unset, of
+// what the fixed amounts leave. The owner takes 1 part unless listed.
+type Member struct {
+	UserID string `json:"user_id"`
+	Weight int    `json:"weight,omitempty"`
+	Amount Money  `json:"amount,omitempty" swaggertype:"string" example:"100.00"`
+}
+
+// MemberJSON is a Member as clients send and receive it, Amount is in the
+// currency of the subscription.
+type MemberJSON struct {
+	UserID string  `json:"user_id"`
+	Weight int     `json:"weight,omitempty"`
+	Amount Decimal `json:"amount,omitempty" swaggertype:"string" example:"100.00"`
+}
+
+// ParseMembers parses members in currency, malformed amounts are reported
+// together as a ValidationError.
+func ParseMembers(members []MemberJSON, currency string) ([]Member, error) {
+	amounts := newAmountParser(currency)
+
+	parsed := make([]Member, 0, len(members))
+	for i, member := range members {
+		parsed = append(parsed, Member{
+			UserID: member.UserID,
+			Weight: member.Weight,
+			Amount: amounts.parse(fmt.Sprintf("members[%d].amount", i), member.Amount),
+		})
+	}
+
+	return parsed, amounts.err()
+}
+
+// Share is the part of an amount paid by UserID.
+type Share struct {
+	UserID string `json:"user_id"`
+	Amount Money  `json:"amount" swaggertype:"string" example:"133.33"`
+}
+
+// Shares splits amount, what the subscription costs in month, between the
+// owner and the members, owner first. Fixed amounts take the same part of
+// amount as of the price in month, so trial and prorated months are split
+// in the same proportion; they are scaled down if they exceed the price.
+// The shares add up to amount, the minor units left by rounding go to the
+// largest remainders.
+func (s *Subscription) Shares(month time.Time, amount Money) []Share {
+	if len(s.Members) == 0 {
+		return []Share{{UserID: s.UserID, Amount: amount}}
+	}
+
+	price := int64(s.PriceIn(month))
+
+	payers := []string{s.UserID}
+	fixed := []int64{0}
+	weights := []int64{1}
+	for _, member := range s.Members {
+		i := 0
+		if member.UserID != s.UserID {
+			i = len(payers)
+			payers = append(payers, member.UserID)
+			fixed = append(fixed, 0)
+			weights = append(weights, 0)
+		}
+
+		switch {
+		case member.Amount > 0:
+			weights[i] = 0
+			if price > 0 {
+				fixed[i] = int64(member.Amount)
+			}
+		case member.Weight > 0:
+			weights[i] = int64(member.Weight)
+		default:
+			weights[i] = 1
+		}
+	}
+
+	// Without a price the fixed amounts are ignored and the weights split
+	// all of amount.
+	if price <= 0 {
+		price = 1
+	}
+
+	totalFixed := new(big.Int)
+	var totalWeight int64
+	for i := range payers {
+		totalFixed.Add(totalFixed, big.NewInt(fixed[i]))
+		totalWeight += weights[i]
+	}
+
+	// Payer i pays parts[i] / whole of amount.
+	parts := make([]*big.Int, len(payers))
+	var whole *big.Int
+	switch rest := new(big.Int).Sub(big.NewInt(price), totalFixed); {
+	case rest.Sign() < 0:
+		whole = totalFixed
+		for i := range payers {
+			parts[i] = big.NewInt(fixed[i])
+		}
+	case totalWeight == 0:
+		whole = big.NewInt(price)
+		for i := range payers {
+			parts[i] = big.NewInt(fixed[i])
+		}
+		parts[0].Add(parts[0], rest)
+	default:
+		whole = new(big.Int).Mul(big.NewInt(price), big.NewInt(totalWeight))
+		for i := range payers {
+			parts[i] = new(big.Int).Mul(big.NewInt(fixed[i]), big.NewInt(totalWeight))
+			parts[i].Add(parts[i], new(big.Int).Mul(rest, big.NewInt(weights[i])))
+		}
+	}
+
+	return splitAmount(amount, payers, parts, whole)
+}
+
+// splitAmount gives every payer parts[i] / whole of amount rounded down and
+// hands out the minor units left to the largest remainders, earlier payers
+// first. The parts add up to whole.
+func splitAmount(amount Money, payers []string, parts []*big.Int, whole *big.Int) []Share {
+	shares := make([]Share, len(payers))
+	remainders := make([]*big.Int, len(payers))
+
+	left := amount
+	for i, payer := range payers {
+		exact := new(big.Int).Mul(big.NewInt(int64(amount)), parts[i])
+		floor, remainder := exact.DivMod(exact, whole, new(big.Int))
+
+		shares[i] = Share{UserID: payer, Amount: Money(floor.Int64())}
+		remainders[i] = remainder
+		left -= shares[i].Amount
+	}
+
+	order := make([]int, len(payers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+
+	// Every share lost less than a minor unit, so fewer than len(payers) are left.
+	for _, i := range order[:left] {
+		shares[i].Amount++
+	}
+
+	return shares
+}
+
+// Debt is what From owes To for the shares of the subscriptions To pays
+// for, net of what To owes From.
+type Debt struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Amount        Money  `json:"amount" swaggertype:"string" example:"266.67"`
+	Subscriptions []int  `json:"subscriptions"`
+}
+
+// Settlement is the result of /summ/settlement: the debts between owners of
+// shared subscriptions and their members over the period.
+type Settlement struct {
+	StartDate string        `json:"start_date"`
+	EndDate   string        `json:"end_date"`
+	Debts     []Debt        `json:"debts"`
+	Currency  string        `json:"currency"`
+	Rates     []AppliedRate `json:"rates"`
+	Rounding  Rounding      `json:"rounding"`
+	Mode      string        `json:"mode"`
+}
+
+func (s Settlement) MarshalJSON() ([]byte, error) {
+	type debtJSON struct {
+		Debt
+		Amount Decimal `json:"amount"`
+	}
+
+	type settlementFields Settlement
+	data := struct {
+		settlementFields
+		Debts []debtJSON `json:"debts"`
+	}{settlementFields: settlementFields(s), Debts: []debtJSON{}}
+
+	for _, debt := range s.Debts {
+		data.Debts = append(data.Debts, debtJSON{Debt: debt, Amount: debt.Amount.Decimal(s.Currency)})
+	}
+
+	return json.Marshal(data)
+}
@@ -0,0 +1,54 @@
+package structures
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestShares(t *testing.T) {
+	const (
+		owner = "owner"
+		b     = "b"
+		c     = "c"
+	)
+
+	tests := []struct {
+		name    string
+		price   Money
+		members []Member
+		amount  Money
+		want    []Money
+	}{
+		{"no members", 1000, nil, 999, []Money{999}},
+		{"equal parts", 100, []Member{{UserID: b}, {UserID: c}}, 100, []Money{34, 33, 33}},
+		{"weights", 300, []Member{{UserID: b, Weight: 2}}, 300, []Money{100, 200}},
+		{"fixed amount and the rest by weight", 1000, []Member{{UserID: b, Amount: 300}, {UserID: c}}, 1000, []Money{350, 300, 350}},
+		{"fixed amount in a trial month", 1000, []Member{{UserID: b, Amount: 300}}, 100, []Money{70, 30}},
+		{"fixed amounts over the price are scaled down", 1000, []Member{{UserID: b, Amount: 800}, {UserID: c, Amount: 400}}, 1000, []Money{0, 667, 333}},
+		{"owner with a fixed amount takes the rest", 1000, []Member{{UserID: owner, Amount: 200}, {UserID: b, Amount: 300}}, 1000, []Money{700, 300}},
+		{"fixed amounts without a price", 0, []Member{{UserID: b, Amount: 300}, {UserID: c}}, 0, []Money{0, 0, 0}},
+		{"remainders go to earlier payers on a tie", 2, []Member{{UserID: b}, {UserID: c}}, 2, []Money{1, 1, 0}},
+		// A float64 has 53 bits, these shares need 55.
+		{"large amounts are split exactly", 1, []Member{{UserID: b}, {UserID: c}}, 30000000000000001, []Money{10000000000000001, 10000000000000000, 10000000000000000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := Subscription{UserID: owner, Price: tt.price, StartDate: "01-2025", Members: tt.members}
+
+			var got []Money
+			var total Money
+			for _, share := range subscription.Shares(mustMonth(t, "01-2025"), tt.amount) {
+				got = append(got, share.Amount)
+				total += share.Amount
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("Shares = %v, want %v", got, tt.want)
+			}
+			if total != tt.amount {
+				t.Fatalf("shares add up to %d, want %d", total, tt.amount)
+			}
+		})
+	}
+}
@@ -1,12 +1,204 @@
 package structures
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type Subscription struct {
 	ID          int    `json:"id"`
 	ServiceName string `json:"service_name"`
-	Price       int    `json:"price"`
-	UserID      string `json:"user_id"`
-	StartDate   string `json:"start_date"`
-	EndDate     string `json:"end_date, omitempty"`
+	Price       Money  `json:"price" swaggertype:"string" example:"299.99"`
+	Currency    string `json:"currency"`
+
+	// ServiceID refers to the service catalog, a name found there sets it.
+	ServiceID int `json:"service_id,omitempty"`
+
+	// Category is the category of the catalog service unless set. Tags are
+	// lower case and sorted.
+	Category string   `json:"category,omitempty" example:"entertainment"`
+	Tags     []string `json:"tags,omitempty" example:"work,cloud"`
+
+	// BillingPeriod is how often Price is charged, monthly if empty.
+	// BillingMonths is the length of a custom period.
+	BillingPeriod string `json:"billing_period" enums:"weekly,monthly,quarterly,yearly,custom"`
+	BillingMonths int    `json:"billing_months,omitempty"`
+
+	UserID    string     `json:"user_id"`
+	StartDate string     `json:"start_date"`
+	EndDate   string     `json:"end_date,omitempty"`
+	Version   int        `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// TrialEndDate is the last month of the trial, TrialPrice is charged
+	// every trial month instead of Price.
+	TrialEndDate string `json:"trial_end_date,omitempty"`
+	TrialPrice   Money  `json:"trial_price,omitempty" swaggertype:"string" example:"0.00"`
+
+	// Status, Pauses and CancelledAt change through the lifecycle
+	// endpoints only, see StatusIn.
+	Status      string     `json:"status" enums:"trial,active,paused,cancelled,expired"`
+	Pauses      []Pause    `json:"pauses,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+
+	// Prices are the scheduled price changes ordered by month, Price is
+	// charged until the first of them takes effect.
+	Prices []PriceChange `json:"prices,omitempty"`
+
+	// Members share the cost with the owner UserID, see Shares. They change
+	// through the members endpoint only.
+	Members []Member `json:"members,omitempty"`
+}
+
+// DefaultCurrency is the currency of subscriptions created without one and
+// the currency sums are reported in by default.
+const DefaultCurrency = "RUB"
+
+// IsActiveIn reports whether month lies between StartDate and EndDate inclusive.
+// Malformed dates make the subscription inactive.
+func (s *Subscription) IsActiveIn(month time.Time) bool {
+	start, err := ParseMonth(s.StartDate)
+	if err != nil || month.Before(start) {
+		return false
+	}
+
+	if s.EndDate == "" {
+		return true
+	}
+
+	end, err := ParseMonth(s.EndDate)
+	return err == nil && !month.After(end)
+}
+
+// ScaledPrice is Price in units of 10^-MaxExponent, so prices in currencies
+// with different exponents compare as decimal amounts.
+func (s *Subscription) ScaledPrice() Money {
+	return s.Price.Rescale(CurrencyExponent(s.Currency), MaxExponent)
+}
+
+// PriceChange sets the price of a subscription from EffectiveFrom (MM-YYYY) on.
+type PriceChange struct {
+	EffectiveFrom string `json:"effective_from"`
+	Price         Money  `json:"price" swaggertype:"string" example:"349.00"`
+}
+
+// PriceChangeJSON is a PriceChange as clients send and receive it, Price is
+// in the currency of the subscription.
+type PriceChangeJSON struct {
+	EffectiveFrom string  `json:"effective_from"`
+	Price         Decimal `json:"price" swaggertype:"string" example:"349.00"`
+}
+
+// PriceChange parses c in currency, a malformed price is a FieldError.
+func (c *PriceChangeJSON) PriceChange(currency string) (PriceChange, error) {
+	amounts := newAmountParser(currency)
+	price := amounts.parse("price", c.Price)
+
+	return PriceChange{EffectiveFrom: c.EffectiveFrom, Price: price}, amounts.err()
+}
+
+// subscriptionFields is Subscription without its JSON methods.
+type subscriptionFields Subscription
+
+// subscriptionJSON writes the amounts of a subscription in its currency.
+type subscriptionJSON struct {
+	subscriptionFields
+	Price      Decimal           `json:"price"`
+	TrialPrice Decimal           `json:"trial_price,omitempty"`
+	Prices     []PriceChangeJSON `json:"prices,omitempty"`
+	Members    []MemberJSON      `json:"members,omitempty"`
+}
+
+func (s Subscription) MarshalJSON() ([]byte, error) {
+	data := subscriptionJSON{
+		subscriptionFields: subscriptionFields(s),
+		Price:              s.Price.Decimal(s.Currency),
+		TrialPrice:         optionalDecimal(s.TrialPrice, s.Currency),
+	}
+
+	for _, change := range s.Prices {
+		data.Prices = append(data.Prices, PriceChangeJSON{
+			EffectiveFrom: change.EffectiveFrom,
+			Price:         change.Price.Decimal(s.Currency),
+		})
+	}
+
+	for _, member := range s.Members {
+		data.Members = append(data.Members, MemberJSON{
+			UserID: member.UserID,
+			Weight: member.Weight,
+			Amount: optionalDecimal(member.Amount, s.Currency),
+		})
+	}
+
+	return json.Marshal(data)
+}
+
+func (s *Subscription) UnmarshalJSON(data []byte) error {
+	subscription, err := DecodeSubscription(data, false)
+	if err != nil {
+		return err
+	}
+
+	*s = subscription
+	return nil
+}
+
+// DecodeSubscription reads a subscription with its amounts in its currency,
+// DefaultCurrency if empty. Malformed amounts are reported together as a
+// ValidationError, strict rejects unknown fields.
+func DecodeSubscription(data []byte, strict bool) (Subscription, error) {
+	var fields subscriptionJSON
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(&fields); err != nil {
+		return Subscription{}, err
+	}
+
+	subscription := Subscription(fields.subscriptionFields)
+	amounts := newAmountParser(subscription.Currency)
+
+	subscription.Price = amounts.parse("price", fields.Price)
+	subscription.TrialPrice = amounts.parse("trial_price", fields.TrialPrice)
+
+	subscription.Prices = nil
+	for i, change := range fields.Prices {
+		subscription.Prices = append(subscription.Prices, PriceChange{
+			EffectiveFrom: change.EffectiveFrom,
+			Price:         amounts.parse(fmt.Sprintf("prices[%d].price", i), change.Price),
+		})
+	}
+
+	subscription.Members = nil
+	for i, member := range fields.Members {
+		subscription.Members = append(subscription.Members, Member{
+			UserID: member.UserID,
+			Weight: member.Weight,
+			Amount: amounts.parse(fmt.Sprintf("members[%d].amount", i), member.Amount),
+		})
+	}
+
+	return subscription, amounts.err()
+}
+
+// PriceIn returns the price charged for month, the last change effective
+// at or before it or Price if there is none.
+func (s *Subscription) PriceIn(month time.Time) Money {
+	price := s.Price
+	for _, change := range s.Prices {
+		effective, err := ParseMonth(change.EffectiveFrom)
+		if err != nil || effective.After(month) {
+			break
+		}
+		price = change.Price
+	}
+
+	return price
 }
 
 type Counting struct {
@@ -14,8 +206,155 @@ type Counting struct {
 	EndDate     string `json:"end_date"`
 	UserID      string `json:"user_id"`
 	ServiceName string `json:"service_name"`
+	ServiceID   int    `json:"service_id,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	GroupBy     string `json:"group_by,omitempty"`
+	Top         int    `json:"top,omitempty"`
+
+	// IncludeDeleted counts soft-deleted subscriptions too.
+	IncludeDeleted bool `json:"include_deleted,omitempty"`
+
+	// TargetCurrency is the currency sums are converted to, DefaultCurrency if empty.
+	TargetCurrency string `json:"target_currency,omitempty"`
+
+	// Mode is CountAmortized or CountCharges, set from the mode query parameter.
+	Mode string `json:"-"`
+}
+
+const (
+	GroupByService  = "service_name"
+	GroupByUser     = "user_id"
+	GroupByCategory = "category"
+)
+
+// Groupings of spend besides the group_by values above, GroupByUser
+// grouping the shares by the user paying them.
+const (
+	GroupBySubscription = "subscription"
+	GroupByDebt         = "debt"
+)
+
+// SpendQuery is how spend is grouped: by month if ByMonth and by GroupBy if
+// it is set, only the Top groups with the largest totals if Top is set.
+type SpendQuery struct {
+	ByMonth bool
+	GroupBy string
+	Top     int
+}
+
+// SpendGroup is the spend of a group in the target currency and how many
+// subscriptions it counts. Month is set when grouping by month, Key to the
+// service name, category or paying user when grouping by them, and
+// SubscriptionID, ServiceName and Owner when grouping by subscription. A
+// GroupByDebt group is what Key pays Owner for SubscriptionIDs.
+type SpendGroup struct {
+	Month           string
+	Key             string
+	SubscriptionID  int
+	ServiceName     string
+	Owner           string
+	Total           Money
+	Subscriptions   int
+	SubscriptionIDs []int
+}
+
+// Spend is the spend grouped as a SpendQuery asks and the rates used,
+// ordered by month and currency. Amounts in a currency without a rate
+// count as 0, MissingRates lists every such currency with the first month
+// it has no rate in.
+type Spend struct {
+	Groups       []SpendGroup
+	Rates        []AppliedRate
+	MissingRates []MissingRate
+}
+
+type MissingRate struct {
+	Currency string
+	Month    string
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+type MonthlySpend struct {
+	Month         string `json:"month"`
+	Total         Money  `json:"total" swaggertype:"string" example:"1499.50"`
+	Subscriptions int    `json:"subscriptions"`
+}
+
+type GroupSpend struct {
+	Key           string `json:"key"`
+	Total         Money  `json:"total" swaggertype:"string" example:"1499.50"`
+	Subscriptions int    `json:"subscriptions"`
+}
+
+// SpendTotal is the result of /summ/, amounts are in Currency and rounded
+// as Rounding describes.
+type SpendTotal struct {
+	Total    Money         `json:"total" swaggertype:"string" example:"1499.50"`
+	Currency string        `json:"currency"`
+	Rates    []AppliedRate `json:"rates"`
+	Rounding Rounding      `json:"rounding"`
+	Mode     string        `json:"mode"`
+}
+
+type MonthlySpendReport struct {
+	Months   []MonthlySpend `json:"months"`
+	Currency string         `json:"currency"`
+	Rates    []AppliedRate  `json:"rates"`
+	Rounding Rounding       `json:"rounding"`
+	Mode     string         `json:"mode"`
+}
+
+type GroupSpendReport struct {
+	GroupBy  string        `json:"group_by"`
+	Groups   []GroupSpend  `json:"groups"`
+	Currency string        `json:"currency"`
+	Rates    []AppliedRate `json:"rates"`
+	Rounding Rounding      `json:"rounding"`
+	Mode     string        `json:"mode"`
+}
+
+func (t SpendTotal) MarshalJSON() ([]byte, error) {
+	type spendTotalFields SpendTotal
+	return json.Marshal(struct {
+		spendTotalFields
+		Total Decimal `json:"total"`
+	}{spendTotalFields(t), t.Total.Decimal(t.Currency)})
+}
+
+func (r MonthlySpendReport) MarshalJSON() ([]byte, error) {
+	type monthJSON struct {
+		MonthlySpend
+		Total Decimal `json:"total"`
+	}
+
+	type reportFields MonthlySpendReport
+	data := struct {
+		reportFields
+		Months []monthJSON `json:"months"`
+	}{reportFields: reportFields(r), Months: []monthJSON{}}
+
+	for _, month := range r.Months {
+		data.Months = append(data.Months, monthJSON{MonthlySpend: month, Total: month.Total.Decimal(r.Currency)})
+	}
+
+	return json.Marshal(data)
+}
+
+func (r GroupSpendReport) MarshalJSON() ([]byte, error) {
+	type groupJSON struct {
+		GroupSpend
+		Total Decimal `json:"total"`
+	}
+
+	type reportFields GroupSpendReport
+	data := struct {
+		reportFields
+		Groups []groupJSON `json:"groups"`
+	}{reportFields: reportFields(r), Groups: []groupJSON{}}
+
+	for _, group := range r.Groups {
+		data.Groups = append(data.Groups, groupJSON{GroupSpend: group, Total: group.Total.Decimal(r.Currency)})
+	}
+
+	return json.Marshal(data)
 }
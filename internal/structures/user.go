@@ -0,0 +1,47 @@
+package structures
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// User owns subscriptions, every Subscription.UserID refers to one.
+type User struct {
+	ID        string    `json:"id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserFilter struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+type UserPage struct {
+	Users []User `json:"users"`
+	Total int    `json:"total"`
+}
+
+// UserSummary describes the subscriptions of a user in the current month.
+// MonthlySpend is amortized and converted to Currency like /summ/monthly.
+type UserSummary struct {
+	UserID              string        `json:"user_id"`
+	Month               string        `json:"month"`
+	ActiveSubscriptions int           `json:"active_subscriptions"`
+	MonthlySpend        Money         `json:"monthly_spend" swaggertype:"string" example:"1499.50"`
+	Currency            string        `json:"currency"`
+	Rates               []AppliedRate `json:"rates"`
+	Rounding            Rounding      `json:"rounding"`
+
+	// NextRenewal is the first charge from today on, within a year.
+	NextRenewal *Renewal `json:"next_renewal,omitempty"`
+}
+
+func (s UserSummary) MarshalJSON() ([]byte, error) {
+	type summaryFields UserSummary
+	return json.Marshal(struct {
+		summaryFields
+		MonthlySpend Decimal `json:"monthly_spend"`
+	}{summaryFields(s), s.MonthlySpend.Decimal(s.Currency)})
+}